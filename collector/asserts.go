@@ -5,11 +5,11 @@ import (
 )
 
 var (
-	assertsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "asserts_total",
-		Help:      "The asserts document reports the number of asserts on the database. While assert errors are typically uncommon, if there are non-zero values for the asserts, you should check the log file for the mongod process for more information. In many cases these errors are trivial, but are worth investigating.",
-	}, []string{"type"})
+	assertsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "asserts_total"),
+		"The asserts document reports the number of asserts on the database. While assert errors are typically uncommon, if there are non-zero values for the asserts, you should check the log file for the mongod process for more information. In many cases these errors are trivial, but are worth investigating.",
+		[]string{"type"}, nil,
+	)
 )
 
 // AssertsStats has the assets metrics
@@ -23,15 +23,14 @@ type AssertsStats struct {
 
 // Export exports the metrics to prometheus.
 func (asserts *AssertsStats) Export(ch chan<- prometheus.Metric) {
-	assertsTotal.WithLabelValues("regular").Set(asserts.Regular)
-	assertsTotal.WithLabelValues("warning").Set(asserts.Warning)
-	assertsTotal.WithLabelValues("msg").Set(asserts.Msg)
-	assertsTotal.WithLabelValues("user").Set(asserts.User)
-	assertsTotal.WithLabelValues("rollovers").Set(asserts.Rollovers)
-	assertsTotal.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(assertsTotal, prometheus.GaugeValue, asserts.Regular, "regular")
+	ch <- prometheus.MustNewConstMetric(assertsTotal, prometheus.GaugeValue, asserts.Warning, "warning")
+	ch <- prometheus.MustNewConstMetric(assertsTotal, prometheus.GaugeValue, asserts.Msg, "msg")
+	ch <- prometheus.MustNewConstMetric(assertsTotal, prometheus.GaugeValue, asserts.User, "user")
+	ch <- prometheus.MustNewConstMetric(assertsTotal, prometheus.GaugeValue, asserts.Rollovers, "rollovers")
 }
 
 // Describe describes the metrics for prometheus
 func (asserts *AssertsStats) Describe(ch chan<- *prometheus.Desc) {
-	assertsTotal.Describe(ch)
+	ch <- assertsTotal
 }
@@ -0,0 +1,288 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	balancerModes = []string{"full", "autoSplitOnly", "off"}
+
+	balancerMode = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "mode"),
+		"The mode of the balancer from balancerStatus, 1 for the current mode: full, autoSplitOnly or off.",
+		[]string{"mode"}, nil,
+	)
+	balancerInRound = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "in_round"),
+		"Whether the balancer is running a balancing round.",
+		nil, nil,
+	)
+	balancerRounds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "rounds_total"),
+		"The total number of balancing rounds since the config server primary started.",
+		nil, nil,
+	)
+	balancerStopped = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "stopped"),
+		"Whether the balancer is stopped in config.settings.",
+		nil, nil,
+	)
+	balancerActiveWindow = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "active_window_info"),
+		"The window the balancer is restricted to in config.settings, if any.",
+		[]string{"start", "stop"}, nil,
+	)
+	balancerChunkSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "chunk_size_bytes"),
+		"The chunk size set in config.settings, the default is used when it isn't set.",
+		nil, nil,
+	)
+	balancerChunks = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "chunks"),
+		"The number of chunks of each sharded collection on each shard.",
+		[]string{"ns", "shard"}, nil,
+	)
+	balancerJumboChunks = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "jumbo_chunks"),
+		"The number of chunks of each sharded collection on each shard which are flagged as jumbo.",
+		[]string{"ns", "shard"}, nil,
+	)
+	balancerImbalanceRatio = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "balancer", "chunk_imbalance_ratio"),
+		"The largest number of chunks of each sharded collection on a shard divided by the smallest one, at least 1. Shards without chunks of the collection count as 1.",
+		[]string{"ns"}, nil,
+	)
+)
+
+// BalancerStatus is the result of balancerStatus.
+type BalancerStatus struct {
+	Mode              string `bson:"mode"`
+	InBalancerRound   bool   `bson:"inBalancerRound"`
+	NumBalancerRounds int64  `bson:"numBalancerRounds"`
+}
+
+// BalancerSettings are the balancer and chunksize documents of config.settings.
+type BalancerSettings struct {
+	Stopped      bool `bson:"stopped"`
+	ActiveWindow *struct {
+		Start string `bson:"start"`
+		Stop  string `bson:"stop"`
+	} `bson:"activeWindow"`
+	// ChunkSizeMB is zero when the default chunk size is used.
+	ChunkSizeMB float64
+}
+
+// ChunkDistribution is the number of chunks of each sharded collection on each shard.
+type ChunkDistribution struct {
+	Shards []string
+	// Chunks and JumboChunks are by namespace and shard.
+	Chunks      map[string]map[string]float64
+	JumboChunks map[string]map[string]float64
+}
+
+// Export exports the balancer status to be consumed by prometheus.
+func (status *BalancerStatus) Export(ch chan<- prometheus.Metric) {
+	for _, mode := range balancerModes {
+		ch <- prometheus.MustNewConstMetric(balancerMode, prometheus.GaugeValue, boolToFloat64(mode == status.Mode), mode)
+	}
+	ch <- prometheus.MustNewConstMetric(balancerInRound, prometheus.GaugeValue, boolToFloat64(status.InBalancerRound))
+	ch <- prometheus.MustNewConstMetric(balancerRounds, prometheus.CounterValue, float64(status.NumBalancerRounds))
+}
+
+// Export exports the balancer settings to be consumed by prometheus.
+func (settings *BalancerSettings) Export(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(balancerStopped, prometheus.GaugeValue, boolToFloat64(settings.Stopped))
+	if settings.ActiveWindow != nil {
+		ch <- prometheus.MustNewConstMetric(balancerActiveWindow, prometheus.GaugeValue, 1, settings.ActiveWindow.Start, settings.ActiveWindow.Stop)
+	}
+	if settings.ChunkSizeMB > 0 {
+		ch <- prometheus.MustNewConstMetric(balancerChunkSize, prometheus.GaugeValue, settings.ChunkSizeMB*1024*1024)
+	}
+}
+
+// Export exports the chunk distribution to be consumed by prometheus.
+func (distribution *ChunkDistribution) Export(ch chan<- prometheus.Metric) {
+	for ns, shards := range distribution.Chunks {
+		for shard, chunks := range shards {
+			ch <- prometheus.MustNewConstMetric(balancerChunks, prometheus.GaugeValue, chunks, ns, shard)
+			ch <- prometheus.MustNewConstMetric(balancerJumboChunks, prometheus.GaugeValue, distribution.JumboChunks[ns][shard], ns, shard)
+		}
+		ch <- prometheus.MustNewConstMetric(balancerImbalanceRatio, prometheus.GaugeValue, chunkImbalanceRatio(shards, distribution.Shards), ns)
+	}
+}
+
+// chunkImbalanceRatio divides the largest number of chunks on a shard by the smallest one, where
+// the shards without chunks count as having one so that the ratio stays finite.
+func chunkImbalanceRatio(chunks map[string]float64, shards []string) float64 {
+	if len(shards) == 0 {
+		return 1
+	}
+	max, min := math.Inf(-1), math.Inf(1)
+	for _, shard := range shards {
+		count := math.Max(chunks[shard], 1)
+		max = math.Max(max, count)
+		min = math.Min(min, count)
+	}
+	return max / min
+}
+
+// GetBalancerStatus returns the status of the balancer, only known by mongos routers and the
+// config servers.
+func GetBalancerStatus(session *mgo.Session) (*BalancerStatus, error) {
+	result := &BalancerStatus{}
+	err := session.DB("admin").Run(bson.D{{Name: "balancerStatus", Value: 1}}, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetBalancerSettings returns the balancer settings of config.settings.
+func GetBalancerSettings(session *mgo.Session) (*BalancerSettings, error) {
+	settings := &BalancerSettings{}
+	settingsCollection := session.DB("config").C("settings")
+	if err := settingsCollection.FindId("balancer").One(settings); err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+
+	chunkSize := struct {
+		Value float64 `bson:"value"`
+	}{}
+	if err := settingsCollection.FindId("chunksize").One(&chunkSize); err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+	settings.ChunkSizeMB = chunkSize.Value
+	return settings, nil
+}
+
+// GetChunkDistribution counts the chunks of config.chunks by collection and shard. Since MongoDB
+// 5.0 the chunks refer to their collection by UUID, which is resolved with config.collections.
+func GetChunkDistribution(session *mgo.Session, maxTimeMS int64) (*ChunkDistribution, error) {
+	var shards []shardEntry
+	if err := session.DB("config").C("shards").Find(nil).All(&shards); err != nil {
+		return nil, fmt.Errorf("couldn't list the shards: %s", err)
+	}
+
+	var collections []struct {
+		ID   string      `bson:"_id"`
+		UUID bson.Binary `bson:"uuid"`
+	}
+	if err := session.DB("config").C("collections").Find(nil).Select(bson.M{"_id": 1, "uuid": 1}).All(&collections); err != nil {
+		return nil, fmt.Errorf("couldn't list the sharded collections: %s", err)
+	}
+	namespaces := make(map[string]string, len(collections))
+	for _, collection := range collections {
+		namespaces[string(collection.UUID.Data)] = collection.ID
+	}
+
+	pipeline := []bson.M{
+		{"$group": bson.M{
+			"_id":    bson.M{"ns": "$ns", "uuid": "$uuid", "shard": "$shard"},
+			"chunks": bson.M{"$sum": 1},
+			"jumbo":  bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{"$jumbo", true}}, 1, 0}}},
+		}},
+	}
+	pipe := session.DB("config").C("chunks").Pipe(pipeline).AllowDiskUse()
+	if maxTimeMS > 0 {
+		pipe = pipe.SetMaxTime(time.Duration(maxTimeMS) * time.Millisecond)
+	}
+	var groups []struct {
+		ID struct {
+			NS    string      `bson:"ns"`
+			UUID  bson.Binary `bson:"uuid"`
+			Shard string      `bson:"shard"`
+		} `bson:"_id"`
+		Chunks float64 `bson:"chunks"`
+		Jumbo  float64 `bson:"jumbo"`
+	}
+	if err := pipe.All(&groups); err != nil {
+		return nil, fmt.Errorf("couldn't count the chunks: %s", err)
+	}
+
+	distribution := &ChunkDistribution{
+		Chunks:      make(map[string]map[string]float64),
+		JumboChunks: make(map[string]map[string]float64),
+	}
+	for _, shard := range shards {
+		distribution.Shards = append(distribution.Shards, shard.ID)
+	}
+	for _, group := range groups {
+		ns := group.ID.NS
+		if ns == "" {
+			ns = namespaces[string(group.ID.UUID.Data)]
+		}
+		if ns == "" {
+			continue
+		}
+		if distribution.Chunks[ns] == nil {
+			distribution.Chunks[ns] = make(map[string]float64)
+			distribution.JumboChunks[ns] = make(map[string]float64)
+		}
+		distribution.Chunks[ns][group.ID.Shard] += group.Chunks
+		distribution.JumboChunks[ns][group.ID.Shard] += group.Jumbo
+	}
+	return distribution, nil
+}
+
+func init() {
+	Register("balancer", newBalancerCollector, false)
+}
+
+// balancerCollector exports the state of the balancer and how the chunks are spread over the
+// shards. It runs on mongos routers, where config.chunks can be aggregated.
+type balancerCollector struct {
+	maxTimeMS int64
+}
+
+func newBalancerCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &balancerCollector{maxTimeMS: opts.MaxTimeMS}, nil
+}
+
+func (c *balancerCollector) runsOnRouter() bool {
+	return true
+}
+
+func (c *balancerCollector) clusterWide() bool {
+	return true
+}
+
+func (c *balancerCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	// balancerStatus only exists since MongoDB 3.4.
+	status, err := GetBalancerStatus(session)
+	if err == nil {
+		status.Export(ch)
+	} else if errorCode(err) != "command_not_found" {
+		return err
+	}
+
+	settings, err := GetBalancerSettings(session)
+	if err != nil {
+		return err
+	}
+	settings.Export(ch)
+
+	distribution, err := GetChunkDistribution(session, c.maxTimeMS)
+	if err != nil {
+		return err
+	}
+	distribution.Export(ch)
+	return nil
+}
+
+func (c *balancerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- balancerMode
+	ch <- balancerInRound
+	ch <- balancerRounds
+	ch <- balancerStopped
+	ch <- balancerActiveWindow
+	ch <- balancerChunkSize
+	ch <- balancerChunks
+	ch <- balancerJumboChunks
+	ch <- balancerImbalanceRatio
+}
@@ -0,0 +1,25 @@
+package collector
+
+import "testing"
+
+func Test_ChunkImbalanceRatio(t *testing.T) {
+	shards := []string{"shard0", "shard1", "shard2"}
+	cases := []struct {
+		chunks   map[string]float64
+		expected float64
+	}{
+		{map[string]float64{"shard0": 10, "shard1": 10, "shard2": 10}, 1},
+		{map[string]float64{"shard0": 30, "shard1": 10, "shard2": 15}, 3},
+		{map[string]float64{"shard0": 100}, 100},
+		{map[string]float64{"shard0": 1}, 1},
+		{map[string]float64{}, 1},
+	}
+	for _, c := range cases {
+		if ratio := chunkImbalanceRatio(c.chunks, shards); ratio != c.expected {
+			t.Errorf("%v: expected %f, got %f", c.chunks, c.expected, ratio)
+		}
+	}
+	if ratio := chunkImbalanceRatio(map[string]float64{"shard0": 5}, nil); ratio != 1 {
+		t.Errorf("expected 1 without shards, got %f", ratio)
+	}
+}
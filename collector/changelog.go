@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// changelogBatchSize is the most config.changelog entries read by one update, the rest are read
+// by the next ones.
+const changelogBatchSize = 10000
+
+// changelogEvents are the config.changelog events which are counted.
+var changelogEvents = map[string]bool{
+	"moveChunk.start":  true,
+	"moveChunk.commit": true,
+	"moveChunk.error":  true,
+	"moveChunk.from":   true,
+	"moveChunk.to":     true,
+	"split":            true,
+	"multi-split":      true,
+	"dropCollection":   true,
+}
+
+// migrationStepRegexp matches the details of the moveChunk.from and moveChunk.to events holding
+// the milliseconds spent in each step of a migration, e.g. "step 3 of 6".
+var migrationStepRegexp = regexp.MustCompile(`^step (\d+) of \d+$`)
+
+// changelogEntry is a document of config.changelog.
+type changelogEntry struct {
+	ID      interface{} `bson:"_id"`
+	Time    time.Time   `bson:"time"`
+	What    string      `bson:"what"`
+	NS      string      `bson:"ns"`
+	Shard   string      `bson:"shard"`
+	Details bson.M      `bson:"details"`
+}
+
+func init() {
+	Register("changelog", newChangelogCollector, false)
+}
+
+// changelogCollector counts the chunk migrations, splits and collection drops of the whole cluster
+// as they are added to config.changelog. It starts from the newest entry when it first runs, and
+// remembers the last entry it saw.
+type changelogCollector struct {
+	maxTimeMS int64
+
+	lock     sync.Mutex
+	started  bool
+	lastTime time.Time
+	// lastIDs are the entries seen with lastTime, which may be followed by others with the same
+	// time since it only has millisecond precision.
+	lastIDs map[interface{}]bool
+
+	events        *prometheus.CounterVec
+	stepDurations *prometheus.HistogramVec
+}
+
+func newChangelogCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &changelogCollector{
+		maxTimeMS: opts.MaxTimeMS,
+		lastIDs:   make(map[interface{}]bool),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "changelog",
+			Name:      "events_total",
+			Help:      "The total number of chunk migration, split and drop events in config.changelog since the exporter started, by namespace and shard.",
+		}, []string{"what", "ns", "shard"}),
+		stepDurations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "changelog",
+			Name:      "migration_step_duration_seconds",
+			Help:      "How long each step of the chunk migrations took on the donor (moveChunk.from) and recipient (moveChunk.to) shards.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 9),
+		}, []string{"what", "step"}),
+	}, nil
+}
+
+func (c *changelogCollector) runsOnRouter() bool {
+	return true
+}
+
+func (c *changelogCollector) clusterWide() bool {
+	return true
+}
+
+func (c *changelogCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	changelog := session.DB("config").C("changelog")
+	if !c.started {
+		newest := changelogEntry{}
+		err := changelog.Find(nil).Sort("-time").Select(bson.M{"_id": 1, "time": 1}).One(&newest)
+		if err != nil && err != mgo.ErrNotFound {
+			return err
+		}
+		c.started = true
+		c.lastTime = newest.Time
+		if newest.ID != nil {
+			c.lastIDs[newest.ID] = true
+		}
+	}
+
+	query := changelog.Find(bson.M{"time": bson.M{"$gte": c.lastTime}}).Sort("time").Limit(changelogBatchSize)
+	if c.maxTimeMS > 0 {
+		query = query.SetMaxTime(time.Duration(c.maxTimeMS) * time.Millisecond)
+	}
+	var entries []changelogEntry
+	if err := query.All(&entries); err != nil {
+		return err
+	}
+	c.observe(entries)
+
+	c.events.Collect(ch)
+	c.stepDurations.Collect(ch)
+	return nil
+}
+
+// observe counts the entries which weren't seen before. They must be sorted by time. The caller
+// must hold c.lock.
+func (c *changelogCollector) observe(entries []changelogEntry) {
+	for _, entry := range entries {
+		if entry.Time.Before(c.lastTime) || (entry.Time.Equal(c.lastTime) && c.lastIDs[entry.ID]) {
+			continue
+		}
+		if entry.Time.After(c.lastTime) {
+			c.lastTime = entry.Time
+			c.lastIDs = make(map[interface{}]bool)
+		}
+		c.lastIDs[entry.ID] = true
+
+		if !changelogEvents[entry.What] {
+			continue
+		}
+		c.events.WithLabelValues(entry.What, entry.NS, entry.Shard).Inc()
+
+		if entry.What != "moveChunk.from" && entry.What != "moveChunk.to" {
+			continue
+		}
+		for key, value := range entry.Details {
+			step := migrationStepRegexp.FindStringSubmatch(key)
+			if step == nil {
+				continue
+			}
+			if millis, ok := metricValue(value); ok {
+				c.stepDurations.WithLabelValues(entry.What, step[1]).Observe(millis / 1000)
+			}
+		}
+	}
+}
+
+func (c *changelogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.events.Describe(ch)
+	c.stepDurations.Describe(ch)
+}
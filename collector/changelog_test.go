@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_ChangelogObserve(t *testing.T) {
+	collector, _ := newChangelogCollector(MongodbCollectorOpts{})
+	c := collector.(*changelogCollector)
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.started, c.lastTime, c.lastIDs = true, start, map[interface{}]bool{"seen": true}
+
+	entries := []changelogEntry{
+		{ID: "seen", Time: start, What: "split", NS: "db.coll", Shard: "shard0"},
+		{ID: "same-time", Time: start, What: "split", NS: "db.coll", Shard: "shard0"},
+		{ID: "from", Time: start.Add(time.Second), What: "moveChunk.from", NS: "db.coll", Shard: "shard0",
+			Details: bson.M{"step 1 of 6": 0, "step 2 of 6": 1500, "to": "shard1", "note": "success"}},
+		{ID: "ignored", Time: start.Add(2 * time.Second), What: "addShard"},
+	}
+	c.observe(entries)
+	// Reading the same entries again counts nothing more.
+	c.observe(entries[2:])
+
+	if splits := counterValue(t, c, "split", "db.coll", "shard0"); splits != 1 {
+		t.Errorf("expected 1 split, got %f", splits)
+	}
+	if moves := counterValue(t, c, "moveChunk.from", "db.coll", "shard0"); moves != 1 {
+		t.Errorf("expected 1 moveChunk.from, got %f", moves)
+	}
+
+	histogram := &dto.Metric{}
+	if err := c.stepDurations.WithLabelValues("moveChunk.from", "2").Write(histogram); err != nil {
+		t.Fatal(err)
+	}
+	if histogram.Histogram.GetSampleCount() != 1 || histogram.Histogram.GetSampleSum() != 1.5 {
+		t.Errorf("unexpected step 2 durations %v", histogram.Histogram)
+	}
+	if !c.lastTime.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("expected the last time to move to the newest entry, got %s", c.lastTime)
+	}
+}
+
+func counterValue(t *testing.T, c *changelogCollector, labels ...string) float64 {
+	metric := &dto.Metric{}
+	if err := c.events.WithLabelValues(labels...).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.Counter.GetValue()
+}
@@ -1,9 +1,9 @@
 package collector
 
 import (
+	"fmt"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	mgo "github.com/globalsign/mgo"
@@ -13,43 +13,35 @@ import (
 )
 
 var (
-	count = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "collection",
-		Name:      "total_objects",
-		Help:      "The number of objects or documents in this collection",
-	}, []string{"ns"})
-
-	size = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "collection",
-		Name:      "size_bytes",
-		Help:      "The total size in memory of all records in a collection",
-	}, []string{"ns"})
-
-	avgObjSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "collection",
-		Name:      "avg_objsize_bytes",
-		Help:      "The average size of an object in the collection",
-	}, []string{"ns"})
-
-	storageSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "collection",
-		Name:      "storage_size_bytes",
-		Help:      "The total amount of storage allocated to this collection for document storage",
-	}, []string{"ns"})
-
-	collIndexSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "collection",
-		Name:      "index_size_bytes",
-		Help:      "The total size of all indexes",
-	}, []string{"ns"})
-
-	// Lock for using these metrics
-	collectionStatsLock = sync.Mutex{}
+	count = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "collection", "total_objects"),
+		"The number of objects or documents in this collection",
+		[]string{"ns"}, nil,
+	)
+
+	size = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "collection", "size_bytes"),
+		"The total size in memory of all records in a collection",
+		[]string{"ns"}, nil,
+	)
+
+	avgObjSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "collection", "avg_objsize_bytes"),
+		"The average size of an object in the collection",
+		[]string{"ns"}, nil,
+	)
+
+	storageSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "collection", "storage_size_bytes"),
+		"The total amount of storage allocated to this collection for document storage",
+		[]string{"ns"}, nil,
+	)
+
+	collIndexSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "collection", "index_size_bytes"),
+		"The total size of all indexes",
+		[]string{"ns"}, nil,
+	)
 )
 
 type CollectionStatus struct {
@@ -62,45 +54,29 @@ type CollectionStatus struct {
 }
 
 func (collStatus *CollectionStatus) Export(ch chan<- prometheus.Metric) {
-	collectionStatsLock.Lock()
-	defer collectionStatsLock.Unlock()
-
-	count.WithLabelValues(collStatus.Name).Set(float64(collStatus.Count))
-	size.WithLabelValues(collStatus.Name).Set(float64(collStatus.Size))
-	avgObjSize.WithLabelValues(collStatus.Name).Set(float64(collStatus.AvgSize))
-	storageSize.WithLabelValues(collStatus.Name).Set(float64(collStatus.StorageSize))
-	collIndexSize.WithLabelValues(collStatus.Name).Set(float64(collStatus.IndexSize))
-
-	count.Collect(ch)
-	size.Collect(ch)
-	avgObjSize.Collect(ch)
-	storageSize.Collect(ch)
-	collIndexSize.Collect(ch)
-
-	count.Reset()
-	size.Reset()
-	avgObjSize.Reset()
-	storageSize.Reset()
-	collIndexSize.Reset()
+	ch <- prometheus.MustNewConstMetric(count, prometheus.GaugeValue, float64(collStatus.Count), collStatus.Name)
+	ch <- prometheus.MustNewConstMetric(size, prometheus.GaugeValue, float64(collStatus.Size), collStatus.Name)
+	ch <- prometheus.MustNewConstMetric(avgObjSize, prometheus.GaugeValue, float64(collStatus.AvgSize), collStatus.Name)
+	ch <- prometheus.MustNewConstMetric(storageSize, prometheus.GaugeValue, float64(collStatus.StorageSize), collStatus.Name)
+	ch <- prometheus.MustNewConstMetric(collIndexSize, prometheus.GaugeValue, float64(collStatus.IndexSize), collStatus.Name)
 }
 
 func (collStatus *CollectionStatus) Describe(ch chan<- *prometheus.Desc) {
-	count.Describe(ch)
-	size.Describe(ch)
-	avgObjSize.Describe(ch)
-	storageSize.Describe(ch)
-	collIndexSize.Describe(ch)
+	ch <- count
+	ch <- size
+	ch <- avgObjSize
+	ch <- storageSize
+	ch <- collIndexSize
 }
 
-func GetCollectionStatus(session *mgo.Session, db string, collection string, maxTimeMS int64) *CollectionStatus {
+func GetCollectionStatus(session *mgo.Session, db string, collection string, maxTimeMS int64) (*CollectionStatus, error) {
 	var collStatus CollectionStatus
-	err := session.DB(db).Run(bson.D{{"collStats", collection}, {"scale", 1}, {"maxTimeMS", maxTimeMS}}, &collStatus)
+	err := session.DB(db).Run(bson.D{{Name: "collStats", Value: collection}, {Name: "scale", Value: 1}, {Name: "maxTimeMS", Value: maxTimeMS}}, &collStatus)
 	if err != nil {
-		glog.Error(err)
-		return nil
+		return nil, err
 	}
 
-	return &collStatus
+	return &collStatus, nil
 }
 
 type CursorData struct {
@@ -174,17 +150,55 @@ func GetCollectionNames(session *mgo.Session, dbname string, maxTimeMS int64) (n
 	return names, nil
 }
 
-func CollectCollectionStatus(session *mgo.Session, db string, ch chan<- prometheus.Metric, maxTimeMS int64) {
+func CollectCollectionStatus(session *mgo.Session, db string, ch chan<- prometheus.Metric, maxTimeMS int64) error {
 	collection_names, err := GetCollectionNames(session, db, maxTimeMS)
 	if err != nil {
-		glog.Error("Failed to get collection names for db=" + db)
-		return
+		return fmt.Errorf("Failed to get collection names for db=%s: %s", db, err)
 	}
+	var lastErr error
 	for _, collection_name := range collection_names {
-		collStats := GetCollectionStatus(session, db, collection_name, maxTimeMS)
-		if collStats != nil {
-			glog.V(1).Infof("exporting Database Metrics for db=%q, table=%q", db, collection_name)
-			collStats.Export(ch)
+		collStats, err := GetCollectionStatus(session, db, collection_name, maxTimeMS)
+		if err != nil {
+			glog.Errorf("Failed to get collection stats for db=%q, table=%q: %s", db, collection_name, err)
+			lastErr = err
+			continue
+		}
+		glog.V(1).Infof("exporting Database Metrics for db=%q, table=%q", db, collection_name)
+		collStats.Export(ch)
+	}
+	return lastErr
+}
+
+func init() {
+	Register("collection", newCollectionCollector, false)
+}
+
+type collectionCollector struct {
+	maxTimeMS int64
+}
+
+func newCollectionCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &collectionCollector{maxTimeMS: opts.MaxTimeMS}, nil
+}
+
+func (c *collectionCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	database_names, err := session.DatabaseNames()
+	if err != nil {
+		return fmt.Errorf("Failed to get database names: %s", err)
+	}
+	var lastErr error
+	for _, db := range database_names {
+		if db == "admin" || db == "test" {
+			continue
+		}
+		if err := CollectCollectionStatus(session, db, ch, c.maxTimeMS); err != nil {
+			glog.Error(err)
+			lastErr = err
 		}
 	}
+	return lastErr
+}
+
+func (c *collectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&CollectionStatus{}).Describe(ch)
 }
@@ -3,46 +3,40 @@ package collector
 import (
 	mgo "github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // server connections -- all of these!
 var (
-	syncClientConnections = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "connection_sync",
-		Help:      "Corresponds to the total number of client connections to mongo.",
-	})
-
-	numAScopedConnections = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "connections_scoped_sync",
-		Help:      "Corresponds to the number of active and stored outgoing scoped synchronous connections from the current instance to other members of the sharded cluster or replica set.",
-	})
-
-	totalInUse = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "connections_in_use",
-		Help:      "Corresponds to the total number of client connections to mongo currently in use.",
-	})
-
-	totalAvailable = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "connections_available",
-		Help:      "Corresponds to the total number of client connections to mongo that are currently available.",
-	})
-
-	totalCreated = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "connections_created_total",
-		Help:      "Corresponds to the total number of client connections to mongo created since instance start",
-	})
+	syncClientConnections = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "connection_sync"),
+		"Corresponds to the total number of client connections to mongo.",
+		nil, nil,
+	)
+
+	numAScopedConnections = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "connections_scoped_sync"),
+		"Corresponds to the number of active and stored outgoing scoped synchronous connections from the current instance to other members of the sharded cluster or replica set.",
+		nil, nil,
+	)
+
+	totalInUse = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "connections_in_use"),
+		"Corresponds to the total number of client connections to mongo currently in use.",
+		nil, nil,
+	)
+
+	totalAvailable = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "connections_available"),
+		"Corresponds to the total number of client connections to mongo that are currently available.",
+		nil, nil,
+	)
+
+	totalCreated = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "connections_created_total"),
+		"Corresponds to the total number of client connections to mongo created since instance start",
+		nil, nil,
+	)
 )
 
 // ServerStatus keeps the data returned by the serverStatus() method.
@@ -53,26 +47,21 @@ type ConnPoolStats struct {
 	TotalAvailable        float64 `bson:"totalAvailable"`
 	TotalCreated          float64 `bson:"totalCreated"`
 
-	Hosts       map[string]*HostConnPoolStats `bson:hosts"`
+	Hosts       map[string]*HostConnPoolStats `bson:"hosts"`
 	ReplicaSets map[string]ReplicaSetStats    `bson:"replicaSets"`
 }
 
 // Export exports the server status to be consumed by prometheus.
 func (stats *ConnPoolStats) Export(ch chan<- prometheus.Metric) {
-	syncClientConnections.Set(stats.SyncClientConnections)
-	syncClientConnections.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(syncClientConnections, prometheus.GaugeValue, stats.SyncClientConnections)
 
-	numAScopedConnections.Set(stats.ASScopedConnections)
-	numAScopedConnections.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(numAScopedConnections, prometheus.GaugeValue, stats.ASScopedConnections)
 
-	totalInUse.Set(stats.TotalInUse)
-	totalInUse.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(totalInUse, prometheus.GaugeValue, stats.TotalInUse)
 
-	totalAvailable.Set(stats.TotalAvailable)
-	totalAvailable.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(totalAvailable, prometheus.GaugeValue, stats.TotalAvailable)
 
-	totalCreated.Set(stats.TotalCreated)
-	totalCreated.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(totalCreated, prometheus.GaugeValue, stats.TotalCreated)
 
 	for hostname, hostStat := range stats.Hosts {
 		hostStat.Export(hostname, ch)
@@ -84,28 +73,50 @@ func (stats *ConnPoolStats) Export(ch chan<- prometheus.Metric) {
 
 // Describe describes the server status for prometheus.
 func (stats *ConnPoolStats) Describe(ch chan<- *prometheus.Desc) {
-	syncClientConnections.Describe(ch)
+	ch <- syncClientConnections
 
-	numAScopedConnections.Describe(ch)
+	ch <- numAScopedConnections
 
-	totalInUse.Describe(ch)
+	ch <- totalInUse
 
-	totalAvailable.Describe(ch)
+	ch <- totalAvailable
 
-	totalCreated.Describe(ch)
+	ch <- totalCreated
 
 	for _, hostStat := range stats.Hosts {
 		hostStat.Describe(ch)
 	}
 }
 
-// GetServerStatus returns the server status info.
-func GetConnPoolStats(session *mgo.Session) *ConnPoolStats {
+// GetConnPoolStats returns the connection pool stats.
+func GetConnPoolStats(session *mgo.Session) (*ConnPoolStats, error) {
 	result := &ConnPoolStats{}
-	err := session.DB("admin").Run(bson.D{{"connPoolStats", 1}, {"recordStats", 0}}, result)
+	err := session.DB("admin").Run(bson.D{{Name: "connPoolStats", Value: 1}, {Name: "recordStats", Value: 0}}, result)
 	if err != nil {
-		glog.Error("Failed to get server status.")
-		return nil
+		return nil, err
 	}
-	return result
+	return result, nil
+}
+
+func init() {
+	Register("connpoolstats", newConnPoolStatsCollector, false)
+}
+
+type connPoolStatsCollector struct{}
+
+func newConnPoolStatsCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &connPoolStatsCollector{}, nil
+}
+
+func (c *connPoolStatsCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	connPoolStats, err := GetConnPoolStats(session)
+	if err != nil {
+		return err
+	}
+	connPoolStats.Export(ch)
+	return nil
+}
+
+func (c *connPoolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&ConnPoolStats{}).Describe(ch)
 }
@@ -1,38 +1,27 @@
 package collector
 
 import (
-	"sync"
-
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	inUse = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "in_use",
-		Help:      "Corresponds to the total number of client connections to mongo.",
-		// TODO: tags
-	}, []string{"host"})
-
-	available = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		// TODO
-		Name: "available",
-		Help: "Corresponds to the total number of client connections to mongo.",
-	}, []string{"host"})
-
-	created = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		// TODO
-		Name: "created",
-		Help: "Corresponds to the total number of client connections to mongo.",
-	}, []string{"host"})
-
-	// Lock for using these metrics
-	connPoolStatsLock = sync.Mutex{}
+	inUse = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "in_use"),
+		"Corresponds to the total number of client connections to mongo.",
+		[]string{"host"}, nil,
+	)
+
+	available = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "available"),
+		"Corresponds to the total number of client connections to mongo.",
+		[]string{"host"}, nil,
+	)
+
+	created = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "created"),
+		"Corresponds to the total number of client connections to mongo.",
+		[]string{"host"}, nil,
+	)
 )
 
 // ServerStatus keeps the data returned by the serverStatus() method.
@@ -44,27 +33,18 @@ type HostConnPoolStats struct {
 
 // Export exports the server status to be consumed by prometheus.
 func (stats *HostConnPoolStats) Export(hostname string, ch chan<- prometheus.Metric) {
-	connPoolStatsLock.Lock()
-	defer connPoolStatsLock.Unlock()
-
-	inUse.WithLabelValues(hostname).Set(float64(stats.InUse))
-	inUse.Collect(ch)
-	inUse.Reset()
+	ch <- prometheus.MustNewConstMetric(inUse, prometheus.GaugeValue, float64(stats.InUse), hostname)
 
-	available.WithLabelValues(hostname).Set(float64(stats.Available))
-	available.Collect(ch)
-	available.Reset()
+	ch <- prometheus.MustNewConstMetric(available, prometheus.GaugeValue, float64(stats.Available), hostname)
 
-	created.WithLabelValues(hostname).Set(float64(stats.Created))
-	created.Collect(ch)
-	created.Reset()
+	ch <- prometheus.MustNewConstMetric(created, prometheus.GaugeValue, float64(stats.Created), hostname)
 }
 
 // Describe describes the server status for prometheus.
 func (stats *HostConnPoolStats) Describe(ch chan<- *prometheus.Desc) {
-	inUse.Describe(ch)
+	ch <- inUse
 
-	available.Describe(ch)
+	ch <- available
 
-	created.Describe(ch)
+	ch <- created
 }
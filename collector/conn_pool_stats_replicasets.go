@@ -1,22 +1,17 @@
 package collector
 
 import (
-	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	pingTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connpoolstats",
-		Name:      "ping_time_seconds",
-		Help:      "Corresponds to the ping time from this mongos to the corresponding host in seconds",
-	}, []string{"host", "rs"})
-
-	// Lock for using these metrics
-	connPoolReplicaSetStatsLock = sync.Mutex{}
+	pingTime = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connpoolstats", "ping_time_seconds"),
+		"Corresponds to the ping time from this mongos to the corresponding host in seconds",
+		[]string{"host", "rs"}, nil,
+	)
 )
 
 type ReplicaSetHostStats struct {
@@ -30,17 +25,12 @@ type ReplicaSetStats struct {
 
 // Export exports the server status to be consumed by prometheus.
 func (stats *ReplicaSetStats) Export(replicaSet string, ch chan<- prometheus.Metric) {
-	connPoolReplicaSetStatsLock.Lock()
-	defer connPoolReplicaSetStatsLock.Unlock()
-
 	for _, rsHostStat := range stats.Hosts {
-		pingTime.WithLabelValues(rsHostStat.Host, replicaSet).Set(rsHostStat.PingTime / float64(time.Second/time.Millisecond))
-		pingTime.Collect(ch)
-		pingTime.Reset()
+		ch <- prometheus.MustNewConstMetric(pingTime, prometheus.GaugeValue, rsHostStat.PingTime/float64(time.Second/time.Millisecond), rsHostStat.Host, replicaSet)
 	}
 }
 
 // Describe describes the server status for prometheus.
 func (stats *ReplicaSetStats) Describe(ch chan<- *prometheus.Desc) {
-	pingTime.Describe(ch)
+	ch <- pingTime
 }
@@ -5,19 +5,18 @@ import (
 )
 
 var (
-	connections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "connections",
-		Help:      "The connections sub document data regarding the current status of incoming connections and availability of the database server. Use these values to assess the current load and capacity requirements of the server",
-	}, []string{"state"})
+	connections = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "connections"),
+		"The connections sub document data regarding the current status of incoming connections and availability of the database server. Use these values to assess the current load and capacity requirements of the server",
+		[]string{"state"}, nil,
+	)
 )
 var (
-	connectionsMetricsCreatedTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "connections_metrics",
-		Name:      "created_total",
-		Help:      "totalCreated provides a count of all incoming connections created to the server. This number includes connections that have since closed",
-	})
+	connectionsMetricsCreatedTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "connections_metrics", "created_total"),
+		"totalCreated provides a count of all incoming connections created to the server. This number includes connections that have since closed",
+		nil, nil,
+	)
 )
 
 // ConnectionStats are connections metrics
@@ -29,16 +28,14 @@ type ConnectionStats struct {
 
 // Export exports the data to prometheus.
 func (connectionStats *ConnectionStats) Export(ch chan<- prometheus.Metric) {
-	connections.WithLabelValues("current").Set(connectionStats.Current)
-	connections.WithLabelValues("available").Set(connectionStats.Available)
-	connections.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(connections, prometheus.GaugeValue, connectionStats.Current, "current")
+	ch <- prometheus.MustNewConstMetric(connections, prometheus.GaugeValue, connectionStats.Available, "available")
 
-	connectionsMetricsCreatedTotal.Set(connectionStats.TotalCreated)
-	connectionsMetricsCreatedTotal.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(connectionsMetricsCreatedTotal, prometheus.GaugeValue, connectionStats.TotalCreated)
 }
 
 // Describe describes the metrics for prometheus
 func (connectionStats *ConnectionStats) Describe(ch chan<- *prometheus.Desc) {
-	connections.Describe(ch)
-	connectionsMetricsCreatedTotal.Describe(ch)
+	ch <- connections
+	ch <- connectionsMetricsCreatedTotal
 }
@@ -3,46 +3,63 @@ package collector
 import (
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	instanceFsyncLockWorker = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "instance",
-		Name:      "fsync_lock_worker",
-		Help:      "The value of the fsync field corresponds to whether the fsyncLockWorker is active or not.",
-	})
+	instanceFsyncLockWorker = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "instance", "fsync_lock_worker"),
+		"The value of the fsync field corresponds to whether the fsyncLockWorker is active or not.",
+		nil, nil,
+	)
 )
 
 // CurrentOp keeps the data returned by the currentOp() method.
 type CurrentOp struct {
-	FsyncLockWorker bool   `bson:"fsyncLock"`
+	FsyncLockWorker bool `bson:"fsyncLock"`
 }
 
 // Export exports the current operation status to be consumed by prometheus.
 func (status *CurrentOp) Export(ch chan<- prometheus.Metric) {
-	var floatVar = float64(0)
-	if (status.FsyncLockWorker){
-		floatVar = float64(1)
-	}
-	instanceFsyncLockWorker.Set(floatVar)
-	instanceFsyncLockWorker.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(instanceFsyncLockWorker, prometheus.GaugeValue, boolToFloat64(status.FsyncLockWorker))
 }
 
 // Describe describes the current operation status for prometheus.
 func (status *CurrentOp) Describe(ch chan<- *prometheus.Desc) {
-	instanceFsyncLockWorker.Describe(ch)
+	ch <- instanceFsyncLockWorker
 }
 
 // GetCurrentOp returns the current operation info.
-func GetCurrentOp(session *mgo.Session, maxTimeMS int64) *CurrentOp {
+func GetCurrentOp(session *mgo.Session, maxTimeMS int64) (*CurrentOp, error) {
 	result := &CurrentOp{}
-	err := session.DB("admin").Run(bson.D{{"currentOp", 1}, {"notexist", 0}}, result)
+	err := session.DB("admin").Run(bson.D{{Name: "currentOp", Value: 1}, {Name: "notexist", Value: 0}}, result)
 	if err != nil {
-		glog.Error("Failed to get currentOp status.")
-		return nil
+		return nil, err
 	}
-	return result
+	return result, nil
+}
+
+func init() {
+	Register("currentop", newCurrentOpCollector, true)
+}
+
+type currentOpCollector struct {
+	maxTimeMS int64
+}
+
+func newCurrentOpCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &currentOpCollector{maxTimeMS: opts.MaxTimeMS}, nil
+}
+
+func (c *currentOpCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	currentOpInfo, err := GetCurrentOp(session, c.maxTimeMS)
+	if err != nil {
+		return err
+	}
+	currentOpInfo.Export(ch)
+	return nil
+}
+
+func (c *currentOpCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&CurrentOp{}).Describe(ch)
 }
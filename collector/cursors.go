@@ -5,11 +5,11 @@ import (
 )
 
 var (
-	cursorsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "cursors",
-		Help:      "The cursors data structure contains data regarding cursor state and use",
-	}, []string{"state"})
+	cursorsGauge = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "cursors"),
+		"The cursors data structure contains data regarding cursor state and use",
+		[]string{"state"}, nil,
+	)
 )
 
 // Cursors are the cursor metrics
@@ -22,14 +22,13 @@ type Cursors struct {
 
 // Export exports the data to prometheus.
 func (cursors *Cursors) Export(ch chan<- prometheus.Metric) {
-	cursorsGauge.WithLabelValues("total_open").Set(cursors.TotalOpen)
-	cursorsGauge.WithLabelValues("timed_out").Set(cursors.TimeOut)
-	cursorsGauge.WithLabelValues("total_no_timeout").Set(cursors.TotalNoTimeout)
-	cursorsGauge.WithLabelValues("pinned").Set(cursors.Pinned)
-	cursorsGauge.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(cursorsGauge, prometheus.GaugeValue, cursors.TotalOpen, "total_open")
+	ch <- prometheus.MustNewConstMetric(cursorsGauge, prometheus.GaugeValue, cursors.TimeOut, "timed_out")
+	ch <- prometheus.MustNewConstMetric(cursorsGauge, prometheus.GaugeValue, cursors.TotalNoTimeout, "total_no_timeout")
+	ch <- prometheus.MustNewConstMetric(cursorsGauge, prometheus.GaugeValue, cursors.Pinned, "pinned")
 }
 
 // Describe describes the metrics for prometheus
 func (cursors *Cursors) Describe(ch chan<- *prometheus.Desc) {
-	cursorsGauge.Describe(ch)
+	ch <- cursorsGauge
 }
@@ -1,8 +1,8 @@
 package collector
 
 import (
+	"fmt"
 	"strings"
-	"sync"
 
 	mgo "github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
@@ -11,39 +11,31 @@ import (
 )
 
 var (
-	indexSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "db",
-		Name:      "index_size_bytes",
-		Help:      "The total size in bytes of all indexes created on this database",
-	}, []string{"db", "shard"})
-	dataSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "db",
-		Name:      "data_size_bytes",
-		Help:      "The total size in bytes of the uncompressed data held in this database",
-	}, []string{"db", "shard"})
-	collectionsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "db",
-		Name:      "collections_total",
-		Help:      "Contains a count of the number of collections in that database",
-	}, []string{"db", "shard"})
-	indexesTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "db",
-		Name:      "indexes_total",
-		Help:      "Contains a count of the total number of indexes across all collections in the database",
-	}, []string{"db", "shard"})
-	objectsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "db",
-		Name:      "objects_total",
-		Help:      "Contains a count of the number of objects (i.e. documents) in the database across all collections",
-	}, []string{"db", "shard"})
-
-	// Lock for using these metrics
-	dbStatsLock = sync.Mutex{}
+	indexSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "db", "index_size_bytes"),
+		"The total size in bytes of all indexes created on this database",
+		[]string{"db", "shard"}, nil,
+	)
+	dataSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "db", "data_size_bytes"),
+		"The total size in bytes of the uncompressed data held in this database",
+		[]string{"db", "shard"}, nil,
+	)
+	collectionsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "db", "collections_total"),
+		"Contains a count of the number of collections in that database",
+		[]string{"db", "shard"}, nil,
+	)
+	indexesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "db", "indexes_total"),
+		"Contains a count of the total number of indexes across all collections in the database",
+		[]string{"db", "shard"}, nil,
+	)
+	objectsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "db", "objects_total"),
+		"Contains a count of the number of objects (i.e. documents) in the database across all collections",
+		[]string{"db", "shard"}, nil,
+	)
 )
 
 // DatabaseStatus represents stats about a database
@@ -69,56 +61,78 @@ type RawStatus struct {
 
 // Export exports database stats to prometheus
 func (dbStatus *DatabaseStatus) Export(ch chan<- prometheus.Metric) {
-	dbStatsLock.Lock()
-	defer dbStatsLock.Unlock()
-
 	if len(dbStatus.Shards) > 0 {
 		for shard, stats := range dbStatus.Shards {
 			shard = strings.Split(shard, "/")[0]
-			indexSize.WithLabelValues(stats.Name, shard).Set(float64(stats.IndexSize))
-			dataSize.WithLabelValues(stats.Name, shard).Set(float64(stats.DataSize))
-			collectionsTotal.WithLabelValues(stats.Name, shard).Set(float64(stats.Collections))
-			indexesTotal.WithLabelValues(stats.Name, shard).Set(float64(stats.Indexes))
-			objectsTotal.WithLabelValues(stats.Name, shard).Set(float64(stats.Objects))
+			ch <- prometheus.MustNewConstMetric(indexSize, prometheus.GaugeValue, float64(stats.IndexSize), stats.Name, shard)
+			ch <- prometheus.MustNewConstMetric(dataSize, prometheus.GaugeValue, float64(stats.DataSize), stats.Name, shard)
+			ch <- prometheus.MustNewConstMetric(collectionsTotal, prometheus.GaugeValue, float64(stats.Collections), stats.Name, shard)
+			ch <- prometheus.MustNewConstMetric(indexesTotal, prometheus.GaugeValue, float64(stats.Indexes), stats.Name, shard)
+			ch <- prometheus.MustNewConstMetric(objectsTotal, prometheus.GaugeValue, float64(stats.Objects), stats.Name, shard)
 		}
 	} else {
-		indexSize.WithLabelValues(dbStatus.Name, "").Set(float64(dbStatus.IndexSize))
-		dataSize.WithLabelValues(dbStatus.Name, "").Set(float64(dbStatus.DataSize))
-		collectionsTotal.WithLabelValues(dbStatus.Name, "").Set(float64(dbStatus.Collections))
-		indexesTotal.WithLabelValues(dbStatus.Name, "").Set(float64(dbStatus.Indexes))
-		objectsTotal.WithLabelValues(dbStatus.Name, "").Set(float64(dbStatus.Objects))
+		ch <- prometheus.MustNewConstMetric(indexSize, prometheus.GaugeValue, float64(dbStatus.IndexSize), dbStatus.Name, "")
+		ch <- prometheus.MustNewConstMetric(dataSize, prometheus.GaugeValue, float64(dbStatus.DataSize), dbStatus.Name, "")
+		ch <- prometheus.MustNewConstMetric(collectionsTotal, prometheus.GaugeValue, float64(dbStatus.Collections), dbStatus.Name, "")
+		ch <- prometheus.MustNewConstMetric(indexesTotal, prometheus.GaugeValue, float64(dbStatus.Indexes), dbStatus.Name, "")
+		ch <- prometheus.MustNewConstMetric(objectsTotal, prometheus.GaugeValue, float64(dbStatus.Objects), dbStatus.Name, "")
 	}
-
-	indexSize.Collect(ch)
-	dataSize.Collect(ch)
-	collectionsTotal.Collect(ch)
-	indexesTotal.Collect(ch)
-	objectsTotal.Collect(ch)
-
-	indexSize.Reset()
-	dataSize.Reset()
-	collectionsTotal.Reset()
-	indexesTotal.Reset()
-	objectsTotal.Reset()
 }
 
 // Describe describes database stats for prometheus
 func (dbStatus *DatabaseStatus) Describe(ch chan<- *prometheus.Desc) {
-	indexSize.Describe(ch)
-	dataSize.Describe(ch)
-	collectionsTotal.Describe(ch)
-	indexesTotal.Describe(ch)
-	objectsTotal.Describe(ch)
+	ch <- indexSize
+	ch <- dataSize
+	ch <- collectionsTotal
+	ch <- indexesTotal
+	ch <- objectsTotal
 }
 
 // GetDatabaseStatus returns stats for a given database
-func GetDatabaseStatus(session *mgo.Session, db string, maxTimeMS int64) *DatabaseStatus {
+func GetDatabaseStatus(session *mgo.Session, db string, maxTimeMS int64) (*DatabaseStatus, error) {
 	var dbStatus DatabaseStatus
-	err := session.DB(db).Run(bson.D{{"dbStats", 1}, {"scale", 1}, {"maxTimeMS", maxTimeMS}}, &dbStatus)
+	err := session.DB(db).Run(bson.D{{Name: "dbStats", Value: 1}, {Name: "scale", Value: 1}, {Name: "maxTimeMS", Value: maxTimeMS}}, &dbStatus)
 	if err != nil {
-		glog.Error(err)
-		return nil
+		return nil, err
 	}
 
-	return &dbStatus
+	return &dbStatus, nil
+}
+
+func init() {
+	Register("database", newDatabaseCollector, false)
+}
+
+type databaseCollector struct {
+	maxTimeMS int64
+}
+
+func newDatabaseCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &databaseCollector{maxTimeMS: opts.MaxTimeMS}, nil
+}
+
+func (c *databaseCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	all, err := session.DatabaseNames()
+	if err != nil {
+		return fmt.Errorf("Failed to get database names: %s", err)
+	}
+	var lastErr error
+	for _, db := range all {
+		if db == "admin" || db == "test" {
+			continue
+		}
+		dbStatus, err := GetDatabaseStatus(session, db, c.maxTimeMS)
+		if err != nil {
+			glog.Errorf("Failed to get database stats for db=%q: %s", db, err)
+			lastErr = err
+			continue
+		}
+		glog.V(1).Infof("exporting Database Metrics for db=%q", dbStatus.Name)
+		dbStatus.Export(ch)
+	}
+	return lastErr
+}
+
+func (c *databaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&DatabaseStatus{}).Describe(ch)
 }
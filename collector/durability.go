@@ -5,46 +5,67 @@ import (
 )
 
 var (
-	durabilityCommits = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "durability_commits",
-		Help:      "Durability commits",
-	}, []string{"state"})
+	durabilityCommits = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "durability_commits"),
+		"Durability commits",
+		[]string{"state"}, nil,
+	)
 )
 var (
-	durabilityJournaledMegabytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "durability",
-		Name:      "journaled_megabytes",
-		Help:      "The journaledMB provides the amount of data in megabytes (MB) written to journal during the last journal group commit interval",
-	})
-	durabilityWriteToDataFilesMegabytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "durability",
-		Name:      "write_to_data_files_megabytes",
-		Help:      "The writeToDataFilesMB provides the amount of data in megabytes (MB) written from journal to the data files during the last journal group commit interval",
-	})
-	durabilityCompression = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "durability",
-		Name:      "compression",
-		Help:      "The compression represents the compression ratio of the data written to the journal: ( journaled_size_of_data / uncompressed_size_of_data )",
-	})
-	durabilityEarlyCommits = prometheus.NewSummary(prometheus.SummaryOpts{
-		Namespace: Namespace,
-		Subsystem: "durability",
-		Name:      "early_commits",
-		Help:      "The earlyCommits value reflects the number of times MongoDB requested a commit before the scheduled journal group commit interval. Use this value to ensure that your journal group commit interval is not too long for your deployment",
-	})
-)
-var (
-	durabilityTimeMilliseconds = prometheus.NewSummaryVec(prometheus.SummaryOpts{
-		Namespace: Namespace,
-		Name:      "durability_time_milliseconds",
-		Help:      "Summary of times spent during the journaling process.",
-	}, []string{"stage"})
+	durabilityJournaledMegabytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "durability", "journaled_megabytes"),
+		"The journaledMB provides the amount of data in megabytes (MB) written to journal during the last journal group commit interval",
+		nil, nil,
+	)
+	durabilityWriteToDataFilesMegabytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "durability", "write_to_data_files_megabytes"),
+		"The writeToDataFilesMB provides the amount of data in megabytes (MB) written from journal to the data files during the last journal group commit interval",
+		nil, nil,
+	)
+	durabilityCompression = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "durability", "compression"),
+		"The compression represents the compression ratio of the data written to the journal: ( journaled_size_of_data / uncompressed_size_of_data )",
+		nil, nil,
+	)
 )
 
+// durabilitySummaries observes the durability stats of every scrape of a serverstatus collector.
+type durabilitySummaries struct {
+	earlyCommits     prometheus.Summary
+	timeMilliseconds *prometheus.SummaryVec
+}
+
+func newDurabilitySummaries() *durabilitySummaries {
+	return &durabilitySummaries{
+		earlyCommits: prometheus.NewSummary(prometheus.SummaryOpts{
+			Namespace: Namespace,
+			Subsystem: "durability",
+			Name:      "early_commits",
+			Help:      "The earlyCommits value reflects the number of times MongoDB requested a commit before the scheduled journal group commit interval. Use this value to ensure that your journal group commit interval is not too long for your deployment",
+		}),
+		timeMilliseconds: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace: Namespace,
+			Name:      "durability_time_milliseconds",
+			Help:      "Summary of times spent during the journaling process.",
+		}, []string{"stage"}),
+	}
+}
+
+func (s *durabilitySummaries) observe(durStats *DurStats) {
+	s.earlyCommits.Observe(durStats.EarlyCommits)
+	durStats.TimeMs.observe(s.timeMilliseconds)
+}
+
+func (s *durabilitySummaries) Collect(ch chan<- prometheus.Metric) {
+	s.earlyCommits.Collect(ch)
+	s.timeMilliseconds.Collect(ch)
+}
+
+func (s *durabilitySummaries) Describe(ch chan<- *prometheus.Desc) {
+	s.earlyCommits.Describe(ch)
+	s.timeMilliseconds.Describe(ch)
+}
+
 // DurTiming is the information about durability returned from the server.
 type DurTiming struct {
 	Dt               float64 `bson:"dt"`
@@ -54,14 +75,12 @@ type DurTiming struct {
 	RemapPrivateView float64 `bson:"remapPrivateView"`
 }
 
-// Export exports the data for the prometheus server.
-func (durTiming *DurTiming) Export(ch chan<- prometheus.Metric) {
-	durabilityTimeMilliseconds.WithLabelValues("dt").Observe(durTiming.Dt)
-	durabilityTimeMilliseconds.WithLabelValues("prep_log_buffer").Observe(durTiming.PrepLogBuffer)
-	durabilityTimeMilliseconds.WithLabelValues("write_to_journal").Observe(durTiming.WriteToJournal)
-	durabilityTimeMilliseconds.WithLabelValues("write_to_data_files").Observe(durTiming.WriteToDataFiles)
-	durabilityTimeMilliseconds.WithLabelValues("remap_private_view").Observe(durTiming.RemapPrivateView)
-	durabilityTimeMilliseconds.Collect(ch)
+func (durTiming *DurTiming) observe(timeMilliseconds *prometheus.SummaryVec) {
+	timeMilliseconds.WithLabelValues("dt").Observe(durTiming.Dt)
+	timeMilliseconds.WithLabelValues("prep_log_buffer").Observe(durTiming.PrepLogBuffer)
+	timeMilliseconds.WithLabelValues("write_to_journal").Observe(durTiming.WriteToJournal)
+	timeMilliseconds.WithLabelValues("write_to_data_files").Observe(durTiming.WriteToDataFiles)
+	timeMilliseconds.WithLabelValues("remap_private_view").Observe(durTiming.RemapPrivateView)
 }
 
 // DurStats are the stats related to durability.
@@ -77,34 +96,18 @@ type DurStats struct {
 
 // Export export the durability stats for the prometheus server.
 func (durStats *DurStats) Export(ch chan<- prometheus.Metric) {
-	durabilityCommits.WithLabelValues("written").Set(durStats.Commits)
-	durabilityCommits.WithLabelValues("in_write_lock").Set(durStats.CommitsInWriteLock)
-
-	durabilityJournaledMegabytes.Set(durStats.JournaledMB)
-	durabilityWriteToDataFilesMegabytes.Set(durStats.WriteToDataFilesMB)
-	durabilityCompression.Set(durStats.Compression)
-	durabilityEarlyCommits.Observe(durStats.EarlyCommits)
-
-	durStats.TimeMs.Export(ch)
-
-	durStats.Collect(ch)
-}
+	ch <- prometheus.MustNewConstMetric(durabilityCommits, prometheus.GaugeValue, durStats.Commits, "written")
+	ch <- prometheus.MustNewConstMetric(durabilityCommits, prometheus.GaugeValue, durStats.CommitsInWriteLock, "in_write_lock")
 
-// Collect collects the metrics for prometheus
-func (durStats *DurStats) Collect(ch chan<- prometheus.Metric) {
-	durabilityCommits.Collect(ch)
-	durabilityJournaledMegabytes.Collect(ch)
-	durabilityWriteToDataFilesMegabytes.Collect(ch)
-	durabilityCompression.Collect(ch)
-	durabilityEarlyCommits.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(durabilityJournaledMegabytes, prometheus.GaugeValue, durStats.JournaledMB)
+	ch <- prometheus.MustNewConstMetric(durabilityWriteToDataFilesMegabytes, prometheus.GaugeValue, durStats.WriteToDataFilesMB)
+	ch <- prometheus.MustNewConstMetric(durabilityCompression, prometheus.GaugeValue, durStats.Compression)
 }
 
 // Describe describes the metrics for prometheus
 func (durStats *DurStats) Describe(ch chan<- *prometheus.Desc) {
-	durabilityCommits.Describe(ch)
-	durabilityJournaledMegabytes.Describe(ch)
-	durabilityWriteToDataFilesMegabytes.Describe(ch)
-	durabilityCompression.Describe(ch)
-	durabilityEarlyCommits.Describe(ch)
-	durabilityTimeMilliseconds.Describe(ch)
+	ch <- durabilityCommits
+	ch <- durabilityJournaledMegabytes
+	ch <- durabilityWriteToDataFilesMegabytes
+	ch <- durabilityCompression
 }
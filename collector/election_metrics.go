@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	electionsCalled = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset", "elections_called_total"),
+		"The total number of elections this member called as a candidate, by reason: stepUpCmd, priorityTakeover, catchUpTakeover, electionTimeout or freezeTimeout.",
+		[]string{"reason"}, nil,
+	)
+	electionsSuccessful = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset", "elections_successful_total"),
+		"The total number of elections this member won as a candidate, by reason.",
+		[]string{"reason"}, nil,
+	)
+	stepDownsCausedByHigherTerm = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset", "step_downs_caused_by_higher_term_total"),
+		"The total number of times this member stepped down because it saw a higher term.",
+		nil, nil,
+	)
+	catchUps = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset", "catch_ups_total"),
+		"The total number of elections where this member, newly elected, had to catch up with the highest known optime.",
+		nil, nil,
+	)
+	catchUpsSucceeded = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset", "catch_ups_succeeded_total"),
+		"The total number of times this member, newly elected, caught up with the highest known optime.",
+		nil, nil,
+	)
+)
+
+// ElectionReasonStats are the elections called and won for a reason.
+type ElectionReasonStats struct {
+	Called     float64 `bson:"called"`
+	Successful float64 `bson:"successful"`
+}
+
+// ElectionMetrics is the electionMetrics section of serverStatus, new in version 4.2.
+type ElectionMetrics struct {
+	StepUpCmd                      *ElectionReasonStats `bson:"stepUpCmd"`
+	PriorityTakeover               *ElectionReasonStats `bson:"priorityTakeover"`
+	CatchUpTakeover                *ElectionReasonStats `bson:"catchUpTakeover"`
+	ElectionTimeout                *ElectionReasonStats `bson:"electionTimeout"`
+	FreezeTimeout                  *ElectionReasonStats `bson:"freezeTimeout"`
+	NumStepDownsCausedByHigherTerm float64              `bson:"numStepDownsCausedByHigherTerm"`
+	NumCatchUps                    float64              `bson:"numCatchUps"`
+	NumCatchUpsSucceeded           float64              `bson:"numCatchUpsSucceeded"`
+}
+
+// Export exports the election metrics to be consumed by prometheus.
+func (metrics *ElectionMetrics) Export(ch chan<- prometheus.Metric) {
+	reasons := map[string]*ElectionReasonStats{
+		"stepUpCmd":        metrics.StepUpCmd,
+		"priorityTakeover": metrics.PriorityTakeover,
+		"catchUpTakeover":  metrics.CatchUpTakeover,
+		"electionTimeout":  metrics.ElectionTimeout,
+		"freezeTimeout":    metrics.FreezeTimeout,
+	}
+	for reason, stats := range reasons {
+		if stats == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(electionsCalled, prometheus.CounterValue, stats.Called, reason)
+		ch <- prometheus.MustNewConstMetric(electionsSuccessful, prometheus.CounterValue, stats.Successful, reason)
+	}
+	ch <- prometheus.MustNewConstMetric(stepDownsCausedByHigherTerm, prometheus.CounterValue, metrics.NumStepDownsCausedByHigherTerm)
+	ch <- prometheus.MustNewConstMetric(catchUps, prometheus.CounterValue, metrics.NumCatchUps)
+	ch <- prometheus.MustNewConstMetric(catchUpsSucceeded, prometheus.CounterValue, metrics.NumCatchUpsSucceeded)
+}
+
+// Describe describes the election metrics for prometheus.
+func (metrics *ElectionMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- electionsCalled
+	ch <- electionsSuccessful
+	ch <- stepDownsCausedByHigherTerm
+	ch <- catchUps
+	ch <- catchUpsSucceeded
+}
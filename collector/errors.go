@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/globalsign/mgo"
+)
+
+// errCollectorTimeout is the error of a collector that didn't finish before its deadline.
+var errCollectorTimeout = errors.New("collector timed out")
+
+// MongoDB error codes which are worth telling apart when a collector fails.
+var errorCodeNames = map[int]string{
+	13:    "unauthorized",
+	18:    "unauthorized", // AuthenticationFailed
+	50:    "timeout",      // MaxTimeMSExpired
+	59:    "command_not_found",
+	76:    "no_replication", // NoReplicationEnabled
+	94:    "no_replication", // NotYetInitialized
+	189:   "not_master",     // PrimarySteppedDown
+	10107: "not_master",
+	13435: "not_master", // NotMasterNoSlaveOk
+	13436: "not_master", // NotMasterOrSecondary
+}
+
+// errorCode returns a short label describing why a collector failed.
+func errorCode(err error) string {
+	if err == errCollectorTimeout {
+		return "timeout"
+	}
+
+	code := 0
+	switch e := err.(type) {
+	case *mgo.QueryError:
+		code = e.Code
+	case *mgo.LastError:
+		code = e.Code
+	case net.Error:
+		if e.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	if code != 0 {
+		if name, ok := errorCodeNames[code]; ok {
+			return name
+		}
+		return strconv.Itoa(code)
+	}
+
+	if err == mgo.ErrNotFound {
+		return "not_found"
+	}
+	if err == io.EOF || strings.Contains(err.Error(), "no reachable servers") {
+		return "network"
+	}
+	if strings.Contains(err.Error(), "not authorized") {
+		return "unauthorized"
+	}
+	return "unknown"
+}
@@ -0,0 +1,32 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/globalsign/mgo"
+)
+
+func Test_ErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{&mgo.QueryError{Code: 13, Message: "not authorized on admin to execute command"}, "unauthorized"},
+		{&mgo.QueryError{Code: 50, Message: "operation exceeded time limit"}, "timeout"},
+		{&mgo.QueryError{Code: 10107, Message: "not master"}, "not_master"},
+		{&mgo.LastError{Code: 13435, Err: "not master and slaveOk=false"}, "not_master"},
+		{&mgo.QueryError{Code: 12345, Message: "something else"}, "12345"},
+		{errCollectorTimeout, "timeout"},
+		{io.EOF, "network"},
+		{errors.New("no reachable servers"), "network"},
+		{errors.New("boom"), "unknown"},
+	}
+
+	for _, test := range tests {
+		if code := errorCode(test.err); code != test.code {
+			t.Errorf("expected code %q for %v, got %q", test.code, test.err, code)
+		}
+	}
+}
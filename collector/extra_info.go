@@ -5,18 +5,16 @@ import (
 )
 
 var (
-	extraInfopageFaultsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "extra_info",
-		Name:      "page_faults_total",
-		Help:      "The page_faults Reports the total number of page faults that require disk operations. Page faults refer to operations that require the database server to access data which isn't available in active memory. The page_faults counter may increase dramatically during moments of poor performance and may correlate with limited memory environments and larger data sets. Limited and sporadic page faults do not necessarily indicate an issue",
-	})
-	extraInfoheapUsageBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "extra_info",
-		Name:      "heap_usage_bytes",
-		Help:      "The heap_usage_bytes field is only available on Unix/Linux systems, and reports the total size in bytes of heap space used by the database process",
-	})
+	extraInfopageFaultsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "extra_info", "page_faults_total"),
+		"The page_faults Reports the total number of page faults that require disk operations. Page faults refer to operations that require the database server to access data which isn't available in active memory. The page_faults counter may increase dramatically during moments of poor performance and may correlate with limited memory environments and larger data sets. Limited and sporadic page faults do not necessarily indicate an issue",
+		nil, nil,
+	)
+	extraInfoheapUsageBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "extra_info", "heap_usage_bytes"),
+		"The heap_usage_bytes field is only available on Unix/Linux systems, and reports the total size in bytes of heap space used by the database process",
+		nil, nil,
+	)
 )
 
 // ExtraInfo has extra info metrics
@@ -27,16 +25,13 @@ type ExtraInfo struct {
 
 // Export exports the metrics to prometheus.
 func (extraInfo *ExtraInfo) Export(ch chan<- prometheus.Metric) {
-	extraInfoheapUsageBytes.Set(extraInfo.HeapUsageBytes)
-	extraInfopageFaultsTotal.Set(extraInfo.PageFaults)
-
-	extraInfoheapUsageBytes.Collect(ch)
-	extraInfopageFaultsTotal.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(extraInfoheapUsageBytes, prometheus.GaugeValue, extraInfo.HeapUsageBytes)
+	ch <- prometheus.MustNewConstMetric(extraInfopageFaultsTotal, prometheus.GaugeValue, extraInfo.PageFaults)
 
 }
 
 // Describe describes the metrics for prometheus
 func (extraInfo *ExtraInfo) Describe(ch chan<- *prometheus.Desc) {
-	extraInfoheapUsageBytes.Describe(ch)
-	extraInfopageFaultsTotal.Describe(ch)
+	ch <- extraInfoheapUsageBytes
+	ch <- extraInfopageFaultsTotal
 }
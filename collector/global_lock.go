@@ -5,38 +5,35 @@ import (
 )
 
 var (
-	globalLockRatio = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "global_lock",
-		Name:      "ratio",
-		Help:      "The value of ratio displays the relationship between lockTime and totalTime. Low values indicate that operations have held the globalLock frequently for shorter periods of time. High values indicate that operations have held globalLock infrequently for longer periods of time",
-	})
-	globalLockTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "global_lock",
-		Name:      "total",
-		Help:      "The value of totalTime represents the time, in microseconds, since the database last started and creation of the globalLock. This is roughly equivalent to total server uptime",
-	})
-	globalLockLockTotal = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "global_lock",
-		Name:      "lock_total",
-		Help:      "The value of lockTime represents the time, in microseconds, since the database last started, that the globalLock has been held",
-	})
+	globalLockRatio = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "global_lock", "ratio"),
+		"The value of ratio displays the relationship between lockTime and totalTime. Low values indicate that operations have held the globalLock frequently for shorter periods of time. High values indicate that operations have held globalLock infrequently for longer periods of time",
+		nil, nil,
+	)
+	globalLockTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "global_lock", "total"),
+		"The value of totalTime represents the time, in microseconds, since the database last started and creation of the globalLock. This is roughly equivalent to total server uptime",
+		nil, nil,
+	)
+	globalLockLockTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "global_lock", "lock_total"),
+		"The value of lockTime represents the time, in microseconds, since the database last started, that the globalLock has been held",
+		nil, nil,
+	)
 )
 var (
-	globalLockCurrentQueue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "global_lock_current_queue",
-		Help:      "The currentQueue data structure value provides more granular information concerning the number of operations queued because of a lock",
-	}, []string{"type"})
+	globalLockCurrentQueue = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "global_lock_current_queue"),
+		"The currentQueue data structure value provides more granular information concerning the number of operations queued because of a lock",
+		[]string{"type"}, nil,
+	)
 )
 var (
-	globalLockClient = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "global_lock_client",
-		Help:      "The activeClients data structure provides more granular information about the number of connected clients and the operation types (e.g. read or write) performed by these clients",
-	}, []string{"type"})
+	globalLockClient = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "global_lock_client"),
+		"The activeClients data structure provides more granular information about the number of connected clients and the operation types (e.g. read or write) performed by these clients",
+		[]string{"type"}, nil,
+	)
 )
 
 // ClientStats metrics for client stats
@@ -48,8 +45,8 @@ type ClientStats struct {
 
 // Export exports the metrics to prometheus
 func (clientStats *ClientStats) Export(ch chan<- prometheus.Metric) {
-	globalLockClient.WithLabelValues("reader").Set(clientStats.Readers)
-	globalLockClient.WithLabelValues("writer").Set(clientStats.Writers)
+	ch <- prometheus.MustNewConstMetric(globalLockClient, prometheus.GaugeValue, clientStats.Readers, "reader")
+	ch <- prometheus.MustNewConstMetric(globalLockClient, prometheus.GaugeValue, clientStats.Writers, "writer")
 }
 
 // QueueStats queue stats
@@ -61,8 +58,8 @@ type QueueStats struct {
 
 // Export exports the metrics to prometheus
 func (queueStats *QueueStats) Export(ch chan<- prometheus.Metric) {
-	globalLockCurrentQueue.WithLabelValues("reader").Set(queueStats.Readers)
-	globalLockCurrentQueue.WithLabelValues("writer").Set(queueStats.Writers)
+	ch <- prometheus.MustNewConstMetric(globalLockCurrentQueue, prometheus.GaugeValue, queueStats.Readers, "reader")
+	ch <- prometheus.MustNewConstMetric(globalLockCurrentQueue, prometheus.GaugeValue, queueStats.Writers, "writer")
 }
 
 // GlobalLockStats global lock stats
@@ -76,22 +73,18 @@ type GlobalLockStats struct {
 
 // Export exports the metrics to prometheus
 func (globalLock *GlobalLockStats) Export(ch chan<- prometheus.Metric) {
-	globalLockTotal.Set(globalLock.LockTime)
-	globalLockRatio.Set(globalLock.Ratio)
+	ch <- prometheus.MustNewConstMetric(globalLockTotal, prometheus.GaugeValue, globalLock.LockTime)
+	ch <- prometheus.MustNewConstMetric(globalLockRatio, prometheus.GaugeValue, globalLock.Ratio)
 
 	globalLock.CurrentQueue.Export(ch)
 	globalLock.ActiveClients.Export(ch)
 
-	globalLockTotal.Collect(ch)
-	globalLockRatio.Collect(ch)
-	globalLockCurrentQueue.Collect(ch)
-	globalLockClient.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (globalLock *GlobalLockStats) Describe(ch chan<- *prometheus.Desc) {
-	globalLockTotal.Describe(ch)
-	globalLockRatio.Describe(ch)
-	globalLockCurrentQueue.Describe(ch)
-	globalLockClient.Describe(ch)
+	ch <- globalLockTotal
+	ch <- globalLockRatio
+	ch <- globalLockCurrentQueue
+	ch <- globalLockClient
 }
@@ -5,23 +5,22 @@ import (
 )
 
 var (
-	indexCountersMissRatio = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "index_counters",
-		Name:      "miss_ratio",
-		Help:      "The missRatio value is the ratio of hits to misses. This value is typically 0 or approaching 0",
-	})
+	indexCountersMissRatio = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "index_counters", "miss_ratio"),
+		"The missRatio value is the ratio of hits to misses. This value is typically 0 or approaching 0",
+		nil, nil,
+	)
 )
 
 var (
-	indexCountersTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "index_counters_total",
-		Help:      "Total indexes by type",
-	}, []string{"type"})
+	indexCountersTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "index_counters_total"),
+		"Total indexes by type",
+		[]string{"type"}, nil,
+	)
 )
 
-//IndexCounterStats index counter stats
+// IndexCounterStats index counter stats
 type IndexCounterStats struct {
 	Accesses  float64 `bson:"accesses"`
 	Hits      float64 `bson:"hits"`
@@ -32,20 +31,17 @@ type IndexCounterStats struct {
 
 // Export exports the data to prometheus.
 func (indexCountersStats *IndexCounterStats) Export(ch chan<- prometheus.Metric) {
-	indexCountersTotal.WithLabelValues("accesses").Set(indexCountersStats.Accesses)
-	indexCountersTotal.WithLabelValues("hits").Set(indexCountersStats.Hits)
-	indexCountersTotal.WithLabelValues("misses").Set(indexCountersStats.Misses)
-	indexCountersTotal.WithLabelValues("resets").Set(indexCountersStats.Resets)
+	ch <- prometheus.MustNewConstMetric(indexCountersTotal, prometheus.GaugeValue, indexCountersStats.Accesses, "accesses")
+	ch <- prometheus.MustNewConstMetric(indexCountersTotal, prometheus.GaugeValue, indexCountersStats.Hits, "hits")
+	ch <- prometheus.MustNewConstMetric(indexCountersTotal, prometheus.GaugeValue, indexCountersStats.Misses, "misses")
+	ch <- prometheus.MustNewConstMetric(indexCountersTotal, prometheus.GaugeValue, indexCountersStats.Resets, "resets")
 
-	indexCountersMissRatio.Set(indexCountersStats.MissRatio)
-
-	indexCountersTotal.Collect(ch)
-	indexCountersMissRatio.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(indexCountersMissRatio, prometheus.GaugeValue, indexCountersStats.MissRatio)
 
 }
 
 // Describe describes the metrics for prometheus
 func (indexCountersStats *IndexCounterStats) Describe(ch chan<- *prometheus.Desc) {
-	indexCountersTotal.Describe(ch)
-	indexCountersMissRatio.Describe(ch)
+	ch <- indexCountersTotal
+	ch <- indexCountersMissRatio
 }
@@ -5,25 +5,25 @@ import (
 )
 
 var (
-	locksTimeLockedGlobalMicrosecondsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "locks_time_locked_global_microseconds_total",
-		Help:      "amount of time in microseconds that any database has held the global lock",
-	}, []string{"type", "database"})
+	locksTimeLockedGlobalMicrosecondsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "locks_time_locked_global_microseconds_total"),
+		"amount of time in microseconds that any database has held the global lock",
+		[]string{"type", "database"}, nil,
+	)
 )
 var (
-	locksTimeLockedLocalMicrosecondsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "locks_time_locked_local_microseconds_total",
-		Help:      "amount of time in microseconds that any database has held the local lock",
-	}, []string{"type", "database"})
+	locksTimeLockedLocalMicrosecondsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "locks_time_locked_local_microseconds_total"),
+		"amount of time in microseconds that any database has held the local lock",
+		[]string{"type", "database"}, nil,
+	)
 )
 var (
-	locksTimeAcquiringGlobalMicrosecondsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "locks_time_acquiring_global_microseconds_total",
-		Help:      "amount of time in microseconds that any database has spent waiting for the global lock",
-	}, []string{"type", "database"})
+	locksTimeAcquiringGlobalMicrosecondsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "locks_time_acquiring_global_microseconds_total"),
+		"amount of time in microseconds that any database has spent waiting for the global lock",
+		[]string{"type", "database"}, nil,
+	)
 )
 
 // LockStatsMap is a map of lock stats
@@ -50,24 +50,21 @@ func (locks LockStatsMap) Export(ch chan<- prometheus.Metric) {
 			key = "dot"
 		}
 
-		locksTimeLockedGlobalMicrosecondsTotal.WithLabelValues("read", key).Set(locks.TimeLockedMicros.Read)
-		locksTimeLockedGlobalMicrosecondsTotal.WithLabelValues("write", key).Set(locks.TimeLockedMicros.Write)
+		ch <- prometheus.MustNewConstMetric(locksTimeLockedGlobalMicrosecondsTotal, prometheus.GaugeValue, locks.TimeLockedMicros.Read, "read", key)
+		ch <- prometheus.MustNewConstMetric(locksTimeLockedGlobalMicrosecondsTotal, prometheus.GaugeValue, locks.TimeLockedMicros.Write, "write", key)
 
-		locksTimeLockedLocalMicrosecondsTotal.WithLabelValues("read", key).Set(locks.TimeLockedMicros.ReadLower)
-		locksTimeLockedLocalMicrosecondsTotal.WithLabelValues("write", key).Set(locks.TimeLockedMicros.WriteLower)
+		ch <- prometheus.MustNewConstMetric(locksTimeLockedLocalMicrosecondsTotal, prometheus.GaugeValue, locks.TimeLockedMicros.ReadLower, "read", key)
+		ch <- prometheus.MustNewConstMetric(locksTimeLockedLocalMicrosecondsTotal, prometheus.GaugeValue, locks.TimeLockedMicros.WriteLower, "write", key)
 
-		locksTimeAcquiringGlobalMicrosecondsTotal.WithLabelValues("read", key).Set(locks.TimeAcquiringMicros.ReadLower)
-		locksTimeAcquiringGlobalMicrosecondsTotal.WithLabelValues("write", key).Set(locks.TimeAcquiringMicros.WriteLower)
+		ch <- prometheus.MustNewConstMetric(locksTimeAcquiringGlobalMicrosecondsTotal, prometheus.GaugeValue, locks.TimeAcquiringMicros.ReadLower, "read", key)
+		ch <- prometheus.MustNewConstMetric(locksTimeAcquiringGlobalMicrosecondsTotal, prometheus.GaugeValue, locks.TimeAcquiringMicros.WriteLower, "write", key)
 	}
 
-	locksTimeLockedGlobalMicrosecondsTotal.Collect(ch)
-	locksTimeLockedLocalMicrosecondsTotal.Collect(ch)
-	locksTimeAcquiringGlobalMicrosecondsTotal.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (locks LockStatsMap) Describe(ch chan<- *prometheus.Desc) {
-	locksTimeLockedGlobalMicrosecondsTotal.Describe(ch)
-	locksTimeLockedLocalMicrosecondsTotal.Describe(ch)
-	locksTimeAcquiringGlobalMicrosecondsTotal.Describe(ch)
+	ch <- locksTimeLockedGlobalMicrosecondsTotal
+	ch <- locksTimeLockedLocalMicrosecondsTotal
+	ch <- locksTimeAcquiringGlobalMicrosecondsTotal
 }
@@ -4,6 +4,8 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestMain(m *testing.M) {
@@ -18,3 +20,30 @@ func LoadFixture(name string) []byte {
 
 	return data
 }
+
+// exportCollector registers the metrics of a decoded command result, like a ServerStatus loaded
+// from a fixture.
+type exportCollector struct {
+	describe func(ch chan<- *prometheus.Desc)
+	export   func(ch chan<- prometheus.Metric)
+}
+
+func (c *exportCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.describe(ch)
+}
+
+func (c *exportCollector) Collect(ch chan<- prometheus.Metric) {
+	c.export(ch)
+}
+
+// checkGather registers the metrics of a command result and gathers them, which fails when the
+// same series is sent twice or with inconsistent labels.
+func checkGather(t *testing.T, name string, describe func(ch chan<- *prometheus.Desc), export func(ch chan<- prometheus.Metric)) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(&exportCollector{describe: describe, export: export}); err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	if _, err := registry.Gather(); err != nil {
+		t.Errorf("%s: %s", name, err)
+	}
+}
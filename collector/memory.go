@@ -5,11 +5,11 @@ import (
 )
 
 var (
-	memory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "memory",
-		Help:      "The mem data structure holds information regarding the target system architecture of mongod and current memory use",
-	}, []string{"type"})
+	memory = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "memory"),
+		"The mem data structure holds information regarding the target system architecture of mongod and current memory use",
+		[]string{"type"}, nil,
+	)
 )
 
 // MemStats tracks the mem stats metrics.
@@ -23,14 +23,13 @@ type MemStats struct {
 
 // Export exports the data to prometheus.
 func (memStats *MemStats) Export(ch chan<- prometheus.Metric) {
-	memory.WithLabelValues("resident").Set(memStats.Resident)
-	memory.WithLabelValues("virtual").Set(memStats.Virtual)
-	memory.WithLabelValues("mapped").Set(memStats.Mapped)
-	memory.WithLabelValues("mapped_with_journal").Set(memStats.MappedWithJournal)
-	memory.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(memory, prometheus.GaugeValue, memStats.Resident, "resident")
+	ch <- prometheus.MustNewConstMetric(memory, prometheus.GaugeValue, memStats.Virtual, "virtual")
+	ch <- prometheus.MustNewConstMetric(memory, prometheus.GaugeValue, memStats.Mapped, "mapped")
+	ch <- prometheus.MustNewConstMetric(memory, prometheus.GaugeValue, memStats.MappedWithJournal, "mapped_with_journal")
 }
 
 // Describe describes the metrics for prometheus
 func (memStats *MemStats) Describe(ch chan<- *prometheus.Desc) {
-	memory.Describe(ch)
+	ch <- memory
 }
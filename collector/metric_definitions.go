@@ -0,0 +1,358 @@
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dcu/mongodb_exporter/shared"
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var metricNameRegexp = regexp.MustCompile("^[a-zA-Z_:][a-zA-Z0-9_:]*$")
+
+// metricDefinitionEntry is either the metadata of a group or one of its metrics in a
+// groups.yml-style file.
+type metricDefinitionEntry struct {
+	Help   string   `yaml:"help"`
+	Type   string   `yaml:"type"`
+	Labels []string `yaml:"labels"`
+	// Path is the dot separated path of the value in serverStatus. The path of a group is
+	// relative to the root of serverStatus, the ones of its metrics to the group. A "*" matches
+	// every field of a document and its name becomes a label value.
+	Path string `yaml:"path"`
+}
+
+// definedMetric is a single value of serverStatus exported as defined in the definitions file.
+type definedMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	path      []string
+	// labelValue is the name of the metric in its group, used as the first label value of the
+	// *_vec groups.
+	labelValue string
+}
+
+// MetricDefinitions are metrics exported from serverStatus as described by a groups.yml-style file
+// instead of Go code, so that new fields can be exported without a new release.
+type MetricDefinitions struct {
+	metrics []*definedMetric
+}
+
+// LoadMetricDefinitions loads the metric definitions from the given file.
+func LoadMetricDefinitions(path string) (*MetricDefinitions, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetricDefinitions(content)
+}
+
+// ParseMetricDefinitions parses the metric definitions from the content of a groups.yml-style file.
+// The definitions must not reuse the names of the metrics the serverstatus collector exports
+// itself, which would be exported twice.
+func ParseMetricDefinitions(content []byte) (*MetricDefinitions, error) {
+	definitions, err := parseMetricDefinitions(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := definitions.checkBuiltinNames(); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+func parseMetricDefinitions(content []byte) (*MetricDefinitions, error) {
+	groups := map[string]map[string]*metricDefinitionEntry{}
+	if err := yaml.UnmarshalStrict(content, &groups); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := &MetricDefinitions{}
+	for _, name := range names {
+		metrics, err := parseMetricGroup(name, groups[name])
+		if err != nil {
+			return nil, fmt.Errorf("group %q: %s", name, err)
+		}
+		definitions.metrics = append(definitions.metrics, metrics...)
+	}
+	return definitions, nil
+}
+
+func parseMetricGroup(name string, entries map[string]*metricDefinitionEntry) ([]*definedMetric, error) {
+	metadata := entries["metadata"]
+	if metadata == nil {
+		return nil, fmt.Errorf("metadata is missing")
+	}
+	groupPath := splitMetricPath(metadata.Path)
+
+	children := make([]string, 0, len(entries))
+	for child := range entries {
+		if child != "metadata" {
+			children = append(children, child)
+		}
+	}
+	sort.Strings(children)
+
+	switch metadata.Type {
+	case "counter", "gauge", "summary":
+		desc, err := newDefinedDesc(name, metadata.Help, metadata.Labels, wildcards(groupPath))
+		if err != nil {
+			return nil, err
+		}
+		return []*definedMetric{{desc: desc, valueType: definedValueType(metadata.Type), path: groupPath}}, nil
+
+	case "metrics":
+		var metrics []*definedMetric
+		for _, child := range children {
+			entry := entries[child]
+			if entry == nil {
+				entry = &metricDefinitionEntry{}
+			}
+			path := append(append([]string{}, groupPath...), childMetricPath(child, entry)...)
+			help := entry.Help
+			if help == "" {
+				help = metadata.Help
+			}
+			desc, err := newDefinedDesc(name+"_"+child, help, metadata.Labels, wildcards(path))
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, &definedMetric{desc: desc, valueType: definedValueType(entry.Type), path: path})
+		}
+		return metrics, nil
+
+	case "counter_vec", "gauge_vec", "summary_vec":
+		if len(metadata.Labels) == 0 {
+			return nil, fmt.Errorf("%s needs at least one label", metadata.Type)
+		}
+		var desc *prometheus.Desc
+		var metrics []*definedMetric
+		for _, child := range children {
+			entry := entries[child]
+			if entry == nil {
+				entry = &metricDefinitionEntry{}
+			}
+			path := append(append([]string{}, groupPath...), childMetricPath(child, entry)...)
+			if desc == nil {
+				var err error
+				if desc, err = newDefinedDesc(name, metadata.Help, metadata.Labels, 1+wildcards(path)); err != nil {
+					return nil, err
+				}
+			} else if 1+wildcards(path) != len(metadata.Labels) {
+				return nil, fmt.Errorf("the path of %q doesn't match the labels", child)
+			}
+			valueType := strings.TrimSuffix(metadata.Type, "_vec")
+			metrics = append(metrics, &definedMetric{desc: desc, valueType: definedValueType(valueType), path: path, labelValue: child})
+		}
+		return metrics, nil
+	}
+
+	return nil, fmt.Errorf("unknown type %q", metadata.Type)
+}
+
+// checkBuiltinNames returns an error naming the defined metrics which are also exported by the
+// serverstatus collector.
+func (definitions *MetricDefinitions) checkBuiltinNames() error {
+	builtin := builtinServerStatusNames()
+	var collisions []string
+	seen := map[string]bool{}
+	for _, metric := range definitions.metrics {
+		name := descName(metric.desc)
+		if builtin[name] && !seen[name] {
+			collisions = append(collisions, name)
+			seen[name] = true
+		}
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("%s already exported by the serverstatus collector", strings.Join(collisions, ", "))
+	}
+	return nil
+}
+
+// builtinServerStatusNames returns the names of all the metrics the serverstatus collector may
+// export, whichever sections the server returns.
+func builtinServerStatusNames() map[string]bool {
+	status := &ServerStatus{}
+	allocateSections(reflect.ValueOf(status).Elem())
+
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		status.Describe(ch)
+		newDurabilitySummaries().Describe(ch)
+		close(ch)
+	}()
+	names := map[string]bool{}
+	for desc := range ch {
+		names[descName(desc)] = true
+	}
+	return names
+}
+
+// allocateSections allocates the nil sections of a decoded command result, so that it describes
+// all of its metrics.
+func allocateSections(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct:
+			field.Set(reflect.New(field.Type().Elem()))
+			allocateSections(field.Elem())
+		case field.Kind() == reflect.Struct:
+			allocateSections(field)
+		}
+	}
+}
+
+var descNameRegexp = regexp.MustCompile(`fqName: "([^"]*)"`)
+
+// descName returns the fully-qualified name of the description, which it only exposes through
+// its string.
+func descName(desc *prometheus.Desc) string {
+	if match := descNameRegexp.FindStringSubmatch(desc.String()); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// newDefinedDesc returns the description of a defined metric, checking that there is one label
+// for every label value found while walking its path.
+func newDefinedDesc(name, help string, labels []string, labelValues int) (*prometheus.Desc, error) {
+	fqName := prometheus.BuildFQName(Namespace, "", name)
+	if !metricNameRegexp.MatchString(fqName) {
+		return nil, fmt.Errorf("%q is not a valid metric name", fqName)
+	}
+	if len(labels) != labelValues {
+		return nil, fmt.Errorf("%s has %d labels but its path gives %d label values", fqName, len(labels), labelValues)
+	}
+	if help == "" {
+		help = fqName
+	}
+	return prometheus.NewDesc(fqName, help, labels, nil), nil
+}
+
+func childMetricPath(name string, entry *metricDefinitionEntry) []string {
+	if entry.Path != "" {
+		return splitMetricPath(entry.Path)
+	}
+	return []string{shared.LowerCamelCase(name)}
+}
+
+func splitMetricPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+func wildcards(path []string) int {
+	count := 0
+	for _, segment := range path {
+		if segment == "*" {
+			count++
+		}
+	}
+	return count
+}
+
+func definedValueType(metricType string) prometheus.ValueType {
+	switch metricType {
+	case "counter":
+		return prometheus.CounterValue
+	case "gauge":
+		return prometheus.GaugeValue
+	}
+	return prometheus.UntypedValue
+}
+
+// Export exports the defined metrics found in the given serverStatus document.
+func (definitions *MetricDefinitions) Export(serverStatus bson.M, ch chan<- prometheus.Metric) {
+	for _, metric := range definitions.metrics {
+		var labelValues []string
+		if metric.labelValue != "" {
+			labelValues = []string{metric.labelValue}
+		}
+		walkMetricPath(serverStatus, metric.path, labelValues, func(value float64, labelValues []string) {
+			ch <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, value, labelValues...)
+		})
+	}
+}
+
+// Describe describes the defined metrics for prometheus.
+func (definitions *MetricDefinitions) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range definitions.metrics {
+		ch <- metric.desc
+	}
+}
+
+// walkMetricPath calls found with every value at the given path of the document, together with the
+// names of the fields matched by the wildcards on the way.
+func walkMetricPath(doc interface{}, path []string, labelValues []string, found func(float64, []string)) {
+	if len(path) == 0 {
+		if value, ok := metricValue(doc); ok {
+			found(value, labelValues)
+		}
+		return
+	}
+
+	fields, ok := doc.(bson.M)
+	if !ok {
+		return
+	}
+	if path[0] != "*" {
+		if field, ok := fields[path[0]]; ok {
+			walkMetricPath(field, path[1:], labelValues, found)
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		walkMetricPath(fields[key], path[1:], append(append([]string{}, labelValues...), key), found)
+	}
+}
+
+// metricValue converts the numeric, boolean and time values of a decoded document to a metric value.
+func metricValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case time.Time:
+		return float64(v.Unix()), true
+	case bson.MongoTimestamp:
+		return float64(v >> 32), true
+	}
+	return 0, false
+}
@@ -0,0 +1,189 @@
+package collector
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const testMetricDefinitions = `
+defined_asserts_total:
+  metadata:
+    type: counter_vec
+    help: "Asserts by type."
+    labels: [type]
+    path: asserts
+  user:
+    help: "User asserts."
+  regular: {}
+
+defined_connections:
+  metadata:
+    type: metrics
+    help: "Connections."
+    path: connections
+  created_total:
+    type: counter
+    path: totalCreated
+  available:
+    type: gauge
+
+defined_locks_time_locked_microseconds:
+  metadata:
+    type: counter_vec
+    help: "Time locked by database."
+    labels: [type, database]
+    path: locks.*.timeLockedMicros
+  r: {}
+`
+
+func Test_MetricDefinitions(t *testing.T) {
+	definitions, err := ParseMetricDefinitions([]byte(testMetricDefinitions))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]float64{
+		"mongodb_defined_asserts_total,user":                     3,
+		"mongodb_defined_asserts_total,regular":                  0,
+		"mongodb_defined_connections_created_total":              4145,
+		"mongodb_defined_connections_available":                  818,
+		"mongodb_defined_locks_time_locked_microseconds,admin,r": 1452829,
+	}
+	checkDefinedValues(t, definitions, expected)
+}
+
+func Test_MetricDefinitionsGroupsFile(t *testing.T) {
+	// groups.yml describes the metrics of the serverstatus collector, so it can't be loaded as is.
+	if _, err := LoadMetricDefinitions("../groups.yml"); err == nil || !strings.Contains(err.Error(), "mongodb_connections,") {
+		t.Errorf("expected groups.yml to collide with the serverstatus metrics, got %v", err)
+	}
+
+	content, err := ioutil.ReadFile("../groups.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	definitions, err := parseMetricDefinitions(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]float64{
+		"mongodb_instance_uptime_seconds":                                   127859,
+		"mongodb_instance_uptime_estimate_seconds":                          13850,
+		"mongodb_asserts_total,user":                                        3,
+		"mongodb_background_flushing_flushes_total":                         3,
+		"mongodb_background_flushing_average_milliseconds":                  7.666666666666667,
+		"mongodb_connections,available":                                     818,
+		"mongodb_connections_metrics_created_total":                         4145,
+		"mongodb_durability_commits,written":                                10,
+		"mongodb_durability_time_milliseconds,dt":                           3187,
+		"mongodb_extra_info_page_faults_total":                              1724156,
+		"mongodb_global_lock_lock_total":                                    7097013,
+		"mongodb_locks_time_locked_global_microseconds_total,.,read":        1979803,
+		"mongodb_locks_time_locked_local_microseconds_total,admin,read":     1452829,
+		"mongodb_locks_time_acquiring_global_microseconds_total,a-db,write": 21,
+		"mongodb_cursors_metrics_timed_out_total":                           3,
+		"mongodb_network_bytes_total,in_bytes":                              162962349,
+		"mongodb_op_counters_total,command":                                 642339,
+		"mongodb_memory,mapped_with_journal":                                47122,
+		"mongodb_metrics_cursor_open,total":                                 0,
+		"mongodb_metrics_document_total,deleted":                            45726,
+		"mongodb_metrics_operation_total,scan_and_order":                    11,
+		"mongodb_metrics_query_executor_total,scanned_objects":              290443242,
+		"mongodb_metrics_repl_buffer_max_size_bytes":                        268435456,
+		"mongodb_metrics_storage_freelist_search_total,bucket_exhausted":    271,
+		"mongodb_metrics_ttl_passes_total":                                  1130,
+		"mongodb_index_counters_total,accesses":                             2094239,
+		"mongodb_global_lock_current_queue,reader":                          0,
+		"mongodb_metrics_get_last_error_wtime_total_milliseconds":           0,
+		"mongodb_metrics_repl_network_readers_created_total":                0,
+		"mongodb_metrics_repl_preload_indexes_total_milliseconds":           0,
+		"mongodb_op_counters_repl_total,getmore":                            0,
+		"mongodb_cursors,no_timeout":                                        0,
+	}
+	checkDefinedValues(t, definitions, expected)
+}
+
+// checkDefinedValues exports the definitions from the serverStatus fixture and compares the values
+// by name and label values.
+func checkDefinedValues(t *testing.T, definitions *MetricDefinitions, expected map[string]float64) {
+	doc := bson.M{}
+	if err := bson.Unmarshal(LoadFixture("server_status.bson"), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&definitionsCollector{definitions: definitions, doc: doc})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			key := family.GetName()
+			for _, label := range metric.Label {
+				key += "," + label.GetValue()
+			}
+			switch {
+			case metric.Counter != nil:
+				values[key] = metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				values[key] = metric.Gauge.GetValue()
+			case metric.Untyped != nil:
+				values[key] = metric.Untyped.GetValue()
+			}
+		}
+	}
+
+	for key, value := range expected {
+		got, ok := values[key]
+		if !ok {
+			t.Errorf("%s was not exported, got %v", key, values)
+		} else if got != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, got)
+		}
+	}
+}
+
+type definitionsCollector struct {
+	definitions *MetricDefinitions
+	doc         bson.M
+}
+
+func (c *definitionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.definitions.Describe(ch)
+}
+
+func (c *definitionsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.definitions.Export(c.doc, ch)
+}
+
+func Test_MetricDefinitionsErrors(t *testing.T) {
+	invalid := []string{
+		"no_metadata:\n  user: {}\n",
+		"unknown_type:\n  metadata:\n    type: histogram\n",
+		"missing_label:\n  metadata:\n    type: counter_vec\n    labels: [type, database]\n    path: asserts\n  user: {}\n",
+		"vec_without_labels:\n  metadata:\n    type: gauge_vec\n  user: {}\n",
+		"invalid-name:\n  metadata:\n    type: gauge\n",
+		"connections:\n  metadata:\n    type: gauge_vec\n    labels: [state]\n    path: connections\n  current: {}\n",
+	}
+	for _, content := range invalid {
+		if _, err := ParseMetricDefinitions([]byte(content)); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
+
+func Test_MetricDefinitionsBuiltinNames(t *testing.T) {
+	content := "instance:\n  metadata:\n    type: metrics\n  uptime_seconds: {path: uptime}\n  uptime_minutes: {path: uptime}\n"
+	_, err := ParseMetricDefinitions([]byte(content))
+	if err == nil || err.Error() != "mongodb_instance_uptime_seconds already exported by the serverstatus collector" {
+		t.Errorf("expected the colliding metric to be named, got %v", err)
+	}
+}
@@ -5,231 +5,204 @@ import (
 )
 
 var (
-	metricsCursorTimedOutTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_cursor",
-		Name:      "timed_out_total",
-		Help:      "timedOut provides the total number of cursors that have timed out since the server process started. If this number is large or growing at a regular rate, this may indicate an application error",
-	})
+	metricsCursorTimedOutTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_cursor", "timed_out_total"),
+		"timedOut provides the total number of cursors that have timed out since the server process started. If this number is large or growing at a regular rate, this may indicate an application error",
+		nil, nil,
+	)
 )
 var (
-	metricsCursorOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "metrics_cursor_open",
-		Help:      "The open is an embedded document that contains data regarding open cursors",
-	}, []string{"state"})
+	metricsCursorOpen = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "metrics_cursor_open"),
+		"The open is an embedded document that contains data regarding open cursors",
+		[]string{"state"}, nil,
+	)
 )
 var (
-	metricsDocumentTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "metrics_document_total",
-		Help:      "The document holds a document of that reflect document access and modification patterns and data use. Compare these values to the data in the opcounters document, which track total number of operations",
-	}, []string{"state"})
+	metricsDocumentTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "metrics_document_total"),
+		"The document holds a document of that reflect document access and modification patterns and data use. Compare these values to the data in the opcounters document, which track total number of operations",
+		[]string{"state"}, nil,
+	)
 )
 var (
-	metricsGetLastErrorWtimeNumTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_get_last_error_wtime",
-		Name:      "num_total",
-		Help:      "num reports the total number of getLastError operations with a specified write concern (i.e. w) that wait for one or more members of a replica set to acknowledge the write operation (i.e. a w value greater than 1.)",
-	})
-	metricsGetLastErrorWtimeTotalMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_get_last_error_wtime",
-		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time in milliseconds that the mongod has spent performing getLastError operations with write concern (i.e. w) that wait for one or more members of a replica set to acknowledge the write operation (i.e. a w value greater than 1.)",
-	})
+	metricsGetLastErrorWtimeNumTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_get_last_error_wtime", "num_total"),
+		"num reports the total number of getLastError operations with a specified write concern (i.e. w) that wait for one or more members of a replica set to acknowledge the write operation (i.e. a w value greater than 1.)",
+		nil, nil,
+	)
+	metricsGetLastErrorWtimeTotalMilliseconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_get_last_error_wtime", "total_milliseconds"),
+		"total_millis reports the total amount of time in milliseconds that the mongod has spent performing getLastError operations with write concern (i.e. w) that wait for one or more members of a replica set to acknowledge the write operation (i.e. a w value greater than 1.)",
+		nil, nil,
+	)
 )
 var (
-	metricsGetLastErrorWtimeoutsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_get_last_error",
-		Name:      "wtimeouts_total",
-		Help:      "wtimeouts reports the number of times that write concern operations have timed out as a result of the wtimeout threshold to getLastError.",
-	})
+	metricsGetLastErrorWtimeoutsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_get_last_error", "wtimeouts_total"),
+		"wtimeouts reports the number of times that write concern operations have timed out as a result of the wtimeout threshold to getLastError.",
+		nil, nil,
+	)
 )
 var (
-	metricsOperationTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "metrics_operation_total",
-		Help:      "operation is a sub-document that holds counters for several types of update and query operations that MongoDB handles using special operation types",
-	}, []string{"type"})
+	metricsOperationTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "metrics_operation_total"),
+		"operation is a sub-document that holds counters for several types of update and query operations that MongoDB handles using special operation types",
+		[]string{"type"}, nil,
+	)
 )
 var (
-	metricsQueryExecutorTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "metrics_query_executor_total",
-		Help:      "queryExecutor is a document that reports data from the query execution system",
-	}, []string{"state"})
+	metricsQueryExecutorTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "metrics_query_executor_total"),
+		"queryExecutor is a document that reports data from the query execution system",
+		[]string{"state"}, nil,
+	)
 )
 var (
-	metricsRecordMovesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_record",
-		Name:      "moves_total",
-		Help:      "moves reports the total number of times documents move within the on-disk representation of the MongoDB data set. Documents move as a result of operations that increase the size of the document beyond their allocated record size",
-	})
+	metricsRecordMovesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_record", "moves_total"),
+		"moves reports the total number of times documents move within the on-disk representation of the MongoDB data set. Documents move as a result of operations that increase the size of the document beyond their allocated record size",
+		nil, nil,
+	)
 )
 var (
-	metricsReplApplyBatchesNumTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_apply_batches",
-		Name:      "num_total",
-		Help:      "num reports the total number of batches applied across all databases",
-	})
-	metricsReplApplyBatchesTotalMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_apply_batches",
-		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time the mongod has spent applying operations from the oplog",
-	})
+	metricsReplApplyBatchesNumTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_apply_batches", "num_total"),
+		"num reports the total number of batches applied across all databases",
+		nil, nil,
+	)
+	metricsReplApplyBatchesTotalMilliseconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_apply_batches", "total_milliseconds"),
+		"total_millis reports the total amount of time the mongod has spent applying operations from the oplog",
+		nil, nil,
+	)
 )
 var (
-	metricsReplApplyOpsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_apply",
-		Name:      "ops_total",
-		Help:      "ops reports the total number of oplog operations applied",
-	})
+	metricsReplApplyOpsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_apply", "ops_total"),
+		"ops reports the total number of oplog operations applied",
+		nil, nil,
+	)
 )
 var (
-	metricsReplBufferCount = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_buffer",
-		Name:      "count",
-		Help:      "count reports the current number of operations in the oplog buffer",
-	})
-	metricsReplBufferMaxSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_buffer",
-		Name:      "max_size_bytes",
-		Help:      "maxSizeBytes reports the maximum size of the buffer. This value is a constant setting in the mongod, and is not configurable",
-	})
-	metricsReplBufferSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_buffer",
-		Name:      "size_bytes",
-		Help:      "sizeBytes reports the current size of the contents of the oplog buffer",
-	})
+	metricsReplBufferCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_buffer", "count"),
+		"count reports the current number of operations in the oplog buffer",
+		nil, nil,
+	)
+	metricsReplBufferMaxSizeBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_buffer", "max_size_bytes"),
+		"maxSizeBytes reports the maximum size of the buffer. This value is a constant setting in the mongod, and is not configurable",
+		nil, nil,
+	)
+	metricsReplBufferSizeBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_buffer", "size_bytes"),
+		"sizeBytes reports the current size of the contents of the oplog buffer",
+		nil, nil,
+	)
 )
 var (
-	metricsReplNetworkGetmoresNumTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_network_getmores",
-		Name:      "num_total",
-		Help:      "num reports the total number of getmore operations, which are operations that request an additional set of operations from the replication sync source.",
-	})
-	metricsReplNetworkGetmoresTotalMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_network_getmores",
-		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time required to collect data from getmore operations",
-	})
+	metricsReplNetworkGetmoresNumTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_network_getmores", "num_total"),
+		"num reports the total number of getmore operations, which are operations that request an additional set of operations from the replication sync source.",
+		nil, nil,
+	)
+	metricsReplNetworkGetmoresTotalMilliseconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_network_getmores", "total_milliseconds"),
+		"total_millis reports the total amount of time required to collect data from getmore operations",
+		nil, nil,
+	)
 )
 var (
-	metricsReplNetworkBytesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_network",
-		Name:      "bytes_total",
-		Help:      "bytes reports the total amount of data read from the replication sync source",
-	})
-	metricsReplNetworkOpsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_network",
-		Name:      "ops_total",
-		Help:      "ops reports the total number of operations read from the replication source.",
-	})
-	metricsReplNetworkReadersCreatedTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_network",
-		Name:      "readers_created_total",
-		Help:      "readersCreated reports the total number of oplog query processes created. MongoDB will create a new oplog query any time an error occurs in the connection, including a timeout, or a network operation. Furthermore, readersCreated will increment every time MongoDB selects a new source fore replication.",
-	})
+	metricsReplNetworkBytesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_network", "bytes_total"),
+		"bytes reports the total amount of data read from the replication sync source",
+		nil, nil,
+	)
+	metricsReplNetworkOpsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_network", "ops_total"),
+		"ops reports the total number of operations read from the replication source.",
+		nil, nil,
+	)
+	metricsReplNetworkReadersCreatedTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_network", "readers_created_total"),
+		"readersCreated reports the total number of oplog query processes created. MongoDB will create a new oplog query any time an error occurs in the connection, including a timeout, or a network operation. Furthermore, readersCreated will increment every time MongoDB selects a new source fore replication.",
+		nil, nil,
+	)
 )
 var (
-	metricsReplOplogInsertNumTotal = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_oplog_insert",
-		Name:      "num_total",
-		Help:      "num reports the total number of items inserted into the oplog.",
-	})
-	metricsReplOplogInsertTotalMilliseconds = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_oplog_insert",
-		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time spent for the mongod to insert data into the oplog.",
-	})
+	metricsReplOplogInsertNumTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_oplog_insert", "num_total"),
+		"num reports the total number of items inserted into the oplog.",
+		nil, nil,
+	)
+	metricsReplOplogInsertTotalMilliseconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_oplog_insert", "total_milliseconds"),
+		"total_millis reports the total amount of time spent for the mongod to insert data into the oplog.",
+		nil, nil,
+	)
 )
 var (
-	metricsReplOplogInsertBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_oplog",
-		Name:      "insert_bytes_total",
-		Help:      "insertBytes the total size of documents inserted into the oplog.",
-	})
+	metricsReplOplogInsertBytesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_oplog", "insert_bytes_total"),
+		"insertBytes the total size of documents inserted into the oplog.",
+		nil, nil,
+	)
 )
 var (
-	metricsReplPreloadDocsNumTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_preload_docs",
-		Name:      "num_total",
-		Help:      "num reports the total number of documents loaded during the pre-fetch stage of replication",
-	})
-	metricsReplPreloadDocsTotalMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_preload_docs",
-		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time spent loading documents as part of the pre-fetch stage of replication",
-	})
+	metricsReplPreloadDocsNumTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_preload_docs", "num_total"),
+		"num reports the total number of documents loaded during the pre-fetch stage of replication",
+		nil, nil,
+	)
+	metricsReplPreloadDocsTotalMilliseconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_preload_docs", "total_milliseconds"),
+		"total_millis reports the total amount of time spent loading documents as part of the pre-fetch stage of replication",
+		nil, nil,
+	)
 )
 var (
-	metricsReplPreloadIndexesNumTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_preload_indexes",
-		Name:      "num_total",
-		Help:      "num reports the total number of index entries loaded by members before updating documents as part of the pre-fetch stage of replication",
-	})
-	metricsReplPreloadIndexesTotalMilliseconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_repl_preload_indexes",
-		Name:      "total_milliseconds",
-		Help:      "total_millis reports the total amount of time spent loading index entries as part of the pre-fetch stage of replication",
-	})
+	metricsReplPreloadIndexesNumTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_preload_indexes", "num_total"),
+		"num reports the total number of index entries loaded by members before updating documents as part of the pre-fetch stage of replication",
+		nil, nil,
+	)
+	metricsReplPreloadIndexesTotalMilliseconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_repl_preload_indexes", "total_milliseconds"),
+		"total_millis reports the total amount of time spent loading index entries as part of the pre-fetch stage of replication",
+		nil, nil,
+	)
 )
 var (
-	metricsStorageFreelistSearchTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "metrics_storage_freelist_search_total",
-		Help:      "metrics about searching records in the database.",
-	}, []string{"type"})
+	metricsStorageFreelistSearchTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "metrics_storage_freelist_search_total"),
+		"metrics about searching records in the database.",
+		[]string{"type"}, nil,
+	)
 )
 var (
-	metricsTTLDeletedDocumentsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_ttl",
-		Name:      "deleted_documents_total",
-		Help:      "deletedDocuments reports the total number of documents deleted from collections with a ttl index.",
-	})
-	metricsTTLPassesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_ttl",
-		Name:      "passes_total",
-		Help:      "passes reports the number of times the background process removes documents from collections with a ttl index",
-	})
+	metricsTTLDeletedDocumentsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_ttl", "deleted_documents_total"),
+		"deletedDocuments reports the total number of documents deleted from collections with a ttl index.",
+		nil, nil,
+	)
+	metricsTTLPassesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_ttl", "passes_total"),
+		"passes reports the number of times the background process removes documents from collections with a ttl index",
+		nil, nil,
+	)
 )
 var (
-	metricsQueryPlanCacheTotalSizeEstimateBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_query",
-		Name:      "plan_cache_total_size_estimate_bytes",
-		Help:      "planCacheTotalSizeEstimateBytes reports the estimated size of plan cache in bytes.",
-	})
-	metricsQueryUpdateOneOpStyleBroadcastWithExactIDCount = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "metrics_query",
-		Name:      "update_broadcast_with_exact_ID_count",
-		Help:      "updateOneOpStyleBroadcastWithExactIDCount Tracks the number of {multi:false} updates with an exact match on _id that are broadcasted to multiple shards.",
-	})
+	metricsQueryPlanCacheTotalSizeEstimateBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_query", "plan_cache_total_size_estimate_bytes"),
+		"planCacheTotalSizeEstimateBytes reports the estimated size of plan cache in bytes.",
+		nil, nil,
+	)
+	metricsQueryUpdateOneOpStyleBroadcastWithExactIDCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "metrics_query", "update_broadcast_with_exact_ID_count"),
+		"updateOneOpStyleBroadcastWithExactIDCount Tracks the number of {multi:false} updates with an exact match on _id that are broadcasted to multiple shards.",
+		nil, nil,
+	)
 )
 
 // DocumentStats are the stats associated to a document.
@@ -242,10 +215,10 @@ type DocumentStats struct {
 
 // Export exposes the document stats to be consumed by the prometheus server.
 func (documentStats *DocumentStats) Export(ch chan<- prometheus.Metric) {
-	metricsDocumentTotal.WithLabelValues("deleted").Set(documentStats.Deleted)
-	metricsDocumentTotal.WithLabelValues("inserted").Set(documentStats.Inserted)
-	metricsDocumentTotal.WithLabelValues("returned").Set(documentStats.Returned)
-	metricsDocumentTotal.WithLabelValues("updated").Set(documentStats.Updated)
+	ch <- prometheus.MustNewConstMetric(metricsDocumentTotal, prometheus.GaugeValue, documentStats.Deleted, "deleted")
+	ch <- prometheus.MustNewConstMetric(metricsDocumentTotal, prometheus.GaugeValue, documentStats.Inserted, "inserted")
+	ch <- prometheus.MustNewConstMetric(metricsDocumentTotal, prometheus.GaugeValue, documentStats.Returned, "returned")
+	ch <- prometheus.MustNewConstMetric(metricsDocumentTotal, prometheus.GaugeValue, documentStats.Updated, "updated")
 }
 
 // BenchmarkStats is bechmark info about an operation.
@@ -262,10 +235,10 @@ type GetLastErrorStats struct {
 
 // Export exposes the get last error stats.
 func (getLastErrorStats *GetLastErrorStats) Export(ch chan<- prometheus.Metric) {
-	metricsGetLastErrorWtimeNumTotal.Set(getLastErrorStats.Wtime.Num)
-	metricsGetLastErrorWtimeTotalMilliseconds.Set(getLastErrorStats.Wtime.TotalMillis)
+	ch <- prometheus.MustNewConstMetric(metricsGetLastErrorWtimeNumTotal, prometheus.GaugeValue, getLastErrorStats.Wtime.Num)
+	ch <- prometheus.MustNewConstMetric(metricsGetLastErrorWtimeTotalMilliseconds, prometheus.GaugeValue, getLastErrorStats.Wtime.TotalMillis)
 
-	metricsGetLastErrorWtimeoutsTotal.Set(getLastErrorStats.Wtimeouts)
+	ch <- prometheus.MustNewConstMetric(metricsGetLastErrorWtimeoutsTotal, prometheus.GaugeValue, getLastErrorStats.Wtimeouts)
 }
 
 // OperationStats are the stats for some kind of operations.
@@ -277,9 +250,9 @@ type OperationStats struct {
 
 // Export exports the operation stats.
 func (operationStats *OperationStats) Export(ch chan<- prometheus.Metric) {
-	metricsOperationTotal.WithLabelValues("fastmod").Set(operationStats.Fastmod)
-	metricsOperationTotal.WithLabelValues("idhack").Set(operationStats.Idhack)
-	metricsOperationTotal.WithLabelValues("scan_and_order").Set(operationStats.ScanAndOrder)
+	ch <- prometheus.MustNewConstMetric(metricsOperationTotal, prometheus.GaugeValue, operationStats.Fastmod, "fastmod")
+	ch <- prometheus.MustNewConstMetric(metricsOperationTotal, prometheus.GaugeValue, operationStats.Idhack, "idhack")
+	ch <- prometheus.MustNewConstMetric(metricsOperationTotal, prometheus.GaugeValue, operationStats.ScanAndOrder, "scan_and_order")
 }
 
 // QueryExecutorStats are the stats associated with a query execution.
@@ -290,8 +263,8 @@ type QueryExecutorStats struct {
 
 // Export exports the query executor stats.
 func (queryExecutorStats *QueryExecutorStats) Export(ch chan<- prometheus.Metric) {
-	metricsQueryExecutorTotal.WithLabelValues("scanned").Set(queryExecutorStats.Scanned)
-	metricsQueryExecutorTotal.WithLabelValues("scanned_objects").Set(queryExecutorStats.ScannedObjects)
+	ch <- prometheus.MustNewConstMetric(metricsQueryExecutorTotal, prometheus.GaugeValue, queryExecutorStats.Scanned, "scanned")
+	ch <- prometheus.MustNewConstMetric(metricsQueryExecutorTotal, prometheus.GaugeValue, queryExecutorStats.ScannedObjects, "scanned_objects")
 }
 
 // RecordStats are stats associated with a record.
@@ -301,7 +274,7 @@ type RecordStats struct {
 
 // Export exposes the record stats.
 func (recordStats *RecordStats) Export(ch chan<- prometheus.Metric) {
-	metricsRecordMovesTotal.Set(recordStats.Moves)
+	ch <- prometheus.MustNewConstMetric(metricsRecordMovesTotal, prometheus.GaugeValue, recordStats.Moves)
 }
 
 // ApplyStats are the stats associated with the apply operation.
@@ -313,10 +286,10 @@ type ApplyStats struct {
 // Export exports the apply stats
 func (applyStats *ApplyStats) Export(ch chan<- prometheus.Metric) {
 	if applyStats != nil {
-		metricsReplApplyOpsTotal.Set(applyStats.Ops)
+		ch <- prometheus.MustNewConstMetric(metricsReplApplyOpsTotal, prometheus.GaugeValue, applyStats.Ops)
 
-		metricsReplApplyBatchesNumTotal.Set(applyStats.Batches.Num)
-		metricsReplApplyBatchesTotalMilliseconds.Set(applyStats.Batches.TotalMillis)
+		ch <- prometheus.MustNewConstMetric(metricsReplApplyBatchesNumTotal, prometheus.GaugeValue, applyStats.Batches.Num)
+		ch <- prometheus.MustNewConstMetric(metricsReplApplyBatchesTotalMilliseconds, prometheus.GaugeValue, applyStats.Batches.TotalMillis)
 	}
 }
 
@@ -329,9 +302,9 @@ type BufferStats struct {
 
 // Export exports the buffer stats.
 func (bufferStats *BufferStats) Export(ch chan<- prometheus.Metric) {
-	metricsReplBufferCount.Set(bufferStats.Count)
-	metricsReplBufferMaxSizeBytes.Set(bufferStats.MaxSizeBytes)
-	metricsReplBufferSizeBytes.Set(bufferStats.SizeBytes)
+	ch <- prometheus.MustNewConstMetric(metricsReplBufferCount, prometheus.GaugeValue, bufferStats.Count)
+	ch <- prometheus.MustNewConstMetric(metricsReplBufferMaxSizeBytes, prometheus.GaugeValue, bufferStats.MaxSizeBytes)
+	ch <- prometheus.MustNewConstMetric(metricsReplBufferSizeBytes, prometheus.GaugeValue, bufferStats.SizeBytes)
 }
 
 // MetricsNetworkStats are the network stats.
@@ -344,13 +317,13 @@ type MetricsNetworkStats struct {
 
 // Export exposes the network stats.
 func (metricsNetworkStats *MetricsNetworkStats) Export(ch chan<- prometheus.Metric) {
-	metricsReplNetworkBytesTotal.Set(metricsNetworkStats.Bytes)
-	metricsReplNetworkOpsTotal.Set(metricsNetworkStats.Ops)
-	metricsReplNetworkReadersCreatedTotal.Set(metricsNetworkStats.ReadersCreated)
+	ch <- prometheus.MustNewConstMetric(metricsReplNetworkBytesTotal, prometheus.GaugeValue, metricsNetworkStats.Bytes)
+	ch <- prometheus.MustNewConstMetric(metricsReplNetworkOpsTotal, prometheus.GaugeValue, metricsNetworkStats.Ops)
+	ch <- prometheus.MustNewConstMetric(metricsReplNetworkReadersCreatedTotal, prometheus.GaugeValue, metricsNetworkStats.ReadersCreated)
 
 	if metricsNetworkStats.GetMores != nil {
-		metricsReplNetworkGetmoresNumTotal.Set(metricsNetworkStats.GetMores.Num)
-		metricsReplNetworkGetmoresTotalMilliseconds.Set(metricsNetworkStats.GetMores.TotalMillis)
+		ch <- prometheus.MustNewConstMetric(metricsReplNetworkGetmoresNumTotal, prometheus.GaugeValue, metricsNetworkStats.GetMores.Num)
+		ch <- prometheus.MustNewConstMetric(metricsReplNetworkGetmoresTotalMilliseconds, prometheus.GaugeValue, metricsNetworkStats.GetMores.TotalMillis)
 	}
 }
 
@@ -360,13 +333,14 @@ type ReplStats struct {
 	Buffer       *BufferStats         `bson:"buffer"`
 	Network      *MetricsNetworkStats `bson:"network"`
 	PreloadStats *PreloadStats        `bson:"preload"`
+	Oplog        *ReplOplogStats      `bson:"oplog"`
 }
 
 // Export exposes the replication stats.
 func (replStats *ReplStats) Export(ch chan<- prometheus.Metric) {
 	if replStats != nil {
-		if replStats.PreloadStats != nil {
-			replStats.PreloadStats.Export(ch)
+		if replStats.Apply != nil {
+			replStats.Apply.Export(ch)
 		}
 		if replStats.Buffer != nil {
 			replStats.Buffer.Export(ch)
@@ -377,7 +351,28 @@ func (replStats *ReplStats) Export(ch chan<- prometheus.Metric) {
 		if replStats.PreloadStats != nil {
 			replStats.PreloadStats.Export(ch)
 		}
+		replStats.Oplog.Export(ch)
+	}
+}
+
+// ReplOplogStats are the stats associated with the inserts into the oplog.
+type ReplOplogStats struct {
+	Insert      *BenchmarkStats `bson:"insert"`
+	InsertBytes float64         `bson:"insertBytes"`
+}
+
+// Export exposes the oplog insert stats, as zeros when the server doesn't report them.
+func (oplogStats *ReplOplogStats) Export(ch chan<- prometheus.Metric) {
+	insert, insertBytes := &BenchmarkStats{}, 0.0
+	if oplogStats != nil {
+		if oplogStats.Insert != nil {
+			insert = oplogStats.Insert
+		}
+		insertBytes = oplogStats.InsertBytes
 	}
+	ch <- prometheus.MustNewConstMetric(metricsReplOplogInsertNumTotal, prometheus.CounterValue, insert.Num)
+	ch <- prometheus.MustNewConstMetric(metricsReplOplogInsertTotalMilliseconds, prometheus.CounterValue, insert.TotalMillis)
+	ch <- prometheus.MustNewConstMetric(metricsReplOplogInsertBytesTotal, prometheus.CounterValue, insertBytes)
 }
 
 // PreloadStats are the stats associated with preload operation.
@@ -389,13 +384,13 @@ type PreloadStats struct {
 // Export exposes the preload stats.
 func (preloadStats *PreloadStats) Export(ch chan<- prometheus.Metric) {
 	if preloadStats.Docs != nil {
-		metricsReplPreloadDocsNumTotal.Set(preloadStats.Docs.Num)
-		metricsReplPreloadDocsTotalMilliseconds.Set(preloadStats.Docs.TotalMillis)
+		ch <- prometheus.MustNewConstMetric(metricsReplPreloadDocsNumTotal, prometheus.GaugeValue, preloadStats.Docs.Num)
+		ch <- prometheus.MustNewConstMetric(metricsReplPreloadDocsTotalMilliseconds, prometheus.GaugeValue, preloadStats.Docs.TotalMillis)
 	}
 
 	if preloadStats.Indexes != nil {
-		metricsReplPreloadIndexesNumTotal.Set(preloadStats.Indexes.Num)
-		metricsReplPreloadIndexesTotalMilliseconds.Set(preloadStats.Indexes.TotalMillis)
+		ch <- prometheus.MustNewConstMetric(metricsReplPreloadIndexesNumTotal, prometheus.GaugeValue, preloadStats.Indexes.Num)
+		ch <- prometheus.MustNewConstMetric(metricsReplPreloadIndexesTotalMilliseconds, prometheus.GaugeValue, preloadStats.Indexes.TotalMillis)
 	}
 }
 
@@ -408,9 +403,9 @@ type StorageStats struct {
 
 // Export exports the storage stats.
 func (storageStats *StorageStats) Export(ch chan<- prometheus.Metric) {
-	metricsStorageFreelistSearchTotal.WithLabelValues("bucket_exhausted").Set(storageStats.BucketExhausted)
-	metricsStorageFreelistSearchTotal.WithLabelValues("requests").Set(storageStats.Requests)
-	metricsStorageFreelistSearchTotal.WithLabelValues("scanned").Set(storageStats.Scanned)
+	ch <- prometheus.MustNewConstMetric(metricsStorageFreelistSearchTotal, prometheus.GaugeValue, storageStats.BucketExhausted, "bucket_exhausted")
+	ch <- prometheus.MustNewConstMetric(metricsStorageFreelistSearchTotal, prometheus.GaugeValue, storageStats.Requests, "requests")
+	ch <- prometheus.MustNewConstMetric(metricsStorageFreelistSearchTotal, prometheus.GaugeValue, storageStats.Scanned, "scanned")
 }
 
 // CursorStatsOpen are the stats for open cursors
@@ -428,10 +423,10 @@ type CursorStats struct {
 
 // Export exports the cursor stats.
 func (cursorStats *CursorStats) Export(ch chan<- prometheus.Metric) {
-	metricsCursorTimedOutTotal.Set(cursorStats.TimedOut)
-	metricsCursorOpen.WithLabelValues("timed_out").Set(cursorStats.Open.NoTimeout)
-	metricsCursorOpen.WithLabelValues("pinned").Set(cursorStats.Open.Pinned)
-	metricsCursorOpen.WithLabelValues("total").Set(cursorStats.Open.Total)
+	ch <- prometheus.MustNewConstMetric(metricsCursorTimedOutTotal, prometheus.GaugeValue, cursorStats.TimedOut)
+	ch <- prometheus.MustNewConstMetric(metricsCursorOpen, prometheus.GaugeValue, cursorStats.Open.NoTimeout, "timed_out")
+	ch <- prometheus.MustNewConstMetric(metricsCursorOpen, prometheus.GaugeValue, cursorStats.Open.Pinned, "pinned")
+	ch <- prometheus.MustNewConstMetric(metricsCursorOpen, prometheus.GaugeValue, cursorStats.Open.Total, "total")
 }
 
 // TTLStats are the stats with a ttl index
@@ -442,8 +437,8 @@ type TTLStats struct {
 
 // Export exports the ttl stats.
 func (ttlStats *TTLStats) Export(ch chan<- prometheus.Metric) {
-	metricsTTLDeletedDocumentsTotal.Set(ttlStats.DeletedDocuments)
-	metricsTTLPassesTotal.Set(ttlStats.Passes)
+	ch <- prometheus.MustNewConstMetric(metricsTTLDeletedDocumentsTotal, prometheus.GaugeValue, ttlStats.DeletedDocuments)
+	ch <- prometheus.MustNewConstMetric(metricsTTLPassesTotal, prometheus.GaugeValue, ttlStats.Passes)
 }
 
 // QueryStats are the stats with a ttl index
@@ -454,8 +449,8 @@ type QueryStats struct {
 
 // Export exports the query stats.
 func (queryStats *QueryStats) Export(ch chan<- prometheus.Metric) {
-	metricsQueryPlanCacheTotalSizeEstimateBytes.Set(queryStats.PlanCacheSize)
-	metricsQueryUpdateOneOpStyleBroadcastWithExactIDCount.Set(queryStats.UpdateCount)
+	ch <- prometheus.MustNewConstMetric(metricsQueryPlanCacheTotalSizeEstimateBytes, prometheus.GaugeValue, queryStats.PlanCacheSize)
+	ch <- prometheus.MustNewConstMetric(metricsQueryUpdateOneOpStyleBroadcastWithExactIDCount, prometheus.GaugeValue, queryStats.UpdateCount)
 }
 
 // MetricsStats are all stats associated with metrics of the system
@@ -505,70 +500,39 @@ func (metricsStats *MetricsStats) Export(ch chan<- prometheus.Metric) {
 		metricsStats.Query.Export(ch)
 	}
 
-	metricsCursorTimedOutTotal.Collect(ch)
-	metricsCursorOpen.Collect(ch)
-	metricsDocumentTotal.Collect(ch)
-	metricsGetLastErrorWtimeNumTotal.Collect(ch)
-	metricsGetLastErrorWtimeTotalMilliseconds.Collect(ch)
-	metricsGetLastErrorWtimeoutsTotal.Collect(ch)
-	metricsOperationTotal.Collect(ch)
-	metricsQueryExecutorTotal.Collect(ch)
-	metricsRecordMovesTotal.Collect(ch)
-	metricsReplApplyBatchesNumTotal.Collect(ch)
-	metricsReplApplyBatchesTotalMilliseconds.Collect(ch)
-	metricsReplApplyOpsTotal.Collect(ch)
-	metricsReplBufferCount.Collect(ch)
-	metricsReplBufferMaxSizeBytes.Collect(ch)
-	metricsReplBufferSizeBytes.Collect(ch)
-	metricsReplNetworkGetmoresNumTotal.Collect(ch)
-	metricsReplNetworkGetmoresTotalMilliseconds.Collect(ch)
-	metricsReplNetworkBytesTotal.Collect(ch)
-	metricsReplNetworkOpsTotal.Collect(ch)
-	metricsReplNetworkReadersCreatedTotal.Collect(ch)
-	metricsReplOplogInsertNumTotal.Collect(ch)
-	metricsReplOplogInsertTotalMilliseconds.Collect(ch)
-	metricsReplOplogInsertBytesTotal.Collect(ch)
-	metricsReplPreloadDocsNumTotal.Collect(ch)
-	metricsReplPreloadDocsTotalMilliseconds.Collect(ch)
-	metricsReplPreloadIndexesNumTotal.Collect(ch)
-	metricsReplPreloadIndexesTotalMilliseconds.Collect(ch)
-	metricsStorageFreelistSearchTotal.Collect(ch)
-	metricsTTLDeletedDocumentsTotal.Collect(ch)
-	metricsTTLPassesTotal.Collect(ch)
-	metricsQueryPlanCacheTotalSizeEstimateBytes.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (metricsStats *MetricsStats) Describe(ch chan<- *prometheus.Desc) {
-	metricsCursorTimedOutTotal.Describe(ch)
-	metricsCursorOpen.Describe(ch)
-	metricsDocumentTotal.Describe(ch)
-	metricsGetLastErrorWtimeNumTotal.Describe(ch)
-	metricsGetLastErrorWtimeTotalMilliseconds.Describe(ch)
-	metricsGetLastErrorWtimeoutsTotal.Describe(ch)
-	metricsOperationTotal.Describe(ch)
-	metricsQueryExecutorTotal.Describe(ch)
-	metricsRecordMovesTotal.Describe(ch)
-	metricsReplApplyBatchesNumTotal.Describe(ch)
-	metricsReplApplyBatchesTotalMilliseconds.Describe(ch)
-	metricsReplApplyOpsTotal.Describe(ch)
-	metricsReplBufferCount.Describe(ch)
-	metricsReplBufferMaxSizeBytes.Describe(ch)
-	metricsReplBufferSizeBytes.Describe(ch)
-	metricsReplNetworkGetmoresNumTotal.Describe(ch)
-	metricsReplNetworkGetmoresTotalMilliseconds.Describe(ch)
-	metricsReplNetworkBytesTotal.Describe(ch)
-	metricsReplNetworkOpsTotal.Describe(ch)
-	metricsReplNetworkReadersCreatedTotal.Describe(ch)
-	metricsReplOplogInsertNumTotal.Describe(ch)
-	metricsReplOplogInsertTotalMilliseconds.Describe(ch)
-	metricsReplOplogInsertBytesTotal.Describe(ch)
-	metricsReplPreloadDocsNumTotal.Describe(ch)
-	metricsReplPreloadDocsTotalMilliseconds.Describe(ch)
-	metricsReplPreloadIndexesNumTotal.Describe(ch)
-	metricsReplPreloadIndexesTotalMilliseconds.Describe(ch)
-	metricsStorageFreelistSearchTotal.Describe(ch)
-	metricsTTLDeletedDocumentsTotal.Describe(ch)
-	metricsTTLPassesTotal.Describe(ch)
-	metricsQueryPlanCacheTotalSizeEstimateBytes.Describe(ch)
+	ch <- metricsCursorTimedOutTotal
+	ch <- metricsCursorOpen
+	ch <- metricsDocumentTotal
+	ch <- metricsGetLastErrorWtimeNumTotal
+	ch <- metricsGetLastErrorWtimeTotalMilliseconds
+	ch <- metricsGetLastErrorWtimeoutsTotal
+	ch <- metricsOperationTotal
+	ch <- metricsQueryExecutorTotal
+	ch <- metricsRecordMovesTotal
+	ch <- metricsReplApplyBatchesNumTotal
+	ch <- metricsReplApplyBatchesTotalMilliseconds
+	ch <- metricsReplApplyOpsTotal
+	ch <- metricsReplBufferCount
+	ch <- metricsReplBufferMaxSizeBytes
+	ch <- metricsReplBufferSizeBytes
+	ch <- metricsReplNetworkGetmoresNumTotal
+	ch <- metricsReplNetworkGetmoresTotalMilliseconds
+	ch <- metricsReplNetworkBytesTotal
+	ch <- metricsReplNetworkOpsTotal
+	ch <- metricsReplNetworkReadersCreatedTotal
+	ch <- metricsReplOplogInsertNumTotal
+	ch <- metricsReplOplogInsertTotalMilliseconds
+	ch <- metricsReplOplogInsertBytesTotal
+	ch <- metricsReplPreloadDocsNumTotal
+	ch <- metricsReplPreloadDocsTotalMilliseconds
+	ch <- metricsReplPreloadIndexesNumTotal
+	ch <- metricsReplPreloadIndexesTotalMilliseconds
+	ch <- metricsStorageFreelistSearchTotal
+	ch <- metricsTTLDeletedDocumentsTotal
+	ch <- metricsTTLPassesTotal
+	ch <- metricsQueryPlanCacheTotalSizeEstimateBytes
 }
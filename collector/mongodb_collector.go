@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,38 +18,143 @@ var (
 )
 
 var (
-	upGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "up",
-		Help:      "To show if we can connect to mongodb instance",
-	}, []string{})
-
-	// Lock for using these metrics
-	collectorLock = sync.Mutex{}
+	upDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "up"),
+		"To show if we can connect to mongodb instance",
+		nil, nil,
+	)
+
+	collectorDurationDesc = prometheus.NewDesc(
+		"mongodb_exporter_collector_duration_seconds",
+		"How long each collector took during the last scrape.",
+		[]string{"collector"}, nil,
+	)
+	collectorSuccessDesc = prometheus.NewDesc(
+		"mongodb_exporter_collector_success",
+		"Whether each collector succeeded before its deadline during the last scrape.",
+		[]string{"collector"}, nil,
+	)
+	lastCollectionDesc = prometheus.NewDesc(
+		"mongodb_exporter_last_collection_timestamp_seconds",
+		"When the served metrics were collected.",
+		nil, nil,
+	)
+	collectorErrorsDesc = prometheus.NewDesc(
+		"mongodb_exporter_collector_errors_total",
+		"The total number of collector failures, by MongoDB error code.",
+		[]string{"collector", "code"}, nil,
+	)
 )
 
+// collectorErrors counts the failures of the collectors by target. They are kept out of the
+// collectors, as probes create a new collector for every request.
+var collectorErrors = newCollectorErrorCounts()
+
+// collectorErrorsIdleTimeout is how long the failures of a target which isn't scraped anymore,
+// e.g. because it is no longer probed, are kept, like the sessions of the shared.SessionManager.
+const collectorErrorsIdleTimeout = 10 * time.Minute
+
+// collectorErrorKey identifies the failures of a collector of a target.
+type collectorErrorKey struct {
+	target    string
+	collector string
+	code      string
+}
+
+type collectorErrorCounts struct {
+	lock   sync.Mutex
+	counts map[collectorErrorKey]float64
+	// lastUsed is when the failures of each target were last counted or exported.
+	lastUsed map[string]time.Time
+}
+
+func newCollectorErrorCounts() *collectorErrorCounts {
+	return &collectorErrorCounts{
+		counts:   make(map[collectorErrorKey]float64),
+		lastUsed: make(map[string]time.Time),
+	}
+}
+
+func (c *collectorErrorCounts) inc(target, collector, code string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.counts[collectorErrorKey{target: target, collector: collector, code: code}]++
+	c.lastUsed[target] = time.Now()
+}
+
+// export exports the failures of the collectors of the target, and forgets about the targets
+// which weren't scraped for a while.
+func (c *collectorErrorCounts) export(target string, ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lastUsed[target] = time.Now()
+	c.removeIdle(time.Now().Add(-collectorErrorsIdleTimeout))
+	for key, count := range c.counts {
+		if key.target == target {
+			ch <- prometheus.MustNewConstMetric(collectorErrorsDesc, prometheus.CounterValue, count, key.collector, key.code)
+		}
+	}
+}
+
+// removeIdle deletes the failures of the targets last used before the deadline. The caller must
+// hold c.lock.
+func (c *collectorErrorCounts) removeIdle(deadline time.Time) {
+	for target, lastUsed := range c.lastUsed {
+		if lastUsed.Before(deadline) {
+			delete(c.lastUsed, target)
+		}
+	}
+	for key := range c.counts {
+		if _, ok := c.lastUsed[key.target]; !ok {
+			delete(c.counts, key)
+		}
+	}
+}
+
+// DefaultCollectorTimeout is how long a collector may take when no timeout is given.
+const DefaultCollectorTimeout = 8 * time.Second
+
 // MongodbCollectorOpts is the options of the mongodb collector.
 type MongodbCollectorOpts struct {
-	URI                      string
-	TLSCertificateFile       string
-	TLSPrivateKeyFile        string
-	TLSCaFile                string
-	TLSHostnameValidation    bool
-	TLSAuth                  bool
-	CollectReplSet           bool
-	CollectOplog             bool
-	TailOplog                bool
-	CollectTopMetrics        bool
-	CollectDatabaseMetrics   bool
-	CollectCollectionMetrics bool
-	CollectProfileMetrics    bool
-	CollectConnPoolStats     bool
-	CollectParameterMetrics  bool
-	CollectParameters        string
-	UserName                 string
-	AuthMechanism            string
-	SocketTimeout            time.Duration
-	MaxTimeMS                int64
+	URI                   string
+	TLSCertificateFile    string
+	TLSPrivateKeyFile     string
+	TLSCaFile             string
+	TLSHostnameValidation bool
+	TLSAuth               bool
+	// Collectors are the names of the registered collectors to run, the ones enabled by the
+	// --collector.<name> flags when nil.
+	Collectors        []string
+	CollectParameters string
+	// EnabledGroups are the serverStatus groups to export, the ones given by --groups.enabled
+	// when nil.
+	EnabledGroups map[string]bool
+	// MetricDefinitions are extra metrics exported from serverStatus, loaded from a
+	// groups.yml-style file.
+	MetricDefinitions *MetricDefinitions
+	// DiscoverServerStatus exports every numeric serverStatus field which isn't exported otherwise.
+	DiscoverServerStatus bool
+	UserName             string
+	Password             string
+	AuthMechanism        string
+	SocketTimeout        time.Duration
+	MaxTimeMS            int64
+	// CollectorTimeout is how long each collector may take, DefaultCollectorTimeout when zero.
+	CollectorTimeout time.Duration
+	// CollectorTimeouts overrides CollectorTimeout for single collectors, by name.
+	CollectorTimeouts map[string]time.Duration
+	// SessionManager keeps the sessions used for scraping, a private one is created when nil.
+	SessionManager *shared.SessionManager
+	// CollectInterval makes the collector collect in the background once Start is called, scrapes
+	// are then served the last complete collection. Zero collects on every scrape.
+	CollectInterval time.Duration
+	// OplogTail are the options of the oplog_tail collector.
+	OplogTail OplogTailOpts
+	// OplogSampleWindow is how far back the oplog_sample collector aggregates the oplog,
+	// DefaultOplogSampleWindow when zero.
+	OplogSampleWindow time.Duration
 }
 
 func (in MongodbCollectorOpts) toSessionOps() shared.MongoSessionOpts {
@@ -59,237 +166,338 @@ func (in MongodbCollectorOpts) toSessionOps() shared.MongoSessionOpts {
 		TLSHostnameValidation: in.TLSHostnameValidation,
 		TLSAuth:               in.TLSAuth,
 		UserName:              in.UserName,
+		Password:              in.Password,
 		AuthMechanism:         in.AuthMechanism,
 		SocketTimeout:         in.SocketTimeout,
 	}
 }
 
+func (in MongodbCollectorOpts) enabledGroups() map[string]bool {
+	if in.EnabledGroups != nil {
+		return in.EnabledGroups
+	}
+	return shared.EnabledGroups
+}
+
+func (in MongodbCollectorOpts) collectorTimeout(name string) time.Duration {
+	if timeout, ok := in.CollectorTimeouts[name]; ok && timeout > 0 {
+		return timeout
+	}
+	if in.CollectorTimeout > 0 {
+		return in.CollectorTimeout
+	}
+	return DefaultCollectorTimeout
+}
+
+// ParseCollectorTimeouts parses a comma-separated list of collector=duration pairs,
+// e.g. "collection=30s,top=5s".
+func ParseCollectorTimeouts(list string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid collector timeout %q, expected collector=duration", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for collector %q: %s", parts[0], err)
+		}
+		timeouts[strings.TrimSpace(parts[0])] = timeout
+	}
+	return timeouts, nil
+}
+
 // MongodbCollector is in charge of collecting mongodb's metrics.
 type MongodbCollector struct {
 	Opts MongodbCollectorOpts
+
+	collectors map[string]Collector
+	names      []string
+	// target names the target in collectorErrors.
+	target string
+
+	// lock guards the collection in progress, which concurrent scrapes wait for instead of
+	// starting their own, and the last complete collection.
+	lock     sync.Mutex
+	inflight *collection
+	last     *snapshot
+	stop     chan struct{}
+}
+
+// snapshot is the metrics of one complete collection.
+type snapshot struct {
+	metrics []prometheus.Metric
+}
+
+// collection is a collection in progress, its snapshot is set once done is closed.
+type collection struct {
+	done     chan struct{}
+	snapshot *snapshot
 }
 
 // NewMongodbCollector returns a new instance of a MongodbCollector.
 func NewMongodbCollector(opts MongodbCollectorOpts) *MongodbCollector {
+	if opts.SessionManager == nil {
+		opts.SessionManager = shared.NewSessionManager()
+	}
+	if opts.Collectors == nil {
+		opts.Collectors = EnabledCollectors()
+	}
 	exporter := &MongodbCollector{
-		Opts: opts,
+		Opts:   opts,
+		target: uriHosts(opts.URI),
 	}
 
+	collectors, names, err := newCollectors(opts.Collectors, opts)
+	if err != nil {
+		glog.Errorf("Couldn't create the collectors: %s", err)
+		collectors, names = map[string]Collector{}, nil
+	}
+	exporter.collectors = collectors
+	exporter.names = names
+
 	return exporter
 }
 
 // Describe describes all mongodb's metrics.
 func (exporter *MongodbCollector) Describe(ch chan<- *prometheus.Desc) {
-	(&CurrentOp{}).Describe(ch)
-	(&ServerStatus{}).Describe(ch)
-	(&ReplSetStatus{}).Describe(ch)
-	(&ReplSetConf{}).Describe(ch)
-	(&DatabaseStatus{}).Describe(ch)
-
-	if exporter.Opts.CollectTopMetrics {
-		(&TopStatus{}).Describe(ch)
+	ch <- upDesc
+	ch <- collectorDurationDesc
+	ch <- collectorSuccessDesc
+	ch <- lastCollectionDesc
+	ch <- collectorErrorsDesc
+
+	for _, name := range exporter.names {
+		if d, ok := exporter.collectors[name].(describer); ok {
+			d.Describe(ch)
+		}
 	}
 }
 
-// Collect collects all mongodb's metrics.
+// Collect collects all mongodb's metrics. In background mode the last complete collection is
+// served, otherwise the scrape waits for a new one.
 func (exporter *MongodbCollector) Collect(ch chan<- prometheus.Metric) {
-	mongoSess := shared.MongoSession(exporter.Opts.toSessionOps())
-	if mongoSess != nil {
-		collectorLock.Lock()
-		upGauge.WithLabelValues().Set(float64(1))
-		upGauge.Collect(ch)
-		upGauge.Reset()
-		collectorLock.Unlock()
-		defer mongoSess.Close()
-		glog.Info("Collecting CurrentOp Info")
-		exporter.collectCurrentOp(mongoSess, ch)
-		glog.Info("Collecting Server Status")
-		exporter.collectServerStatus(mongoSess, ch)
-		if exporter.Opts.CollectReplSet {
-			glog.Info("Collecting ReplSet Status")
-			exporter.collectReplSetStatus(mongoSess, ch)
-			exporter.collectReplSetConf(mongoSess, ch)
-		}
-		if exporter.Opts.CollectOplog {
-			glog.Info("Collecting Oplog Status")
-			exporter.collectOplogStatus(mongoSess, ch)
-		}
-
-		if exporter.Opts.TailOplog {
-			glog.Info("Collecting Oplog Tail Stats")
-			exporter.collectOplogTailStats(mongoSess, ch)
-		}
-
-		if exporter.Opts.CollectTopMetrics {
-			glog.Info("Collecting Top Metrics")
-			exporter.collectTopStatus(mongoSess, ch)
-		}
-
-		if exporter.Opts.CollectDatabaseMetrics {
-			glog.Info("Collecting Database Metrics")
-			exporter.collectDatabaseStatus(mongoSess, ch)
-		}
-
-		if exporter.Opts.CollectCollectionMetrics {
-			glog.Info("Collection Collection Metrics")
-			exporter.collectCollectionStatus(mongoSess, ch)
-		}
-
-		if exporter.Opts.CollectProfileMetrics {
-			glog.Info("Collection Profile Metrics")
-			exporter.collectProfileStatus(mongoSess, ch)
-		}
-
-		if exporter.Opts.CollectConnPoolStats {
-			glog.Info("Collecting Connection Pool Stats")
-			exporter.collectConnPoolStats(mongoSess, ch)
-		}
-		if exporter.Opts.CollectParameterMetrics {
-			glog.Info("Collection parameter metrics")
-			exporter.collectParameter(mongoSess, ch, exporter.Opts.CollectParameters)
-		}
-	} else {
-		collectorLock.Lock()
-		upGauge.WithLabelValues().Set(float64(0))
-		upGauge.Collect(ch)
-		upGauge.Reset()
-		collectorLock.Unlock()
+	var last *snapshot
+	if exporter.Opts.CollectInterval > 0 {
+		exporter.lock.Lock()
+		last = exporter.last
+		exporter.lock.Unlock()
 	}
-}
-
-func (exporter *MongodbCollector) collectCurrentOp(session *mgo.Session, ch chan<- prometheus.Metric) *CurrentOp {
-	currentOpInfo := GetCurrentOp(session, exporter.Opts.MaxTimeMS)
-	if currentOpInfo != nil {
-		glog.Info("exporting CurrentOp Metrics")
-		currentOpInfo.Export(ch)
+	if last == nil {
+		last = exporter.collect()
 	}
-	return currentOpInfo
-}
 
-func (exporter *MongodbCollector) collectServerStatus(session *mgo.Session, ch chan<- prometheus.Metric) *ServerStatus {
-	serverStatus := GetServerStatus(session, exporter.Opts.MaxTimeMS)
-	if serverStatus != nil {
-		glog.Info("exporting ServerStatus Metrics")
-		serverStatus.Export(ch)
+	for _, metric := range last.metrics {
+		ch <- metric
 	}
-	return serverStatus
+	collectorErrors.export(exporter.target, ch)
 }
 
-func (exporter *MongodbCollector) collectParameter(session *mgo.Session, ch chan<- prometheus.Metric, parameters string) *ParameterMetrics {
-	parameterMetrics := GetParameters(session, parameters)
-
-	if parameterMetrics != nil {
-		glog.Info("exporting Parameter Metrics")
-		parameterMetrics.Export(ch)
+// Start starts the collectors which run in the background, and collects every CollectInterval
+// until Stop is called when it isn't zero.
+func (exporter *MongodbCollector) Start() {
+	for _, name := range exporter.names {
+		if b, ok := exporter.collectors[name].(background); ok {
+			b.start()
+		}
+	}
+	if exporter.Opts.CollectInterval <= 0 {
+		return
 	}
 
-	return parameterMetrics
+	exporter.lock.Lock()
+	defer exporter.lock.Unlock()
+	if exporter.stop != nil {
+		return
+	}
+	exporter.stop = make(chan struct{})
+	go exporter.collectInBackground(exporter.Opts.CollectInterval, exporter.stop)
 }
 
-func (exporter *MongodbCollector) collectReplSetStatus(session *mgo.Session, ch chan<- prometheus.Metric) *ReplSetStatus {
-	replSetStatus := GetReplSetStatus(session)
-
-	if replSetStatus != nil {
-		glog.Info("exporting ReplSetStatus Metrics")
-		replSetStatus.Export(ch)
+// Stop stops collecting in the background and stops the collectors which run in the background.
+// The last collection keeps being served.
+func (exporter *MongodbCollector) Stop() {
+	exporter.lock.Lock()
+	if exporter.stop != nil {
+		close(exporter.stop)
+		exporter.stop = nil
 	}
+	exporter.lock.Unlock()
 
-	return replSetStatus
+	for _, name := range exporter.names {
+		if b, ok := exporter.collectors[name].(background); ok {
+			b.stop()
+		}
+	}
 }
 
-func (exporter *MongodbCollector) collectReplSetConf(session *mgo.Session, ch chan<- prometheus.Metric) *ReplSetConf {
-	replSetConf := GetReplSetConf(session)
-
-	if replSetConf != nil {
-		glog.Info("exporting ReplSetConf Metrics")
-		replSetConf.Export(ch)
+func (exporter *MongodbCollector) collectInBackground(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		exporter.collect()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
 	}
-
-	return replSetConf
 }
 
-func (exporter *MongodbCollector) collectOplogStatus(session *mgo.Session, ch chan<- prometheus.Metric) *OplogStatus {
-	oplogStatus := GetOplogStatus(session, exporter.Opts.MaxTimeMS)
-
-	if oplogStatus != nil {
-		glog.Info("exporting OplogStatus Metrics")
-		oplogStatus.Export(ch)
+// collect returns the snapshot of a new collection, or of the one in progress if there is one.
+func (exporter *MongodbCollector) collect() *snapshot {
+	exporter.lock.Lock()
+	if current := exporter.inflight; current != nil {
+		exporter.lock.Unlock()
+		<-current.done
+		return current.snapshot
 	}
-
-	return oplogStatus
+	current := &collection{done: make(chan struct{})}
+	exporter.inflight = current
+	exporter.lock.Unlock()
+
+	current.snapshot = exporter.newSnapshot()
+
+	exporter.lock.Lock()
+	exporter.inflight = nil
+	exporter.last = current.snapshot
+	exporter.lock.Unlock()
+	close(current.done)
+	return current.snapshot
 }
 
-func (exporter *MongodbCollector) collectOplogTailStats(session *mgo.Session, ch chan<- prometheus.Metric) *OplogTailStats {
-	oplogTailStats := GetOplogTailStats(session)
+// newSnapshot collects the metrics of all the collectors.
+func (exporter *MongodbCollector) newSnapshot() *snapshot {
+	metrics, collected := bufferMetrics()
 
-	if oplogTailStats != nil {
-		glog.Info("exporting oplogTailStats Metrics")
-		oplogTailStats.Export(ch)
+	mongoSess, err := exporter.Opts.SessionManager.Session(exporter.Opts.toSessionOps())
+	if err != nil {
+		glog.Errorf("%s", err)
 	}
+	if mongoSess != nil {
+		metrics <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+		exporter.runCollectors(mongoSess, metrics)
+		mongoSess.Close()
+	} else {
+		metrics <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+	}
+
+	metrics <- prometheus.MustNewConstMetric(lastCollectionDesc, prometheus.GaugeValue, float64(time.Now().UnixNano())/1e9)
+	close(metrics)
 
-	return oplogTailStats
+	return &snapshot{metrics: <-collected}
 }
 
-func (exporter *MongodbCollector) collectTopStatus(session *mgo.Session, ch chan<- prometheus.Metric) *TopStatus {
-	topStatus := GetTopStatus(session)
-	if topStatus != nil {
-		glog.Info("exporting Top Metrics")
-		topStatus.Export(ch)
-	}
-	return topStatus
+// collectorResult is what a collector returned before its deadline.
+type collectorResult struct {
+	name     string
+	metrics  []prometheus.Metric
+	duration time.Duration
+	err      error
 }
 
-func (exporter *MongodbCollector) collectDatabaseStatus(session *mgo.Session, ch chan<- prometheus.Metric) {
-	all, err := session.DatabaseNames()
-	if err != nil {
-		glog.Error("Failed to get database names")
-		return
+// runCollectors runs all the collectors concurrently and sends the metrics of the ones that
+// finished before their deadline, together with their duration and success.
+func (exporter *MongodbCollector) runCollectors(session *mgo.Session, ch chan<- prometheus.Metric) {
+	names := exporter.runnableCollectors(session)
+	results := make(chan collectorResult, len(names))
+	for _, name := range names {
+		go func(name string) {
+			results <- exporter.runCollector(session, name, exporter.collectors[name])
+		}(name)
 	}
-	for _, db := range all {
-		if db == "admin" || db == "test" {
-			continue
+
+	for range names {
+		result := <-results
+		success := float64(1)
+		if result.err != nil {
+			success = 0
+			collectorErrors.inc(exporter.target, result.name, errorCode(result.err))
+			glog.Errorf("Collector %s failed after %s: %s", result.name, result.duration, result.err)
 		}
-		dbStatus := GetDatabaseStatus(session, db, exporter.Opts.MaxTimeMS)
-		if dbStatus != nil {
-			glog.Infof("exporting Database Metrics for db=%q", dbStatus.Name)
-			dbStatus.Export(ch)
+		for _, metric := range result.metrics {
+			ch <- metric
 		}
+		ch <- prometheus.MustNewConstMetric(collectorDurationDesc, prometheus.GaugeValue, result.duration.Seconds(), result.name)
+		ch <- prometheus.MustNewConstMetric(collectorSuccessDesc, prometheus.GaugeValue, success, result.name)
 	}
 }
 
-func (exporter *MongodbCollector) collectCollectionStatus(session *mgo.Session, ch chan<- prometheus.Metric) {
-	database_names, err := session.DatabaseNames()
+// runnableCollectors returns the collectors which work on the node of the session: the ones which
+// only work on mongod are skipped on mongos routers, and the other way round.
+func (exporter *MongodbCollector) runnableCollectors(session *mgo.Session) []string {
+	isMaster, err := getIsMaster(session)
 	if err != nil {
-		glog.Error("failed to get database names")
-		return
+		glog.Warningf("Couldn't tell whether the node is a mongos router, running all the collectors: %s", err)
+		return exporter.names
 	}
-	for _, db := range database_names {
-		if db == "admin" || db == "test" {
+
+	router := isMaster.isMongos()
+	var names []string
+	for _, name := range exporter.names {
+		if aware, ok := exporter.collectors[name].(routerAware); ok && aware.runsOnRouter() != router {
+			glog.V(1).Infof("Skipping %s, which doesn't work on this node (mongos=%t)", name, router)
 			continue
 		}
-		CollectCollectionStatus(session, db, ch, exporter.Opts.MaxTimeMS)
+		names = append(names, name)
 	}
+	return names
 }
 
-func (exporter *MongodbCollector) collectProfileStatus(session *mgo.Session, ch chan<- prometheus.Metric) {
-	all, err := session.DatabaseNames()
-	if err != nil {
-		glog.Error("failed to get database names: %s", err)
-		return
+// runCollector runs a collector on its own copy of the session. Its metrics are buffered so that
+// nothing it collects after its deadline ends up in the scrape.
+func (exporter *MongodbCollector) runCollector(session *mgo.Session, name string, collector Collector) collectorResult {
+	start := time.Now()
+	timeout := exporter.Opts.collectorTimeout(name)
+
+	collectorSession := session.Copy()
+	if exporter.Opts.SocketTimeout == 0 || exporter.Opts.SocketTimeout > timeout {
+		collectorSession.SetSocketTimeout(timeout)
 	}
-	for _, db := range all {
-		if db == "admin" || db == "test" {
-			continue
-		}
-		CollectProfileStatus(session, db, ch)
+
+	metrics, collected := bufferMetrics()
+
+	done := make(chan error, 1)
+	go func() {
+		defer collectorSession.Close()
+		glog.V(1).Infof("Collecting %s", name)
+		err := collector.Update(collectorSession, metrics)
+		close(metrics)
+		done <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return collectorResult{name: name, metrics: <-collected, duration: time.Since(start), err: err}
+	case <-timer.C:
+		return collectorResult{name: name, duration: time.Since(start), err: errCollectorTimeout}
 	}
 }
 
-func (exporter *MongodbCollector) collectConnPoolStats(session *mgo.Session, ch chan<- prometheus.Metric) {
-	connPoolStats := GetConnPoolStats(session)
+// bufferMetrics returns a channel whose metrics are buffered until it is closed, and a channel
+// receiving them then.
+func bufferMetrics() (chan prometheus.Metric, <-chan []prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	collected := make(chan []prometheus.Metric, 1)
+	go func() {
+		var buffer []prometheus.Metric
+		for metric := range metrics {
+			buffer = append(buffer, metric)
+		}
+		collected <- buffer
+	}()
+	return metrics, collected
+}
 
-	if connPoolStats != nil {
-		glog.Info("exporting ConnPoolStats Metrics")
-		connPoolStats.Export(ch)
+func boolToFloat64(value bool) float64 {
+	if value {
+		return 1
 	}
+	return 0
 }
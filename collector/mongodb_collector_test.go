@@ -2,9 +2,11 @@ package collector
 
 import (
 	"testing"
+	"time"
 
 	"github.com/dcu/mongodb_exporter/shared"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func Test_CollectServerStatus(t *testing.T) {
@@ -26,3 +28,124 @@ func Test_CollectCollector(t *testing.T) {
 	ch := make(chan prometheus.Metric)
 	go collector.Collect(ch)
 }
+
+func Test_ParseCollectorTimeouts(t *testing.T) {
+	timeouts, err := ParseCollectorTimeouts("collection=30s, top=500ms,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeouts) != 2 || timeouts["collection"] != 30*time.Second || timeouts["top"] != 500*time.Millisecond {
+		t.Errorf("unexpected timeouts %v", timeouts)
+	}
+
+	if _, err := ParseCollectorTimeouts("collection"); err == nil {
+		t.Error("expected an error for a missing duration")
+	}
+	if _, err := ParseCollectorTimeouts("collection=soon"); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+
+	opts := MongodbCollectorOpts{CollectorTimeouts: timeouts}
+	if timeout := opts.collectorTimeout("collection"); timeout != 30*time.Second {
+		t.Errorf("expected the collection override, got %s", timeout)
+	}
+	if timeout := opts.collectorTimeout("serverstatus"); timeout != DefaultCollectorTimeout {
+		t.Errorf("expected the default timeout, got %s", timeout)
+	}
+}
+
+func Test_CollectInBackground(t *testing.T) {
+	// The URI doesn't parse, so every collection fails right away with up=0.
+	collector := NewMongodbCollector(MongodbCollectorOpts{URI: "mongodb://localhost/?unknownOption=1", CollectInterval: time.Hour})
+	collector.Start()
+	defer collector.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	first := collectTimestamp(t, collector)
+	time.Sleep(10 * time.Millisecond)
+	if second := collectTimestamp(t, collector); second != first {
+		t.Errorf("expected the cached collection from %f, got one from %f", first, second)
+	}
+}
+
+func collectTimestamp(t *testing.T, collector *MongodbCollector) float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.Collect(ch)
+		close(ch)
+	}()
+
+	timestamp := float64(0)
+	for metric := range ch {
+		if metric.Desc() != lastCollectionDesc {
+			continue
+		}
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatal(err)
+		}
+		timestamp = out.GetGauge().GetValue()
+	}
+	if timestamp == 0 {
+		t.Fatal("the collection has no timestamp")
+	}
+	return timestamp
+}
+
+func Test_CollectorErrorCounts(t *testing.T) {
+	counts := newCollectorErrorCounts()
+	// Two probes of a target, each with its own collector, add to the same count.
+	counts.inc("db1:27017", "top", "unauthorized")
+	counts.inc("db1:27017", "top", "unauthorized")
+	counts.inc("db2:27017", "top", "unauthorized")
+
+	ch := make(chan prometheus.Metric, 10)
+	counts.export("db1:27017", ch)
+	close(ch)
+	var metrics []prometheus.Metric
+	for metric := range ch {
+		metrics = append(metrics, metric)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected the errors of a single collector, got %d metrics", len(metrics))
+	}
+	metric := &dto.Metric{}
+	if err := metrics[0].Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if value := metric.GetCounter().GetValue(); value != 2 {
+		t.Errorf("expected 2 errors, got %v", value)
+	}
+
+	// db2 is no longer scraped while db1 still is.
+	counts.lock.Lock()
+	counts.lastUsed["db2:27017"] = time.Now().Add(-2 * collectorErrorsIdleTimeout)
+	counts.lock.Unlock()
+	counts.export("db1:27017", make(chan prometheus.Metric, 10))
+	if len(counts.counts) != 1 || len(counts.lastUsed) != 1 {
+		t.Errorf("expected the errors of the idle target to be removed, got %v", counts.counts)
+	}
+}
+
+func Test_CommandResultsGather(t *testing.T) {
+	connPoolStats := &ConnPoolStats{
+		Hosts: map[string]*HostConnPoolStats{"a:27017": {InUse: 1}, "b:27017": {Available: 2}},
+		ReplicaSets: map[string]ReplicaSetStats{
+			"rs0": {Hosts: []*ReplicaSetHostStats{{Host: "a:27017"}, {Host: "b:27017"}}},
+			"rs1": {Hosts: []*ReplicaSetHostStats{{Host: "c:27017"}}},
+		},
+	}
+	checkGather(t, "connPoolStats", connPoolStats.Describe, connPoolStats.Export)
+
+	dbStatus := &DatabaseStatus{Name: "test", Shards: map[string]*RawStatus{
+		"rs0/a:27017,b:27017": {Name: "test"},
+		"rs1/c:27017":         {Name: "test"},
+	}}
+	checkGather(t, "dbStats", dbStatus.Describe, dbStatus.Export)
+
+	collStatus := &CollectionStatus{Name: "test.users"}
+	checkGather(t, "collStats", collStatus.Describe, collStatus.Export)
+
+	currentOp := &CurrentOp{}
+	checkGather(t, "currentOp", currentOp.Describe, currentOp.Export)
+}
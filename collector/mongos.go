@@ -0,0 +1,176 @@
+package collector
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	mongosShards = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shards"),
+		"The number of shards in the cluster, from listShards.",
+		nil, nil,
+	)
+	mongosShardState = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_state"),
+		"The state of each shard, 1 when it is shard aware.",
+		[]string{"shard"}, nil,
+	)
+	mongosShardDraining = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_draining"),
+		"Whether each shard is being drained before its removal.",
+		[]string{"shard"}, nil,
+	)
+
+	mongosShardConnPoolInUse = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_conn_pool_connections_in_use"),
+		"The number of connections from the router to the shards currently in use, from shardConnPoolStats.",
+		nil, nil,
+	)
+	mongosShardConnPoolAvailable = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_conn_pool_connections_available"),
+		"The number of connections from the router to the shards currently available, from shardConnPoolStats.",
+		nil, nil,
+	)
+	mongosShardConnPoolCreated = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_conn_pool_connections_created_total"),
+		"The total number of connections the router created to the shards, from shardConnPoolStats.",
+		nil, nil,
+	)
+	mongosShardConnPoolHostInUse = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_conn_pool_host_connections_in_use"),
+		"The number of connections from the router to each shard host currently in use.",
+		[]string{"host"}, nil,
+	)
+	mongosShardConnPoolHostAvailable = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_conn_pool_host_connections_available"),
+		"The number of connections from the router to each shard host currently available.",
+		[]string{"host"}, nil,
+	)
+	mongosShardConnPoolHostCreated = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "mongos", "shard_conn_pool_host_connections_created_total"),
+		"The total number of connections the router created to each shard host.",
+		[]string{"host"}, nil,
+	)
+)
+
+// ShardList is the result of listShards.
+type ShardList struct {
+	Shards []ShardInfo `bson:"shards"`
+}
+
+// ShardInfo is a shard returned by listShards.
+type ShardInfo struct {
+	ID       string  `bson:"_id"`
+	Host     string  `bson:"host"`
+	State    float64 `bson:"state"`
+	Draining bool    `bson:"draining"`
+}
+
+// Export exports the shards to be consumed by prometheus.
+func (list *ShardList) Export(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(mongosShards, prometheus.GaugeValue, float64(len(list.Shards)))
+	for _, shard := range list.Shards {
+		ch <- prometheus.MustNewConstMetric(mongosShardState, prometheus.GaugeValue, shard.State, shard.ID)
+		ch <- prometheus.MustNewConstMetric(mongosShardDraining, prometheus.GaugeValue, boolToFloat64(shard.Draining), shard.ID)
+	}
+}
+
+// Describe describes the shards for prometheus.
+func (list *ShardList) Describe(ch chan<- *prometheus.Desc) {
+	ch <- mongosShards
+	ch <- mongosShardState
+	ch <- mongosShardDraining
+}
+
+// GetShardList returns the shards of the cluster.
+func GetShardList(session *mgo.Session) (*ShardList, error) {
+	result := &ShardList{}
+	err := session.DB("admin").Run(bson.D{{Name: "listShards", Value: 1}}, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ShardConnPoolStats is the result of shardConnPoolStats, the connections from a router to the
+// shards.
+type ShardConnPoolStats struct {
+	TotalInUse     float64                       `bson:"totalInUse"`
+	TotalAvailable float64                       `bson:"totalAvailable"`
+	TotalCreated   float64                       `bson:"totalCreated"`
+	Hosts          map[string]*HostConnPoolStats `bson:"hosts"`
+}
+
+// Export exports the connection pool stats to be consumed by prometheus.
+func (stats *ShardConnPoolStats) Export(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(mongosShardConnPoolInUse, prometheus.GaugeValue, stats.TotalInUse)
+	ch <- prometheus.MustNewConstMetric(mongosShardConnPoolAvailable, prometheus.GaugeValue, stats.TotalAvailable)
+	ch <- prometheus.MustNewConstMetric(mongosShardConnPoolCreated, prometheus.CounterValue, stats.TotalCreated)
+	for host, hostStats := range stats.Hosts {
+		ch <- prometheus.MustNewConstMetric(mongosShardConnPoolHostInUse, prometheus.GaugeValue, hostStats.InUse, host)
+		ch <- prometheus.MustNewConstMetric(mongosShardConnPoolHostAvailable, prometheus.GaugeValue, hostStats.Available, host)
+		ch <- prometheus.MustNewConstMetric(mongosShardConnPoolHostCreated, prometheus.CounterValue, hostStats.Created, host)
+	}
+}
+
+// Describe describes the connection pool stats for prometheus.
+func (stats *ShardConnPoolStats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- mongosShardConnPoolInUse
+	ch <- mongosShardConnPoolAvailable
+	ch <- mongosShardConnPoolCreated
+	ch <- mongosShardConnPoolHostInUse
+	ch <- mongosShardConnPoolHostAvailable
+	ch <- mongosShardConnPoolHostCreated
+}
+
+// GetShardConnPoolStats returns the connection pool stats of a router to the shards.
+func GetShardConnPoolStats(session *mgo.Session) (*ShardConnPoolStats, error) {
+	result := &ShardConnPoolStats{}
+	err := session.DB("admin").Run(bson.D{{Name: "shardConnPoolStats", Value: 1}}, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func init() {
+	Register("mongos", newMongosCollector, true)
+}
+
+// mongosCollector collects what only a mongos router knows about the cluster. The targeting of
+// the shards is exported by the serverstatus collector, from shardingStatistics.
+type mongosCollector struct{}
+
+func newMongosCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &mongosCollector{}, nil
+}
+
+func (c *mongosCollector) runsOnRouter() bool {
+	return true
+}
+
+func (c *mongosCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	shards, err := GetShardList(session)
+	if err != nil {
+		return err
+	}
+	shards.Export(ch)
+
+	// shardConnPoolStats was removed in MongoDB 5.0.
+	stats, err := GetShardConnPoolStats(session)
+	if err != nil {
+		if errorCode(err) == "command_not_found" {
+			return nil
+		}
+		return err
+	}
+	stats.Export(ch)
+	return nil
+}
+
+func (c *mongosCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&ShardList{}).Describe(ch)
+	(&ShardConnPoolStats{}).Describe(ch)
+}
@@ -5,22 +5,21 @@ import (
 )
 
 var (
-	networkBytesTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "network_bytes_total",
-		Help:      "The network data structure contains data regarding MongoDB's network use",
-	}, []string{"state"})
+	networkBytesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "network_bytes_total"),
+		"The network data structure contains data regarding MongoDB's network use",
+		[]string{"state"}, nil,
+	)
 )
 var (
-	networkMetricsNumRequestsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "network_metrics",
-		Name:      "num_requests_total",
-		Help:      "The numRequests field is a counter of the total number of distinct requests that the server has received. Use this value to provide context for the bytesIn and bytesOut values to ensure that MongoDB's network utilization is consistent with expectations and application use",
-	})
+	networkMetricsNumRequestsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "network_metrics", "num_requests_total"),
+		"The numRequests field is a counter of the total number of distinct requests that the server has received. Use this value to provide context for the bytesIn and bytesOut values to ensure that MongoDB's network utilization is consistent with expectations and application use",
+		nil, nil,
+	)
 )
 
-//NetworkStats network stats
+// NetworkStats network stats
 type NetworkStats struct {
 	BytesIn     float64 `bson:"bytesIn"`
 	BytesOut    float64 `bson:"bytesOut"`
@@ -29,17 +28,15 @@ type NetworkStats struct {
 
 // Export exports the data to prometheus
 func (networkStats *NetworkStats) Export(ch chan<- prometheus.Metric) {
-	networkBytesTotal.WithLabelValues("in_bytes").Set(networkStats.BytesIn)
-	networkBytesTotal.WithLabelValues("out_bytes").Set(networkStats.BytesOut)
+	ch <- prometheus.MustNewConstMetric(networkBytesTotal, prometheus.GaugeValue, networkStats.BytesIn, "in_bytes")
+	ch <- prometheus.MustNewConstMetric(networkBytesTotal, prometheus.GaugeValue, networkStats.BytesOut, "out_bytes")
 
-	networkMetricsNumRequestsTotal.Set(networkStats.NumRequests)
+	ch <- prometheus.MustNewConstMetric(networkMetricsNumRequestsTotal, prometheus.GaugeValue, networkStats.NumRequests)
 
-	networkMetricsNumRequestsTotal.Collect(ch)
-	networkBytesTotal.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (networkStats *NetworkStats) Describe(ch chan<- *prometheus.Desc) {
-	networkMetricsNumRequestsTotal.Describe(ch)
-	networkBytesTotal.Describe(ch)
+	ch <- networkMetricsNumRequestsTotal
+	ch <- networkBytesTotal
 }
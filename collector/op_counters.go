@@ -5,18 +5,18 @@ import (
 )
 
 var (
-	opCountersTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "op_counters_total",
-		Help:      "The opcounters data structure provides an overview of database operations by type and makes it possible to analyze the load on the database in more granular manner. These numbers will grow over time and in response to database use. Analyze these values over time to track database utilization",
-	}, []string{"type"})
+	opCountersTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "op_counters_total"),
+		"The opcounters data structure provides an overview of database operations by type and makes it possible to analyze the load on the database in more granular manner. These numbers will grow over time and in response to database use. Analyze these values over time to track database utilization",
+		[]string{"type"}, nil,
+	)
 )
 var (
-	opCountersReplTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "op_counters_repl_total",
-		Help:      "The opcountersRepl data structure, similar to the opcounters data structure, provides an overview of database replication operations by type and makes it possible to analyze the load on the replica in more granular manner. These values only appear when the current host has replication enabled",
-	}, []string{"type"})
+	opCountersReplTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "op_counters_repl_total"),
+		"The opcountersRepl data structure, similar to the opcounters data structure, provides an overview of database replication operations by type and makes it possible to analyze the load on the replica in more granular manner. These values only appear when the current host has replication enabled",
+		[]string{"type"}, nil,
+	)
 )
 
 // OpcountersStats opcounters stats
@@ -31,19 +31,18 @@ type OpcountersStats struct {
 
 // Export exports the data to prometheus.
 func (opCounters *OpcountersStats) Export(ch chan<- prometheus.Metric) {
-	opCountersTotal.WithLabelValues("insert").Set(opCounters.Insert)
-	opCountersTotal.WithLabelValues("query").Set(opCounters.Query)
-	opCountersTotal.WithLabelValues("update").Set(opCounters.Update)
-	opCountersTotal.WithLabelValues("delete").Set(opCounters.Delete)
-	opCountersTotal.WithLabelValues("getmore").Set(opCounters.GetMore)
-	opCountersTotal.WithLabelValues("command").Set(opCounters.Command)
+	ch <- prometheus.MustNewConstMetric(opCountersTotal, prometheus.GaugeValue, opCounters.Insert, "insert")
+	ch <- prometheus.MustNewConstMetric(opCountersTotal, prometheus.GaugeValue, opCounters.Query, "query")
+	ch <- prometheus.MustNewConstMetric(opCountersTotal, prometheus.GaugeValue, opCounters.Update, "update")
+	ch <- prometheus.MustNewConstMetric(opCountersTotal, prometheus.GaugeValue, opCounters.Delete, "delete")
+	ch <- prometheus.MustNewConstMetric(opCountersTotal, prometheus.GaugeValue, opCounters.GetMore, "getmore")
+	ch <- prometheus.MustNewConstMetric(opCountersTotal, prometheus.GaugeValue, opCounters.Command, "command")
 
-	opCountersTotal.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (opCounters *OpcountersStats) Describe(ch chan<- *prometheus.Desc) {
-	opCountersTotal.Describe(ch)
+	ch <- opCountersTotal
 }
 
 // OpcountersReplStats opcounters stats
@@ -58,17 +57,16 @@ type OpcountersReplStats struct {
 
 // Export exports the data to prometheus.
 func (opCounters *OpcountersReplStats) Export(ch chan<- prometheus.Metric) {
-	opCountersReplTotal.WithLabelValues("insert").Set(opCounters.Insert)
-	opCountersReplTotal.WithLabelValues("query").Set(opCounters.Query)
-	opCountersReplTotal.WithLabelValues("update").Set(opCounters.Update)
-	opCountersReplTotal.WithLabelValues("delete").Set(opCounters.Delete)
-	opCountersReplTotal.WithLabelValues("getmore").Set(opCounters.GetMore)
-	opCountersReplTotal.WithLabelValues("command").Set(opCounters.Command)
+	ch <- prometheus.MustNewConstMetric(opCountersReplTotal, prometheus.GaugeValue, opCounters.Insert, "insert")
+	ch <- prometheus.MustNewConstMetric(opCountersReplTotal, prometheus.GaugeValue, opCounters.Query, "query")
+	ch <- prometheus.MustNewConstMetric(opCountersReplTotal, prometheus.GaugeValue, opCounters.Update, "update")
+	ch <- prometheus.MustNewConstMetric(opCountersReplTotal, prometheus.GaugeValue, opCounters.Delete, "delete")
+	ch <- prometheus.MustNewConstMetric(opCountersReplTotal, prometheus.GaugeValue, opCounters.GetMore, "getmore")
+	ch <- prometheus.MustNewConstMetric(opCountersReplTotal, prometheus.GaugeValue, opCounters.Command, "command")
 
-	opCountersReplTotal.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (opCounters *OpcountersReplStats) Describe(ch chan<- *prometheus.Desc) {
-	opCountersReplTotal.Describe(ch)
+	ch <- opCountersReplTotal
 }
@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultOplogSampleWindow is how far back the oplog is sampled when no window is given.
+const DefaultOplogSampleWindow = time.Minute
+
+var (
+	oplogSampleEntries = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogsample", "entries_per_second"),
+		"The rate of the entries written to the oplog over the sample window by ns/op.",
+		[]string{"ns", "op"}, nil,
+	)
+	oplogSampleBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogsample", "bytes_per_second"),
+		"The rate of the bytes written to the oplog over the sample window by ns/op, since MongoDB 4.4.",
+		[]string{"ns", "op"}, nil,
+	)
+	oplogSampleWindow = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogsample", "window_seconds"),
+		"How far back the oplog is sampled.",
+		nil, nil,
+	)
+)
+
+// bsonSizeUnsupported are the error codes of the servers which don't know $bsonSize, before
+// MongoDB 4.4.
+var bsonSizeUnsupported = map[int]bool{
+	168:   true, // InvalidPipelineOperator
+	15999: true,
+}
+
+// oplogSampleGroup counts the entries of a namespace and an op.
+type oplogSampleGroup struct {
+	ID struct {
+		NS string `bson:"ns"`
+		Op string `bson:"op"`
+	} `bson:"_id"`
+	Count float64 `bson:"count"`
+	Size  float64 `bson:"size"`
+}
+
+// OplogSample is what was written to the oplog over a window.
+type OplogSample struct {
+	Window time.Duration
+	Groups []oplogSampleGroup
+	// WithSize tells whether the size of the entries was summed.
+	WithSize bool
+}
+
+// GetOplogSample aggregates the entries of the oplog written over the window before the current
+// time of the server by namespace and op, so that the clock of the exporter doesn't matter and an
+// idle server has no writes rather than those of its last busy window. The aggregation is cut
+// short after maxTime, without it the whole oplog may be scanned before MongoDB 4.4.
+func GetOplogSample(session *mgo.Session, window, maxTime time.Duration) (*OplogSample, error) {
+	isMaster, err := getIsMaster(session)
+	if err != nil {
+		return nil, err
+	}
+	since := isMaster.LocalTime.Add(-window).Unix()
+	group := bson.M{
+		"_id":   bson.M{"ns": "$ns", "op": "$op"},
+		"count": bson.M{"$sum": 1},
+		"size":  bson.M{"$sum": bson.M{"$bsonSize": "$$ROOT"}},
+	}
+	pipeline := func() []bson.M {
+		return []bson.M{
+			{"$match": bson.M{"ts": bson.M{"$gte": bson.MongoTimestamp(since << 32)}}},
+			{"$group": group},
+		}
+	}
+
+	sample := &OplogSample{Window: window, WithSize: true}
+	oplog := session.DB("local").C("oplog.rs")
+	err = oplog.Pipe(pipeline()).SetMaxTime(maxTime).All(&sample.Groups)
+	if queryErr, ok := err.(*mgo.QueryError); ok && bsonSizeUnsupported[queryErr.Code] {
+		delete(group, "size")
+		sample.WithSize = false
+		sample.Groups = nil
+		err = oplog.Pipe(pipeline()).SetMaxTime(maxTime).All(&sample.Groups)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
+// relabel merges the groups by their ns label, dropping the namespaces which aren't counted.
+func (sample *OplogSample) relabel(namespaces *oplogTailNamespaces) {
+	indexes := make(map[oplogTailKey]int)
+	var groups []oplogSampleGroup
+	for _, group := range sample.Groups {
+		label, ok := namespaces.label(group.ID.NS)
+		if !ok {
+			continue
+		}
+		key := oplogTailKey{ns: label, op: group.ID.Op}
+		if i, ok := indexes[key]; ok {
+			groups[i].Count += group.Count
+			groups[i].Size += group.Size
+			continue
+		}
+		indexes[key] = len(groups)
+		group.ID.NS = label
+		groups = append(groups, group)
+	}
+	sample.Groups = groups
+}
+
+// Export exports metrics to Prometheus
+func (sample *OplogSample) Export(ch chan<- prometheus.Metric) {
+	window := sample.Window.Seconds()
+	for _, group := range sample.Groups {
+		ch <- prometheus.MustNewConstMetric(oplogSampleEntries, prometheus.GaugeValue, group.Count/window, group.ID.NS, group.ID.Op)
+		if sample.WithSize {
+			ch <- prometheus.MustNewConstMetric(oplogSampleBytes, prometheus.GaugeValue, group.Size/window, group.ID.NS, group.ID.Op)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(oplogSampleWindow, prometheus.GaugeValue, window)
+}
+
+// Describe describes metrics collected
+func (sample *OplogSample) Describe(ch chan<- *prometheus.Desc) {
+	ch <- oplogSampleEntries
+	ch <- oplogSampleBytes
+	ch <- oplogSampleWindow
+}
+
+func init() {
+	Register("oplog_sample", newOplogSampleCollector, false)
+}
+
+// oplogSampleCollector exports the write rates by namespace from an aggregation of the recent
+// oplog entries on every update, a lighter alternative to oplog_tail which keeps no cursor open.
+// Its ns labels follow the namespace options of oplog_tail.
+type oplogSampleCollector struct {
+	window  time.Duration
+	maxTime time.Duration
+
+	lock       sync.Mutex
+	namespaces *oplogTailNamespaces
+}
+
+func newOplogSampleCollector(opts MongodbCollectorOpts) (Collector, error) {
+	window := opts.OplogSampleWindow
+	if window == 0 {
+		window = DefaultOplogSampleWindow
+	}
+	if window < time.Second {
+		return nil, fmt.Errorf("the oplog sample window %s is shorter than a second", window)
+	}
+	// The aggregation is always bounded, by the collector timeout when there is no maxTimeMS.
+	maxTime := time.Duration(opts.MaxTimeMS) * time.Millisecond
+	if maxTime <= 0 {
+		maxTime = opts.collectorTimeout("oplog_sample")
+	}
+	namespaces, err := newOplogTailNamespaces(opts.OplogTail)
+	if err != nil {
+		return nil, err
+	}
+	return &oplogSampleCollector{window: window, maxTime: maxTime, namespaces: namespaces}, nil
+}
+
+func (c *oplogSampleCollector) runsOnRouter() bool {
+	return false
+}
+
+func (c *oplogSampleCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	sample, err := GetOplogSample(session, c.window, c.maxTime)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	sample.relabel(c.namespaces)
+	c.lock.Unlock()
+	sample.Export(ch)
+	return nil
+}
+
+func (c *oplogSampleCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&OplogSample{}).Describe(ch)
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_OplogSampleExport(t *testing.T) {
+	group := oplogSampleGroup{Count: 120, Size: 6000}
+	group.ID.NS, group.ID.Op = "app.orders", "i"
+	sample := &OplogSample{Window: time.Minute, Groups: []oplogSampleGroup{group}}
+
+	ch := make(chan prometheus.Metric, 10)
+	sample.Export(ch)
+	close(ch)
+	values := map[*prometheus.Desc]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		metric.Write(m)
+		values[metric.Desc()] = m.GetGauge().GetValue()
+	}
+	if values[oplogSampleEntries] != 2 || values[oplogSampleWindow] != 60 {
+		t.Errorf("expected 2 entries/s over 60s, got %v", values)
+	}
+	if _, ok := values[oplogSampleBytes]; ok {
+		t.Error("expected no byte rate without the size of the entries")
+	}
+
+	sample.WithSize = true
+	ch = make(chan prometheus.Metric, 10)
+	sample.Export(ch)
+	close(ch)
+	for metric := range ch {
+		if metric.Desc() == oplogSampleBytes {
+			m := &dto.Metric{}
+			metric.Write(m)
+			if m.GetGauge().GetValue() != 100 {
+				t.Errorf("expected 100 bytes/s, got %v", m.GetGauge().GetValue())
+			}
+		}
+	}
+}
+
+func Test_OplogSampleCollectorOpts(t *testing.T) {
+	c, err := newOplogSampleCollector(MongodbCollectorOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampler := c.(*oplogSampleCollector)
+	if sampler.window != DefaultOplogSampleWindow || sampler.maxTime != DefaultCollectorTimeout {
+		t.Errorf("expected the default window bounded by the collector timeout, got %s and %s", sampler.window, sampler.maxTime)
+	}
+
+	c, _ = newOplogSampleCollector(MongodbCollectorOpts{MaxTimeMS: 500})
+	if sampler := c.(*oplogSampleCollector); sampler.maxTime != 500*time.Millisecond {
+		t.Errorf("expected maxTimeMS to bound the aggregation, got %s", sampler.maxTime)
+	}
+
+	if _, err := newOplogSampleCollector(MongodbCollectorOpts{OplogSampleWindow: time.Millisecond}); err == nil {
+		t.Error("expected a window shorter than a second to be rejected")
+	}
+}
+
+func Test_OplogSampleRelabel(t *testing.T) {
+	namespaces, err := newOplogTailNamespaces(OplogTailOpts{ExcludeNamespaces: `^config\.`, MaxNamespaces: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := &OplogSample{Window: time.Minute}
+	for _, ns := range []string{"app.orders", "app.users", "app.carts", "config.system.sessions"} {
+		group := oplogSampleGroup{Count: 10, Size: 100}
+		group.ID.NS, group.ID.Op = ns, "i"
+		sample.Groups = append(sample.Groups, group)
+	}
+
+	sample.relabel(namespaces)
+	if len(sample.Groups) != 2 {
+		t.Fatalf("expected app.orders and __other__, got %v", sample.Groups)
+	}
+	if group := sample.Groups[0]; group.ID.NS != "app.orders" || group.Count != 10 {
+		t.Errorf("unexpected group %v", group)
+	}
+	if group := sample.Groups[1]; group.ID.NS != otherNamespace || group.Count != 20 || group.Size != 200 {
+		t.Errorf("expected the other namespaces to be merged, got %v", group)
+	}
+}
@@ -1,39 +1,55 @@
 package collector
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	oplogStatusCount = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "replset_oplog",
-		Name:      "items_total",
-		Help:      "The total number of changes in the oplog",
-	})
-	oplogStatusHeadTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "replset_oplog",
-		Name:      "head_timestamp",
-		Help:      "The timestamp of the newest change in the oplog",
-	})
-	oplogStatusTailTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "replset_oplog",
-		Name:      "tail_timestamp",
-		Help:      "The timestamp of the oldest change in the oplog",
-	})
-	oplogStatusSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "replset_oplog",
-		Name:      "size_bytes",
-		Help:      "Size of oplog in bytes",
-	}, []string{"type"})
+	oplogStatusCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "items_total"),
+		"The total number of changes in the oplog",
+		nil, nil,
+	)
+	oplogStatusHeadTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "head_timestamp"),
+		"The timestamp of the oldest change in the oplog",
+		nil, nil,
+	)
+	oplogStatusTailTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "tail_timestamp"),
+		"The timestamp of the newest change in the oplog",
+		nil, nil,
+	)
+	oplogStatusSizeBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "size_bytes"),
+		"Size of oplog in bytes",
+		[]string{"type"}, nil,
+	)
+	oplogStatusMaxSizeBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "max_size_bytes"),
+		"The size the oplog is capped at, the oldest changes are truncated past it.",
+		nil, nil,
+	)
+	oplogStatusWindow = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "window_seconds"),
+		"The time between the oldest and the newest change in the oplog.",
+		nil, nil,
+	)
+	oplogStatusFillRate = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "fill_rate_bytes_per_second"),
+		"An estimate of how fast the oplog grows, its size divided by its window.",
+		nil, nil,
+	)
+	oplogStatusTimeToRollover = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "time_to_rollover_seconds"),
+		"An estimate of how long until the oldest change a member still has to apply is truncated from the oplog, at the current fill rate. The member can't catch up past this point without a resync.",
+		[]string{"set", "name"}, nil,
+	)
 )
 
 // OplogCollectionStats represents metrics about an oplog collection
@@ -41,13 +57,45 @@ type OplogCollectionStats struct {
 	Count       float64 `bson:"count"`
 	Size        float64 `bson:"size"`
 	StorageSize float64 `bson:"storageSize"`
+	MaxSize     float64 `bson:"maxSize"`
 }
 
 // OplogStatus represents oplog metrics
 type OplogStatus struct {
+	// TailTimestamp is the newest change and HeadTimestamp the oldest one, see GetOplogTimestamp.
 	TailTimestamp   float64
 	HeadTimestamp   float64
 	CollectionStats *OplogCollectionStats
+	// ReplSetStatus tells how far behind the other members are, it is nil when unavailable.
+	ReplSetStatus *ReplSetStatus
+}
+
+// window returns the seconds between the oldest and the newest change.
+func (status *OplogStatus) window() float64 {
+	return status.TailTimestamp - status.HeadTimestamp
+}
+
+// fillRate returns the average number of bytes written to the oplog per second over its window.
+func (status *OplogStatus) fillRate() (float64, bool) {
+	window := status.window()
+	if status.CollectionStats == nil || window <= 0 {
+		return 0, false
+	}
+	return status.CollectionStats.Size / window, true
+}
+
+// timeToRollover estimates when the change at the given timestamp is truncated. The changes
+// before it are truncated first, and only once the oplog has reached its maximum size.
+func (status *OplogStatus) timeToRollover(timestamp float64) (float64, bool) {
+	rate, ok := status.fillRate()
+	if !ok || rate == 0 {
+		return 0, false
+	}
+	seconds := timestamp - status.HeadTimestamp
+	if free := status.CollectionStats.MaxSize - status.CollectionStats.Size; free > 0 {
+		seconds += free / rate
+	}
+	return seconds, true
 }
 
 // BsonMongoTimestampToUnix converts a mongo timestamp to UNIX time
@@ -56,7 +104,8 @@ func BsonMongoTimestampToUnix(timestamp bson.MongoTimestamp) float64 {
 	return float64(timestamp >> 32)
 }
 
-// GetOplogTimestamp fetches the latest oplog timestamp
+// GetOplogTimestamp fetches the timestamp of the first change in natural order, the oldest one,
+// or of the last one when returnTail is true.
 func GetOplogTimestamp(session *mgo.Session, returnTail bool) (float64, error) {
 	sortBy := "$natural"
 	if returnTail {
@@ -93,51 +142,112 @@ func GetOplogCollectionStats(session *mgo.Session, maxTimeMS int64) (*OplogColle
 
 // Export exports metrics to Prometheus
 func (status *OplogStatus) Export(ch chan<- prometheus.Metric) {
-	oplogStatusSizeBytes.WithLabelValues("current").Set(0)
-	oplogStatusSizeBytes.WithLabelValues("storage").Set(0)
 	if status.CollectionStats != nil {
-		oplogStatusCount.Set(status.CollectionStats.Count)
-		oplogStatusSizeBytes.WithLabelValues("current").Set(status.CollectionStats.Size)
-		oplogStatusSizeBytes.WithLabelValues("storage").Set(status.CollectionStats.StorageSize)
+		ch <- prometheus.MustNewConstMetric(oplogStatusCount, prometheus.GaugeValue, status.CollectionStats.Count)
+		ch <- prometheus.MustNewConstMetric(oplogStatusSizeBytes, prometheus.GaugeValue, status.CollectionStats.Size, "current")
+		ch <- prometheus.MustNewConstMetric(oplogStatusSizeBytes, prometheus.GaugeValue, status.CollectionStats.StorageSize, "storage")
+		if status.CollectionStats.MaxSize > 0 {
+			ch <- prometheus.MustNewConstMetric(oplogStatusMaxSizeBytes, prometheus.GaugeValue, status.CollectionStats.MaxSize)
+		}
+	} else {
+		ch <- prometheus.MustNewConstMetric(oplogStatusSizeBytes, prometheus.GaugeValue, 0, "current")
+		ch <- prometheus.MustNewConstMetric(oplogStatusSizeBytes, prometheus.GaugeValue, 0, "storage")
+	}
+	if status.HeadTimestamp == 0 || status.TailTimestamp == 0 {
+		return
 	}
-	if status.HeadTimestamp != 0 && status.TailTimestamp != 0 {
-		oplogStatusHeadTimestamp.Set(status.HeadTimestamp)
-		oplogStatusTailTimestamp.Set(status.TailTimestamp)
+	ch <- prometheus.MustNewConstMetric(oplogStatusHeadTimestamp, prometheus.GaugeValue, status.HeadTimestamp)
+	ch <- prometheus.MustNewConstMetric(oplogStatusTailTimestamp, prometheus.GaugeValue, status.TailTimestamp)
+	ch <- prometheus.MustNewConstMetric(oplogStatusWindow, prometheus.GaugeValue, status.window())
+	if rate, ok := status.fillRate(); ok {
+		ch <- prometheus.MustNewConstMetric(oplogStatusFillRate, prometheus.GaugeValue, rate)
 	}
 
-	oplogStatusCount.Collect(ch)
-	oplogStatusHeadTimestamp.Collect(ch)
-	oplogStatusTailTimestamp.Collect(ch)
-	oplogStatusSizeBytes.Collect(ch)
+	if status.ReplSetStatus == nil {
+		return
+	}
+	for i, member := range status.ReplSetStatus.Members {
+		// The primary and the arbiters don't apply the oplog of this member, nor does the member
+		// itself when it is a secondary.
+		if member.State == 1 || member.State == 7 || (member.Self != nil && *member.Self) {
+			continue
+		}
+		ts, ok := status.ReplSetStatus.Members[i].optimeTimestamp()
+		if !ok || ts == 0 {
+			continue
+		}
+		if seconds, ok := status.timeToRollover(BsonMongoTimestampToUnix(ts)); ok {
+			ch <- prometheus.MustNewConstMetric(oplogStatusTimeToRollover, prometheus.GaugeValue, seconds, status.ReplSetStatus.Set, member.Name)
+		}
+	}
 }
 
 // Describe describes metrics collected
 func (status *OplogStatus) Describe(ch chan<- *prometheus.Desc) {
-	oplogStatusCount.Describe(ch)
-	oplogStatusHeadTimestamp.Describe(ch)
-	oplogStatusTailTimestamp.Describe(ch)
-	oplogStatusSizeBytes.Describe(ch)
+	ch <- oplogStatusCount
+	ch <- oplogStatusHeadTimestamp
+	ch <- oplogStatusTailTimestamp
+	ch <- oplogStatusSizeBytes
+	ch <- oplogStatusMaxSizeBytes
+	ch <- oplogStatusWindow
+	ch <- oplogStatusFillRate
+	ch <- oplogStatusTimeToRollover
 }
 
 // GetOplogStatus fetches oplog collection stats
-func GetOplogStatus(session *mgo.Session, maxTimeMS int64) *OplogStatus {
+func GetOplogStatus(session *mgo.Session, maxTimeMS int64) (*OplogStatus, error) {
 	oplogStatus := &OplogStatus{}
 	collectionStats, err := GetOplogCollectionStats(session, maxTimeMS)
 	if err != nil {
-		glog.Errorf("Failed to get local.oplog_rs collection stats: %v", err)
-		return nil
+		return nil, fmt.Errorf("Failed to get local.oplog_rs collection stats: %v", err)
 	}
 
 	headTimestamp, err := GetOplogTimestamp(session, false)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get oplog head timestamp: %v", err)
+	}
 	tailTimestamp, err := GetOplogTimestamp(session, true)
 	if err != nil {
-		glog.Errorf("Failed to get oplog head or tail timestamps: %v", err)
-		return nil
+		return nil, fmt.Errorf("Failed to get oplog tail timestamp: %v", err)
 	}
 
 	oplogStatus.CollectionStats = collectionStats
 	oplogStatus.HeadTimestamp = headTimestamp
 	oplogStatus.TailTimestamp = tailTimestamp
 
-	return oplogStatus
+	// The oplog of a master/slave deployment has no replica set status, nor lagging members.
+	if replSetStatus, err := GetReplSetStatus(session); err == nil {
+		oplogStatus.ReplSetStatus = replSetStatus
+	}
+
+	return oplogStatus, nil
+}
+
+func init() {
+	Register("oplog", newOplogCollector, true)
+}
+
+type oplogCollector struct {
+	maxTimeMS int64
+}
+
+func newOplogCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &oplogCollector{maxTimeMS: opts.MaxTimeMS}, nil
+}
+
+func (c *oplogCollector) runsOnRouter() bool {
+	return false
+}
+
+func (c *oplogCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	oplogStatus, err := GetOplogStatus(session, c.maxTimeMS)
+	if err != nil {
+		return err
+	}
+	oplogStatus.Export(ch)
+	return nil
+}
+
+func (c *oplogCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&OplogStatus{}).Describe(ch)
 }
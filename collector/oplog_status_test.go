@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_OplogTimeToRollover(t *testing.T) {
+	// 1000 bytes over 100 seconds, half of the maximum size.
+	status := &OplogStatus{
+		HeadTimestamp:   1000,
+		TailTimestamp:   1100,
+		CollectionStats: &OplogCollectionStats{Size: 1000, MaxSize: 2000},
+	}
+	if rate, ok := status.fillRate(); !ok || rate != 10 {
+		t.Errorf("expected a fill rate of 10 bytes/s, got %f", rate)
+	}
+
+	// The 1000 free bytes fill in 100 seconds, then the 30 seconds before the member are truncated.
+	if seconds, ok := status.timeToRollover(1030); !ok || seconds != 130 {
+		t.Errorf("expected 130 seconds until the rollover, got %f", seconds)
+	}
+
+	status.CollectionStats.Size = 2000
+	if seconds, ok := status.timeToRollover(1030); !ok || seconds != 30 {
+		t.Errorf("expected 30 seconds until the rollover of a full oplog, got %f", seconds)
+	}
+
+	status.TailTimestamp = status.HeadTimestamp
+	if _, ok := status.timeToRollover(1000); ok {
+		t.Error("expected no estimate without a window")
+	}
+}
+
+func Test_OplogTimeToRolloverMembers(t *testing.T) {
+	self := true
+	status := &OplogStatus{
+		HeadTimestamp:   1000,
+		TailTimestamp:   1100,
+		CollectionStats: &OplogCollectionStats{Size: 1000, MaxSize: 2000},
+		ReplSetStatus: &ReplSetStatus{
+			Set: "rs0",
+			Members: []Member{
+				{Name: "primary:27017", State: 1, Optime: bson.MongoTimestamp(1100 << 32)},
+				{Name: "self:27017", State: 2, Self: &self, Optime: bson.MongoTimestamp(1090 << 32)},
+				{Name: "other:27017", State: 2, Optime: bson.MongoTimestamp(1030 << 32)},
+			},
+		},
+	}
+
+	ch := make(chan prometheus.Metric, 20)
+	status.Export(ch)
+	close(ch)
+	members := map[string]bool{}
+	for metric := range ch {
+		if metric.Desc() != oplogStatusTimeToRollover {
+			continue
+		}
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range out.Label {
+			if label.GetName() == "name" {
+				members[label.GetValue()] = true
+			}
+		}
+	}
+	if len(members) != 1 || !members["other:27017"] {
+		t.Errorf("expected the time to rollover of other:27017 only, got %v", members)
+	}
+}
+
+func Test_OplogStatusGather(t *testing.T) {
+	statuses := map[string]*OplogStatus{
+		"without stats": {},
+		"with stats": {
+			HeadTimestamp:   1000,
+			TailTimestamp:   1100,
+			CollectionStats: &OplogCollectionStats{Count: 10, Size: 1000, StorageSize: 4096, MaxSize: 2000},
+		},
+	}
+	for name, status := range statuses {
+		checkGather(t, name, status.Describe, status.Export)
+	}
+}
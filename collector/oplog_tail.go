@@ -1,98 +1,770 @@
 package collector
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/dcu/mongodb_exporter/shared"
 	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/rwynn/gtm"
+)
+
+const (
+	minOplogTailBackoff = 1 * time.Second
+	maxOplogTailBackoff = 1 * time.Minute
+
+	// oplogTailAwait is how long the cursor waits for new entries, so that the tailer notices when
+	// it is stopped.
+	oplogTailAwait = time.Second
+
+	// defaultOplogTailCheckpointInterval is how often the position of the tailer is saved when
+	// OplogTailOpts.CheckpointInterval isn't set.
+	defaultOplogTailCheckpointInterval = 10 * time.Second
+
+	// otherNamespace is the ns label of the entries of the namespaces past OplogTailOpts.MaxNamespaces.
+	otherNamespace = "__other__"
 )
 
 var (
-	oplogEntryCount = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "oplogtail",
-		Name:      "entry_count",
-		Help:      "The total number of entries observed in the oplog by ns/op",
-	}, []string{"ns", "op"})
-	oplogEntrySize = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "oplogtail",
-		Name:      "entry_size",
-		Help:      "The total size of entries observed in the oplog by ns/op",
-	}, []string{"ns", "op"})
-	oplogTailError = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: Namespace,
-		Subsystem: "oplogtail",
-		Name:      "tail_error",
-		Help:      "The total number of errors while tailing the oplog",
-	})
+	oplogEntryCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "entry_count"),
+		"The total number of entries observed in the oplog by ns/op",
+		[]string{"ns", "op"}, nil,
+	)
+	oplogEntrySize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "entry_size"),
+		"The total size of entries observed in the oplog by ns/op",
+		[]string{"ns", "op"}, nil,
+	)
+	oplogTailError = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "tail_error"),
+		"The total number of errors while tailing the oplog",
+		nil, nil,
+	)
+	oplogTailRunning = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "running"),
+		"Whether the oplog is being tailed, 0 while the tailer waits to restart.",
+		nil, nil,
+	)
+	oplogTailRestarts = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "restarts_total"),
+		"The total number of times the tailer restarted after its session died.",
+		nil, nil,
+	)
+	oplogTailLag = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "lag_seconds"),
+		"How far the tailer is behind, from the timestamp of the last entry it saw. It also grows while nothing is written.",
+		nil, nil,
+	)
+	oplogTailReplayed = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "replayed_entries_total"),
+		"The total number of entries written while the exporter was down, read after resuming from the state file.",
+		nil, nil,
+	)
+	oplogTailGaps = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "gaps_total"),
+		"The total number of times the tailer couldn't resume where it stopped, as the oplog rolled over, and missed entries.",
+		nil, nil,
+	)
 )
 
-var tailer *OplogTailStats
+// OplogTailOpts are the options of the oplog_tail collector, which keep the number of ns labels
+// in check.
+type OplogTailOpts struct {
+	// IncludeNamespaces is a regular expression matching the namespaces of the entries which are
+	// counted, all of them when empty.
+	IncludeNamespaces string
+	// ExcludeNamespaces is a regular expression matching the namespaces of the entries which are
+	// not counted, e.g. `^config\.|\.system\.`.
+	ExcludeNamespaces string
+	// AggregateByDatabase labels the entries with their database instead of their namespace.
+	AggregateByDatabase bool
+	// MaxNamespaces caps the number of distinct ns labels, the entries of the namespaces seen
+	// after it is reached are labeled __other__. Zero doesn't cap them.
+	MaxNamespaces int
+	// Backend is OplogTailBackendOplog, the default when empty, or OplogTailBackendChangeStream.
+	Backend string
+	// StateFile is the file where the position of the tailer is saved, so that it resumes where it
+	// stopped when the exporter restarts. The position isn't saved when empty.
+	StateFile string
+	// CheckpointInterval is how often the position is saved, besides when the tailer stops.
+	CheckpointInterval time.Duration
+}
+
+// Validate checks the backend and the regular expressions of the options.
+func (o OplogTailOpts) Validate() error {
+	switch o.Backend {
+	case "", OplogTailBackendOplog, OplogTailBackendChangeStream:
+	default:
+		return fmt.Errorf("unknown backend %q, expected %s or %s", o.Backend, OplogTailBackendOplog, OplogTailBackendChangeStream)
+	}
+	if o.CheckpointInterval < 0 {
+		return fmt.Errorf("negative checkpoint interval %s", o.CheckpointInterval)
+	}
+	_, err := newOplogTailNamespaces(o)
+	return err
+}
+
+// checkpointInterval returns how often the position is saved.
+func (o OplogTailOpts) checkpointInterval() time.Duration {
+	if o.CheckpointInterval == 0 {
+		return defaultOplogTailCheckpointInterval
+	}
+	return o.CheckpointInterval
+}
+
+// changeStream tells whether the entries are read from a change stream instead of the oplog.
+func (o OplogTailOpts) changeStream() bool {
+	return o.Backend == OplogTailBackendChangeStream
+}
+
+// oplogTailNamespaces turns the namespaces of the entries into ns labels.
+type oplogTailNamespaces struct {
+	include    *regexp.Regexp
+	exclude    *regexp.Regexp
+	byDatabase bool
+	max        int
+	// labels are the distinct labels given so far, besides __other__.
+	labels map[string]bool
+}
+
+func newOplogTailNamespaces(opts OplogTailOpts) (*oplogTailNamespaces, error) {
+	namespaces := &oplogTailNamespaces{
+		byDatabase: opts.AggregateByDatabase,
+		max:        opts.MaxNamespaces,
+		labels:     make(map[string]bool),
+	}
+	var err error
+	if opts.IncludeNamespaces != "" {
+		if namespaces.include, err = regexp.Compile(opts.IncludeNamespaces); err != nil {
+			return nil, fmt.Errorf("invalid namespaces to include: %s", err)
+		}
+	}
+	if opts.ExcludeNamespaces != "" {
+		if namespaces.exclude, err = regexp.Compile(opts.ExcludeNamespaces); err != nil {
+			return nil, fmt.Errorf("invalid namespaces to exclude: %s", err)
+		}
+	}
+	return namespaces, nil
+}
+
+// label returns the ns label of an entry of the given namespace, false when it isn't counted.
+func (n *oplogTailNamespaces) label(ns string) (string, bool) {
+	if n.include != nil && !n.include.MatchString(ns) {
+		return "", false
+	}
+	if n.exclude != nil && n.exclude.MatchString(ns) {
+		return "", false
+	}
+
+	label := ns
+	if n.byDatabase {
+		label = strings.SplitN(ns, ".", 2)[0]
+	}
+	if n.labels[label] {
+		return label, true
+	}
+	if n.max > 0 && len(n.labels) >= n.max {
+		return otherNamespace, true
+	}
+	n.labels[label] = true
+	return label, true
+}
+
+// oplogTails are the oplog tailers of the process, one per target.
+var oplogTails = newOplogTailManager()
+
+// StopOplogTailers stops tailing the oplog of all the targets, it is meant to be called when the
+// exporter shuts down.
+func StopOplogTailers() {
+	oplogTails.stopAll()
+}
+
+// oplogTailKey identifies the entries counted by the tailer.
+type oplogTailKey struct {
+	ns string
+	op string
+}
+
+// OplogTailStats keeps the counters of the entries seen while tailing the oplog.
+type OplogTailStats struct {
+	lock       sync.Mutex
+	namespaces *oplogTailNamespaces
+	entryCount map[oplogTailKey]float64
+	entrySize  map[oplogTailKey]float64
+	entrySizes *prometheus.HistogramVec
+	// The operations of the applyOps entries and the updates, see observeOplogEntry.
+	applyOpsCount map[oplogTailKey]float64
+	applyOpsSize  map[oplogTailKey]float64
+	// updates are keyed by ns and update type.
+	updates               map[oplogTailKey]float64
+	transactionSizes      prometheus.Histogram
+	transactionStatements prometheus.Histogram
+	pendingTransactions   map[string]*pendingTransaction
+	// target names the target in the DDL events.
+	target    string
+	ddlEvents map[ddlKey]float64
+	errors    float64
+	restarts  float64
+	replayed  float64
+	gaps      float64
+	running   bool
+	// lastTimestamp is the timestamp of the last entry seen, the tailer resumes after it.
+	lastTimestamp bson.MongoTimestamp
+	// resumeToken is the resume token of the last change event seen, the change stream resumes
+	// after it.
+	resumeToken *bson.Raw
+	// replayUntil is when the tailer resumed from the state file, the entries written before were
+	// missed while the exporter was down.
+	replayUntil bson.MongoTimestamp
+}
+
+func newOplogTailStats(namespaces *oplogTailNamespaces) *OplogTailStats {
+	return &OplogTailStats{
+		namespaces: namespaces,
+		entryCount: make(map[oplogTailKey]float64),
+		entrySize:  make(map[oplogTailKey]float64),
+		entrySizes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "oplogtail",
+			Name:      "entry_size_bytes",
+			Help:      "The size of the entries observed in the oplog by op, of all the namespaces.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 9),
+		}, []string{"op"}),
+		applyOpsCount: make(map[oplogTailKey]float64),
+		applyOpsSize:  make(map[oplogTailKey]float64),
+		updates:       make(map[oplogTailKey]float64),
+		transactionSizes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "oplogtail",
+			Name:      "transaction_size_bytes",
+			Help:      "The size of the applyOps entries of the oplog, summed over all the entries of a transaction.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 10),
+		}),
+		transactionStatements: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "oplogtail",
+			Name:      "transaction_statements",
+			Help:      "The number of operations of the applyOps entries of the oplog, summed over all the entries of a transaction.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 15),
+		}),
+		pendingTransactions: make(map[string]*pendingTransaction),
+		ddlEvents:           make(map[ddlKey]float64),
+	}
+}
+
+func (o *OplogTailStats) observeEntry(ns, op string, size int, timestamp bson.MongoTimestamp) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if timestamp > o.lastTimestamp {
+		o.lastTimestamp = timestamp
+	}
+	if timestamp < o.replayUntil {
+		o.replayed++
+	}
+	o.entrySizes.WithLabelValues(op).Observe(float64(size))
+
+	label, ok := o.namespaces.label(ns)
+	if !ok {
+		return
+	}
+	key := oplogTailKey{ns: label, op: op}
+	o.entryCount[key]++
+	o.entrySize[key] += float64(size)
+}
+
+func (o *OplogTailStats) observeError() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.errors++
+}
+
+func (o *OplogTailStats) observeRestart() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.restarts++
+}
+
+func (o *OplogTailStats) setRunning(running bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.running = running
+}
+
+func (o *OplogTailStats) last() bson.MongoTimestamp {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	return o.lastTimestamp
+}
+
+func (o *OplogTailStats) setResumeToken(token *bson.Raw) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.resumeToken = token
+}
+
+// position returns where the tailer resumes after a restart.
+func (o *OplogTailStats) position() oplogTailPosition {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	position := oplogTailPosition{Timestamp: o.lastTimestamp}
+	if o.resumeToken != nil {
+		position.ResumeToken = o.resumeToken.Data
+	}
+	return position
+}
+
+// resume makes the tailer resume from a position saved before the exporter restarted, the entries
+// written until now are counted as replayed.
+func (o *OplogTailStats) resume(position oplogTailPosition, now time.Time) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.lastTimestamp = position.Timestamp
+	o.resumeToken = position.resumeToken()
+	o.replayUntil = bson.MongoTimestamp(now.Unix() << 32)
+}
+
+// observeGap counts that the tailer can't resume from its position, which is dropped so that it
+// starts over from the newest entry. The pending transactions are dropped too, as their last
+// entries may be in the gap.
+func (o *OplogTailStats) observeGap() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.gaps++
+	o.lastTimestamp = 0
+	o.resumeToken = nil
+	o.pendingTransactions = make(map[string]*pendingTransaction)
+}
+
+// Export exports metrics to Prometheus
+func (status *OplogTailStats) Export(ch chan<- prometheus.Metric) {
+	status.lock.Lock()
+	defer status.lock.Unlock()
+
+	for key, count := range status.entryCount {
+		ch <- prometheus.MustNewConstMetric(oplogEntryCount, prometheus.CounterValue, count, key.ns, key.op)
+	}
+	for key, size := range status.entrySize {
+		ch <- prometheus.MustNewConstMetric(oplogEntrySize, prometheus.CounterValue, size, key.ns, key.op)
+	}
+	status.entrySizes.Collect(ch)
+	for key, count := range status.applyOpsCount {
+		ch <- prometheus.MustNewConstMetric(oplogApplyOpsCount, prometheus.CounterValue, count, key.ns, key.op)
+	}
+	for key, size := range status.applyOpsSize {
+		ch <- prometheus.MustNewConstMetric(oplogApplyOpsSize, prometheus.CounterValue, size, key.ns, key.op)
+	}
+	for key, count := range status.updates {
+		ch <- prometheus.MustNewConstMetric(oplogUpdates, prometheus.CounterValue, count, key.ns, key.op)
+	}
+	status.transactionSizes.Collect(ch)
+	status.transactionStatements.Collect(ch)
+	for key, count := range status.ddlEvents {
+		ch <- prometheus.MustNewConstMetric(oplogDDLEvents, prometheus.CounterValue, count, key.db, key.collection, key.command)
+	}
+	ch <- prometheus.MustNewConstMetric(oplogTailError, prometheus.CounterValue, status.errors)
+	ch <- prometheus.MustNewConstMetric(oplogTailRestarts, prometheus.CounterValue, status.restarts)
+	ch <- prometheus.MustNewConstMetric(oplogTailRunning, prometheus.GaugeValue, boolToFloat64(status.running))
+	ch <- prometheus.MustNewConstMetric(oplogTailReplayed, prometheus.CounterValue, status.replayed)
+	ch <- prometheus.MustNewConstMetric(oplogTailGaps, prometheus.CounterValue, status.gaps)
+	if status.lastTimestamp != 0 {
+		lag := float64(time.Now().Unix()) - BsonMongoTimestampToUnix(status.lastTimestamp)
+		ch <- prometheus.MustNewConstMetric(oplogTailLag, prometheus.GaugeValue, lag)
+	}
+}
+
+// Describe describes metrics collected
+func (status *OplogTailStats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- oplogEntryCount
+	ch <- oplogEntrySize
+	status.entrySizes.Describe(ch)
+	ch <- oplogApplyOpsCount
+	ch <- oplogApplyOpsSize
+	ch <- oplogUpdates
+	status.transactionSizes.Describe(ch)
+	status.transactionStatements.Describe(ch)
+	ch <- oplogDDLEvents
+	ch <- oplogTailError
+	ch <- oplogTailRestarts
+	ch <- oplogTailRunning
+	ch <- oplogTailLag
+	ch <- oplogTailReplayed
+	ch <- oplogTailGaps
+}
+
+// oplogTailer tails the oplog of a single target until it is stopped. When its session dies, it
+// starts again after a backoff from the last entry it saw. With a state file, it also resumes
+// from there when the exporter restarts.
+type oplogTailer struct {
+	opts  MongodbCollectorOpts
+	stats *OplogTailStats
+	// refs is the number of collectors using the tailer, guarded by the lock of the manager.
+	refs int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newOplogTailer(opts MongodbCollectorOpts, namespaces *oplogTailNamespaces) *oplogTailer {
+	stats := newOplogTailStats(namespaces)
+	stats.target = uriHosts(opts.URI)
+	return &oplogTailer{
+		opts:  opts,
+		stats: stats,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// run tails the oplog, restarting with an increasing backoff, until the tailer is stopped.
+func (t *oplogTailer) run() {
+	defer close(t.done)
+
+	if t.opts.OplogTail.StateFile != "" {
+		t.resume()
+		checkpointsDone := make(chan struct{})
+		go t.checkpoints(checkpointsDone)
+		defer func() {
+			<-checkpointsDone
+			t.checkpoint()
+		}()
+	}
+
+	backoff := minOplogTailBackoff
+	for {
+		started := time.Now()
+		var err error
+		if t.opts.OplogTail.changeStream() {
+			err = t.tailChangeStream()
+		} else {
+			err = t.tail()
+		}
+		t.stats.setRunning(false)
+		if err == nil {
+			return
+		}
+		// A tailer which ran for a while failed for a new reason, not the one it backed off for.
+		if time.Since(started) > maxOplogTailBackoff {
+			backoff = minOplogTailBackoff
+		}
+		glog.Errorf("Stopped tailing the oplog of %s, restarting in %s: %s", uriHosts(t.opts.URI), backoff, err)
+
+		select {
+		case <-t.stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxOplogTailBackoff {
+			backoff = maxOplogTailBackoff
+		}
+		t.stats.observeRestart()
+	}
+}
+
+// target names the target of the tailer in the state file.
+func (t *oplogTailer) target() string {
+	return uriHosts(t.opts.URI)
+}
+
+// resume loads the position saved in the state file, the tailer starts from the newest entry
+// when there is none.
+func (t *oplogTailer) resume() {
+	position, ok, err := loadOplogTailPosition(t.opts.OplogTail.StateFile, t.target())
+	if err != nil {
+		glog.Warningf("Can't read the oplog tail state file %s, starting over from now: %s", t.opts.OplogTail.StateFile, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	t.stats.resume(position, time.Now())
+	glog.Infof("Resuming the oplog tail of %s from %s", t.target(), time.Unix(int64(BsonMongoTimestampToUnix(position.Timestamp)), 0).UTC())
+}
+
+// checkpoints saves the position on an interval until the tailer is stopped.
+func (t *oplogTailer) checkpoints(done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(t.opts.OplogTail.checkpointInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.checkpoint()
+		}
+	}
+}
+
+// checkpoint saves the position in the state file, once the tailer saw an entry.
+func (t *oplogTailer) checkpoint() {
+	position := t.stats.position()
+	if position.Timestamp == 0 && position.ResumeToken == nil {
+		return
+	}
+	if err := saveOplogTailPosition(t.opts.OplogTail.StateFile, t.target(), position); err != nil {
+		glog.Errorf("Error saving the oplog tail position of %s to %s: %s", t.target(), t.opts.OplogTail.StateFile, err)
+	}
+}
 
-type OplogTailStats struct{}
+// tail tails the oplog until the tailer is stopped, when it returns nil, or until its cursor
+// fails. The whole entries are read, rather than what a driver makes of them, so that the writes
+// folded into applyOps entries are counted too.
+func (t *oplogTailer) tail() error {
+	session, err := t.opts.SessionManager.Session(t.opts.toSessionOps())
+	if err != nil {
+		return err
+	}
+	defer session.Close()
 
-func (o *OplogTailStats) Start(session *mgo.Session) {
 	// Override the socket timeout for oplog tailing
 	// Here we want a long-running socket, otherwise we cause lots of locks
 	// which seriously impede oplog performance
-	timeout := time.Second * 120
-	session.SetSocketTimeout(timeout)
-
-	defer session.Close()
+	session.SetSocketTimeout(120 * time.Second)
 	session.SetMode(mgo.Monotonic, true)
+	oplog := session.DB("local").C("oplog.rs")
 
-    // We want to include all oplog metrics, as such we'll include migrate entries
-    // which are the entries with `fromMigrate`
-	opts := gtm.DefaultOptions()
-	opts.IncludeMigrate = true
-	// If the mongoD has not joined a replicaSet, gtm.Start will crash when it
-	// try to get the oplog collection name. Setting the name here will prevent that.
-	// newer version of gtm fixed this and it always use 'oplog.rs'.
-	oplogName := "oplog.rs"
-	opts.OpLogCollectionName = &oplogName
-
-	ctx := gtm.Start(session, opts)
-	defer ctx.Stop()
-
-	// ctx.OpC is a channel to read ops from
-	// ctx.ErrC is a channel to read errors from
-	// ctx.Stop() stops all go routines started by gtm.Start
+	// The entries after the last one seen are gone once the oplog rolled over it.
+	if last := t.stats.last(); last != 0 {
+		oldest, err := GetOplogTimestamp(session, false)
+		if err != nil {
+			return err
+		}
+		if BsonMongoTimestampToUnix(last) < oldest {
+			glog.Warningf("The oplog of %s rolled over the last entry seen, starting over from now", t.target())
+			t.stats.observeGap()
+		}
+	}
+	// Resume after the last entry seen before a restart, or start after the newest one.
+	after := t.stats.last()
+	if after == 0 {
+		newest := struct {
+			Timestamp bson.MongoTimestamp `bson:"ts"`
+		}{}
+		if err := oplog.Find(nil).Sort("-$natural").One(&newest); err != nil && err != mgo.ErrNotFound {
+			return err
+		}
+		after = newest.Timestamp
+	}
+
+	// We want to include all oplog metrics, as such we'll include migrate entries
+	// which are the entries with `fromMigrate`
+	query := func() *mgo.Iter {
+		return oplog.Find(bson.M{"ts": bson.M{"$gt": after}}).LogReplay().Sort("$natural").Tail(oplogTailAwait)
+	}
+	iter := query()
+	defer func() { iter.Close() }()
+	t.stats.setRunning(true)
+
+	var entry bson.Raw
 	for {
-		// loop forever receiving events
+		for iter.Next(&entry) {
+			if timestamp, err := t.stats.observeOplogEntry(entry); err != nil {
+				t.stats.observeError()
+				glog.Errorf("Error decoding an oplog entry: %v", err)
+			} else {
+				after = timestamp
+			}
+		}
+		if err := iter.Err(); err != nil {
+			t.stats.observeError()
+			return err
+		}
+
 		select {
-		case err := <-ctx.ErrC:
-			oplogTailError.Add(1)
-			glog.Errorf("Error getting entry from oplog: %v", err)
-		case op := <-ctx.OpC:
-			oplogEntryCount.WithLabelValues(op.Namespace, op.Operation).Add(1)
-			oplogEntrySize.WithLabelValues(op.Namespace, op.Operation).Add(float64(op.DataSize))
+		case <-t.stop:
+			return nil
+		default:
+		}
+		// The cursor dies when it finds nothing to start from, it is restarted after the last
+		// entry seen.
+		if !iter.Timeout() {
+			iter.Close()
+			select {
+			case <-t.stop:
+				return nil
+			case <-time.After(oplogTailAwait):
+			}
+			iter = query()
 		}
 	}
 }
 
-// Export exports metrics to Prometheus
-func (status *OplogTailStats) Export(ch chan<- prometheus.Metric) {
-	oplogEntryCount.Collect(ch)
-	oplogEntrySize.Collect(ch)
-	oplogTailError.Collect(ch)
+// oplogTailerKey identifies the tailers which can be shared: the same target, reached the same
+// way, tailed with the same options.
+type oplogTailerKey struct {
+	session shared.MongoSessionOpts
+	tail    OplogTailOpts
 }
 
-// Describe describes metrics collected
-func (status *OplogTailStats) Describe(ch chan<- *prometheus.Desc) {
-	oplogEntryCount.Describe(ch)
-	oplogEntrySize.Describe(ch)
-	oplogTailError.Describe(ch)
+func newOplogTailerKey(opts MongodbCollectorOpts) oplogTailerKey {
+	return oplogTailerKey{session: opts.toSessionOps(), tail: opts.OplogTail}
+}
+
+// oplogTailManager runs one oplog tailer per target and options, shared by the collectors of the
+// target. A tailer is stopped once no collector uses it anymore.
+type oplogTailManager struct {
+	lock    sync.Mutex
+	tailers map[oplogTailerKey]*oplogTailer
 }
 
-func GetOplogTailStats(session *mgo.Session) *OplogTailStats {
-	if tailer == nil {
-		tailer = &OplogTailStats{}
-		// Start a tailer with a copy of the session (to avoid messing with the other metrics in the session)
-		go tailer.Start(session.Copy())
+func newOplogTailManager() *oplogTailManager {
+	return &oplogTailManager{tailers: make(map[oplogTailerKey]*oplogTailer)}
+}
+
+// acquire returns the tailer of the target of the options, starting it when there is none with the
+// same options.
+func (m *oplogTailManager) acquire(opts MongodbCollectorOpts) (*oplogTailer, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := newOplogTailerKey(opts)
+	tailer, ok := m.tailers[key]
+	if !ok {
+		namespaces, err := newOplogTailNamespaces(opts.OplogTail)
+		if err != nil {
+			return nil, err
+		}
+		tailer = newOplogTailer(opts, namespaces)
+		m.tailers[key] = tailer
+		go tailer.run()
+		glog.Infof("Started tailing the oplog of %s", uriHosts(opts.URI))
 	}
+	tailer.refs++
+	return tailer, nil
+}
+
+// reuse returns the running tailer of the target of the options, or nil when there is none with
+// the same options. It lets a collector take over the tailer of the collector it replaces on a
+// reload, before that one releases it, so that the tailer keeps its counters.
+func (m *oplogTailManager) reuse(opts MongodbCollectorOpts) *oplogTailer {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 
+	tailer, ok := m.tailers[newOplogTailerKey(opts)]
+	if !ok {
+		return nil
+	}
+	tailer.refs++
 	return tailer
 }
+
+// release stops the tailer when it was its last user and waits for it to finish.
+func (m *oplogTailManager) release(tailer *oplogTailer) {
+	m.lock.Lock()
+	tailer.refs--
+	key := newOplogTailerKey(tailer.opts)
+	last := tailer.refs == 0 && m.tailers[key] == tailer
+	if last {
+		delete(m.tailers, key)
+	}
+	m.lock.Unlock()
+
+	if last {
+		close(tailer.stop)
+		<-tailer.done
+		glog.Infof("Stopped tailing the oplog of %s", uriHosts(tailer.opts.URI))
+	}
+}
+
+// stopAll stops all the tailers and waits for them to finish.
+func (m *oplogTailManager) stopAll() {
+	m.lock.Lock()
+	tailers := m.tailers
+	m.tailers = make(map[oplogTailerKey]*oplogTailer)
+	m.lock.Unlock()
+
+	for _, tailer := range tailers {
+		close(tailer.stop)
+	}
+	for _, tailer := range tailers {
+		<-tailer.done
+	}
+}
+
+func init() {
+	Register("oplog_tail", newOplogTailCollector, false)
+}
+
+// oplogTailCollector starts tailing the oplog of its target on its first update and exports what
+// the tailer saw. The tailer is released when the collector is stopped. The oplog is only tailed
+// on mongod, while a change stream is also watched on mongos routers, where it covers every shard.
+type oplogTailCollector struct {
+	opts MongodbCollectorOpts
+
+	lock    sync.Mutex
+	tailer  *oplogTailer
+	stopped bool
+}
+
+func newOplogTailCollector(opts MongodbCollectorOpts) (Collector, error) {
+	if err := opts.OplogTail.Validate(); err != nil {
+		return nil, err
+	}
+	return &oplogTailCollector{opts: opts}, nil
+}
+
+func (c *oplogTailCollector) start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stopped = false
+	// The collectors of a new configuration start before the previous ones stop, so an unchanged
+	// tailer is handed over instead of being restarted.
+	if c.tailer == nil {
+		c.tailer = oplogTails.reuse(c.opts)
+	}
+}
+
+func (c *oplogTailCollector) stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stopped = true
+	if c.tailer != nil {
+		oplogTails.release(c.tailer)
+		c.tailer = nil
+	}
+}
+
+func (c *oplogTailCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// A scrape still in flight after the collector was stopped must not start a new tailer.
+	if c.stopped {
+		return nil
+	}
+	if c.tailer == nil {
+		isMaster, err := getIsMaster(session)
+		if err != nil {
+			return err
+		}
+		// Arbiters have no oplog to tail, mongos routers only have change streams.
+		if isMaster.ArbiterOnly || (isMaster.isMongos() && !c.opts.OplogTail.changeStream()) {
+			return nil
+		}
+		if c.tailer, err = oplogTails.acquire(c.opts); err != nil {
+			return err
+		}
+	}
+	c.tailer.stats.Export(ch)
+	return nil
+}
+
+func (c *oplogTailCollector) Describe(ch chan<- *prometheus.Desc) {
+	newOplogTailStats(nil).Describe(ch)
+}
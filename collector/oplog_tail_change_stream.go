@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/golang/glog"
+)
+
+const (
+	// OplogTailBackendOplog tails local.oplog.rs of a mongod, the default.
+	OplogTailBackendOplog = "oplog"
+	// OplogTailBackendChangeStream watches a change stream of the whole cluster, which also works
+	// on mongos routers and without read access to the local database.
+	OplogTailBackendChangeStream = "changestream"
+
+	// changeStreamAwait is how long getMore waits for new events, so that the tailer notices when
+	// it is stopped.
+	changeStreamAwait = time.Second
+)
+
+// changeStreamResumeErrors are the MongoDB error codes telling that a change stream can't be
+// resumed from its resume token, which has to be dropped.
+var changeStreamResumeErrors = map[int]bool{
+	260: true, // InvalidResumeToken
+	280: true, // ChangeStreamFatalError
+	286: true, // ChangeStreamHistoryLost
+}
+
+// changeEvent is the part of a change stream event counted by the tailer.
+type changeEvent struct {
+	// ID is the resume token of the event.
+	ID            *bson.Raw `bson:"_id"`
+	OperationType string    `bson:"operationType"`
+	NS            struct {
+		DB   string `bson:"db"`
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	// To is the new namespace of a renamed collection.
+	To struct {
+		DB   string `bson:"db"`
+		Coll string `bson:"coll"`
+	} `bson:"to"`
+	ClusterTime bson.MongoTimestamp `bson:"clusterTime"`
+}
+
+// oplogOperations map the operation types of the change events to the op of the oplog entries.
+var oplogOperations = map[string]string{
+	"insert":  "i",
+	"update":  "u",
+	"replace": "u",
+	"delete":  "d",
+}
+
+// oplogEntry returns the namespace and the op of the oplog entry the event was made from. Like in
+// the oplog, the commands are on the $cmd collection of their database.
+func (event *changeEvent) oplogEntry() (string, string) {
+	if op, ok := oplogOperations[event.OperationType]; ok {
+		return event.NS.DB + "." + event.NS.Coll, op
+	}
+	return event.NS.DB + ".$cmd", "c"
+}
+
+// ddlEvent returns the DDL event of a change event which changes the schema.
+func (event *changeEvent) ddlEvent(target, command string) DDLEvent {
+	ddl := DDLEvent{
+		Time:       time.Unix(int64(BsonMongoTimestampToUnix(event.ClusterTime)), 0).UTC(),
+		Target:     target,
+		DB:         event.NS.DB,
+		Collection: event.NS.Coll,
+		Command:    command,
+	}
+	if event.To.DB != "" {
+		ddl.To = event.To.DB + "." + event.To.Coll
+	}
+	return ddl
+}
+
+// changeStreamCursor is the cursor of the aggregate and getMore commands of a change stream.
+type changeStreamCursor struct {
+	Cursor struct {
+		ID         int64      `bson:"id"`
+		FirstBatch []bson.Raw `bson:"firstBatch"`
+		NextBatch  []bson.Raw `bson:"nextBatch"`
+		// PostBatchResumeToken is new in version 4.0.7, it moves forward even when no event
+		// matches.
+		PostBatchResumeToken *bson.Raw `bson:"postBatchResumeToken"`
+	} `bson:"cursor"`
+}
+
+// tailChangeStream counts the events of a change stream of the whole cluster until the tailer is
+// stopped, when it returns nil, or until the stream fails. It resumes after the last event seen
+// before a restart. The size of the events is counted as the size of the entries, it is not
+// quite the size of the oplog entries they were made from.
+//
+// The commands are run directly, as the change streams of mgo can't resume a stream of the whole
+// cluster on their own.
+func (t *oplogTailer) tailChangeStream() error {
+	session, err := t.opts.SessionManager.Session(t.opts.toSessionOps())
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	session.SetSocketTimeout(120 * time.Second)
+	admin := session.DB("admin")
+
+	// Without a resume token, e.g. when the state file was saved by the oplog backend, the stream
+	// starts at the last entry seen, which is counted again.
+	position := t.stats.position()
+	resuming := true
+	stage := bson.D{{Name: "allChangesForCluster", Value: true}}
+	if token := position.resumeToken(); token != nil {
+		stage = append(stage, bson.DocElem{Name: "resumeAfter", Value: token})
+	} else if position.Timestamp != 0 {
+		stage = append(stage, bson.DocElem{Name: "startAtOperationTime", Value: position.Timestamp})
+	} else {
+		resuming = false
+	}
+	// Before MongoDB 6.0 the streams only get the drops, the renames and dropDatabase among the
+	// schema changes, and the option is rejected.
+	if info, err := session.BuildInfo(); err != nil {
+		glog.Warningf("Couldn't get the version of %s, the change stream won't show the expanded events: %s", t.target(), err)
+	} else if info.VersionAtLeast(6, 0) {
+		stage = append(stage, bson.DocElem{Name: "showExpandedEvents", Value: true})
+	}
+	result := changeStreamCursor{}
+	err = admin.Run(bson.D{
+		{Name: "aggregate", Value: 1},
+		{Name: "pipeline", Value: []bson.D{{{Name: "$changeStream", Value: stage}}}},
+		{Name: "cursor", Value: bson.M{}},
+	}, &result)
+	if err != nil {
+		if queryErr, ok := err.(*mgo.QueryError); ok && resuming && changeStreamResumeErrors[queryErr.Code] {
+			glog.Warningf("Can't resume the change stream of %s, starting over from now: %s", t.target(), err)
+			t.stats.observeGap()
+		}
+		return err
+	}
+	cursorID := result.Cursor.ID
+	defer func() {
+		if cursorID != 0 {
+			admin.Run(bson.D{{Name: "killCursors", Value: "$cmd.aggregate"}, {Name: "cursors", Value: []int64{cursorID}}}, nil)
+		}
+	}()
+	t.stats.setRunning(true)
+
+	batch := result.Cursor.FirstBatch
+	for {
+		t.observeChangeEvents(batch, result.Cursor.PostBatchResumeToken)
+		if cursorID == 0 {
+			return fmt.Errorf("the change stream was closed by the server")
+		}
+
+		select {
+		case <-t.stop:
+			return nil
+		default:
+		}
+
+		result = changeStreamCursor{}
+		err := admin.Run(bson.D{
+			{Name: "getMore", Value: cursorID},
+			{Name: "collection", Value: "$cmd.aggregate"},
+			{Name: "maxTimeMS", Value: int64(changeStreamAwait / time.Millisecond)},
+		}, &result)
+		if err != nil {
+			t.stats.observeError()
+			return err
+		}
+		cursorID = result.Cursor.ID
+		batch = result.Cursor.NextBatch
+	}
+}
+
+// observeChangeEvents counts a batch of change events and remembers where to resume after it.
+func (t *oplogTailer) observeChangeEvents(batch []bson.Raw, postBatchResumeToken *bson.Raw) {
+	for _, raw := range batch {
+		event := changeEvent{}
+		if err := raw.Unmarshal(&event); err != nil {
+			t.stats.observeError()
+			glog.Errorf("Error decoding a change event: %v", err)
+			continue
+		}
+		ns, op := event.oplogEntry()
+		t.stats.observeEntry(ns, op, len(raw.Data), event.ClusterTime)
+		switch event.OperationType {
+		case "update":
+			t.stats.observeUpdate(ns, updateModifier)
+		case "replace":
+			t.stats.observeUpdate(ns, updateReplacement)
+		}
+		if command, ok := changeStreamDDLCommands[event.OperationType]; ok {
+			t.stats.observeDDLEvent(event.ddlEvent(t.stats.target, command))
+		}
+		t.stats.setResumeToken(event.ID)
+	}
+	if postBatchResumeToken != nil {
+		t.stats.setResumeToken(postBatchResumeToken)
+	}
+}
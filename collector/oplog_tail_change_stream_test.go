@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func Test_ObserveChangeEvents(t *testing.T) {
+	namespaces, err := newOplogTailNamespaces(OplogTailOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tailer := newOplogTailer(MongodbCollectorOpts{}, namespaces)
+
+	event := func(id, operationType, db, coll string, seconds int64) bson.Raw {
+		data, err := bson.Marshal(bson.M{
+			"_id":           bson.M{"_data": id},
+			"operationType": operationType,
+			"ns":            bson.M{"db": db, "coll": coll},
+			"clusterTime":   bson.MongoTimestamp(seconds << 32),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bson.Raw{Kind: 0x03, Data: data}
+	}
+	tailer.observeChangeEvents([]bson.Raw{
+		event("1", "insert", "app", "users", 100),
+		event("2", "replace", "app", "users", 101),
+		event("3", "drop", "app", "orders", 102),
+	}, nil)
+
+	stats := tailer.stats
+	if count := stats.entryCount[oplogTailKey{ns: "app.users", op: "u"}]; count != 1 {
+		t.Errorf("expected the replacement to be counted as an update, got %f", count)
+	}
+	if count := stats.entryCount[oplogTailKey{ns: "app.$cmd", op: "c"}]; count != 1 {
+		t.Errorf("expected the drop to be counted as a command, got %f", count)
+	}
+	if last := BsonMongoTimestampToUnix(stats.lastTimestamp); last != 102 {
+		t.Errorf("expected the last timestamp to be 102, got %f", last)
+	}
+
+	token := struct {
+		Data string `bson:"_data"`
+	}{}
+	if err := tailer.stats.resumeToken.Unmarshal(&token); err != nil || token.Data != "3" {
+		t.Errorf("expected to resume after the last event, got %q (%v)", token.Data, err)
+	}
+
+	// The resume token after the batch moves forward even without events.
+	data, err := bson.Marshal(bson.M{"_data": "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	postBatch := bson.Raw{Kind: 0x03, Data: data}
+	tailer.observeChangeEvents(nil, &postBatch)
+	if err := tailer.stats.resumeToken.Unmarshal(&token); err != nil || token.Data != "4" {
+		t.Errorf("expected to resume after the batch, got %q (%v)", token.Data, err)
+	}
+}
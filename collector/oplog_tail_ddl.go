@@ -0,0 +1,192 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultDDLEventsCapacity is the number of recent DDL events kept for DDLEventsHandler.
+const DefaultDDLEventsCapacity = 100
+
+var (
+	oplogDDLEvents = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "ddl_events_total"),
+		"The total number of schema changes observed in the oplog by db, collection and command.",
+		[]string{"db", "collection", "command"}, nil,
+	)
+)
+
+// ddlCommands are the commands of the oplog entries which change the schema.
+var ddlCommands = map[string]bool{
+	"create":           true,
+	"drop":             true,
+	"renameCollection": true,
+	"createIndexes":    true,
+	"dropIndexes":      true,
+	"collMod":          true,
+	"dropDatabase":     true,
+	"convertToCapped":  true,
+	"emptycapped":      true,
+	// Since MongoDB 4.4 the indexes of replica sets are built by these instead of createIndexes.
+	"startIndexBuild":  true,
+	"commitIndexBuild": true,
+	"abortIndexBuild":  true,
+}
+
+// changeStreamDDLCommands map the operation types of the change events to the command of the
+// oplog entries they were made from. The events besides drop, rename and dropDatabase are only
+// sent to streams which ask for expanded events, since MongoDB 6.0.
+var changeStreamDDLCommands = map[string]string{
+	"create":        "create",
+	"drop":          "drop",
+	"rename":        "renameCollection",
+	"createIndexes": "createIndexes",
+	"dropIndexes":   "dropIndexes",
+	"modify":        "collMod",
+	"dropDatabase":  "dropDatabase",
+}
+
+// ddlKey identifies the DDL events counted by the tailer.
+type ddlKey struct {
+	db         string
+	collection string
+	command    string
+}
+
+// DDLEvent is a schema change observed in the oplog.
+type DDLEvent struct {
+	Time       time.Time `json:"time"`
+	Target     string    `json:"target"`
+	DB         string    `json:"db"`
+	Collection string    `json:"collection,omitempty"`
+	Command    string    `json:"command"`
+	// To is the namespace a collection is renamed to.
+	To string `json:"to,omitempty"`
+	// Index is the name of the index created or dropped.
+	Index string `json:"index,omitempty"`
+}
+
+// ddlEventBuffer keeps the most recent DDL events of all the tailers.
+type ddlEventBuffer struct {
+	lock   sync.Mutex
+	events []DDLEvent
+	// next is where the next event is written, once the buffer is full it is the oldest event.
+	next int
+	full bool
+}
+
+func newDDLEventBuffer(capacity int) *ddlEventBuffer {
+	return &ddlEventBuffer{events: make([]DDLEvent, capacity)}
+}
+
+func (b *ddlEventBuffer) add(event DDLEvent) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.events) == 0 {
+		return
+	}
+	b.events[b.next] = event
+	b.next++
+	if b.next == len(b.events) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// list returns the events, the most recent first.
+func (b *ddlEventBuffer) list() []DDLEvent {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	count := b.next
+	if b.full {
+		count = len(b.events)
+	}
+	events := make([]DDLEvent, 0, count)
+	for i := 1; i <= count; i++ {
+		events = append(events, b.events[(b.next-i+len(b.events))%len(b.events)])
+	}
+	return events
+}
+
+// ddlEvents are the recent DDL events of the process.
+var ddlEvents = newDDLEventBuffer(DefaultDDLEventsCapacity)
+
+// SetDDLEventsCapacity sets how many recent DDL events are kept, dropping those kept so far. It is
+// meant to be called before the tailers start.
+func SetDDLEventsCapacity(capacity int) {
+	if capacity < 0 {
+		capacity = 0
+	}
+	ddlEvents = newDDLEventBuffer(capacity)
+}
+
+// DDLEventsHandler serves the recent DDL events as JSON, the most recent first.
+func DDLEventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ddlEvents.list()); err != nil {
+			glog.Errorf("Error encoding the DDL events: %s", err)
+		}
+	})
+}
+
+// observeDDL counts a command entry of the given namespace when it changes the schema. The
+// collection labels follow the options of the ns labels, the collection of the namespaces past
+// the cap is __other__, and it is empty when they are aggregated by database.
+func (o *OplogTailStats) observeDDL(ns string, timestamp bson.MongoTimestamp, command *oplogCommand) {
+	if !ddlCommands[command.Name] {
+		return
+	}
+	event := DDLEvent{
+		Time:       time.Unix(int64(BsonMongoTimestampToUnix(timestamp)), 0).UTC(),
+		Target:     o.target,
+		DB:         strings.SplitN(ns, ".", 2)[0],
+		Collection: command.Collection,
+		Command:    command.Name,
+		To:         command.To,
+		Index:      command.Index,
+	}
+	switch command.Name {
+	case "dropDatabase":
+		event.Collection = ""
+	case "renameCollection":
+		// The command runs on the admin database, its collection is a namespace.
+		parts := strings.SplitN(command.Collection, ".", 2)
+		if len(parts) == 2 {
+			event.DB, event.Collection = parts[0], parts[1]
+		}
+	}
+	o.observeDDLEvent(event)
+}
+
+func (o *OplogTailStats) observeDDLEvent(event DDLEvent) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	ns := event.DB
+	if event.Collection != "" {
+		ns += "." + event.Collection
+	}
+	label, ok := o.namespaces.label(ns)
+	if !ok {
+		return
+	}
+	ddlEvents.add(event)
+	key := ddlKey{db: event.DB, command: event.Command}
+	switch {
+	case label == otherNamespace:
+		key.collection = otherNamespace
+	case !o.namespaces.byDatabase:
+		key.collection = event.Collection
+	}
+	o.ddlEvents[key]++
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func Test_DDLEventBuffer(t *testing.T) {
+	buffer := newDDLEventBuffer(3)
+	if events := buffer.list(); len(events) != 0 {
+		t.Fatalf("expected no event, got %v", events)
+	}
+	for _, command := range []string{"create", "createIndexes", "dropIndexes", "drop"} {
+		buffer.add(DDLEvent{Command: command})
+	}
+	events := buffer.list()
+	if len(events) != 3 || events[0].Command != "drop" || events[2].Command != "createIndexes" {
+		t.Errorf("expected the 3 most recent events, the most recent first, got %v", events)
+	}
+
+	newDDLEventBuffer(0).add(DDLEvent{})
+}
+
+func Test_OplogTailDDLEvents(t *testing.T) {
+	ddlEvents = newDDLEventBuffer(DefaultDDLEventsCapacity)
+	namespaces, _ := newOplogTailNamespaces(OplogTailOpts{ExcludeNamespaces: `^config\.`})
+	stats := newOplogTailStats(namespaces)
+	stats.target = "db1:27017"
+
+	entries := []bson.M{
+		{"ts": bson.MongoTimestamp(1 << 32), "op": "c", "ns": "app.$cmd", "o": bson.D{{Name: "create", Value: "orders"}}},
+		{"ts": bson.MongoTimestamp(2 << 32), "op": "c", "ns": "admin.$cmd", "o": bson.D{{Name: "renameCollection", Value: "app.orders"}, {Name: "to", Value: "app.orders_v2"}}},
+		{"ts": bson.MongoTimestamp(3 << 32), "op": "c", "ns": "app.$cmd", "o": bson.D{{Name: "dropIndexes", Value: "orders_v2"}, {Name: "index", Value: "status_1"}}},
+		{"ts": bson.MongoTimestamp(4 << 32), "op": "i", "ns": "app.system.indexes", "o": bson.M{"ns": "app.orders_v2", "name": "created_1", "key": bson.M{"created": 1}}},
+		{"ts": bson.MongoTimestamp(5 << 32), "op": "c", "ns": "admin.$cmd", "o": bson.D{{Name: "applyOps", Value: []bson.M{
+			{"op": "c", "ns": "app.$cmd", "o": bson.D{{Name: "create", Value: "items"}}},
+		}}}},
+		{"ts": bson.MongoTimestamp(6 << 32), "op": "c", "ns": "config.$cmd", "o": bson.D{{Name: "drop", Value: "cache"}}},
+		{"ts": bson.MongoTimestamp(7 << 32), "op": "c", "ns": "app.$cmd", "o": bson.D{{Name: "dropDatabase", Value: 1}}},
+		{"ts": bson.MongoTimestamp(8 << 32), "op": "c", "ns": "app.$cmd", "o": bson.D{{Name: "commitTransaction", Value: 1}}},
+	}
+	for _, entry := range entries {
+		if _, err := stats.observeOplogEntry(rawDocument(t, entry)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[ddlKey]float64{
+		{db: "app", collection: "orders", command: "create"}:           1,
+		{db: "app", collection: "orders", command: "renameCollection"}: 1,
+		{db: "app", collection: "orders_v2", command: "dropIndexes"}:   1,
+		{db: "app", collection: "orders_v2", command: "createIndexes"}: 1,
+		{db: "app", collection: "items", command: "create"}:            1,
+		{db: "app", collection: "", command: "dropDatabase"}:           1,
+	}
+	if len(stats.ddlEvents) != len(expected) {
+		t.Errorf("expected %d DDL events, got %v", len(expected), stats.ddlEvents)
+	}
+	for key, count := range expected {
+		if stats.ddlEvents[key] != count {
+			t.Errorf("expected %v %s on %s.%s, got %v", count, key.command, key.db, key.collection, stats.ddlEvents[key])
+		}
+	}
+
+	events := ddlEvents.list()
+	if len(events) != 6 {
+		t.Fatalf("expected 6 recent DDL events, got %v", events)
+	}
+	if rename := events[4]; rename.To != "app.orders_v2" || rename.Target != "db1:27017" || rename.Time.Unix() != 2 {
+		t.Errorf("expected the rename to app.orders_v2, got %+v", rename)
+	}
+	if drop := events[3]; drop.Index != "status_1" {
+		t.Errorf("expected the dropped index, got %+v", drop)
+	}
+}
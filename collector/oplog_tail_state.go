@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// oplogTailPosition is where the tailer of a target resumes after the exporter restarts.
+type oplogTailPosition struct {
+	// Timestamp is the timestamp of the last entry seen.
+	Timestamp bson.MongoTimestamp `json:"ts"`
+	// ResumeToken is the data of the resume token of the last change event seen, only set with the
+	// change stream backend.
+	ResumeToken []byte `json:"resume_token,omitempty"`
+}
+
+// resumeToken returns the resume token of the position, nil when there is none.
+func (p oplogTailPosition) resumeToken() *bson.Raw {
+	if len(p.ResumeToken) == 0 {
+		return nil
+	}
+	return &bson.Raw{Kind: 0x03, Data: p.ResumeToken}
+}
+
+// oplogTailState is the content of the state file, the positions of the tailers by target. The
+// targets are named after their hosts, so that the credentials don't end up in the file.
+type oplogTailState struct {
+	Positions map[string]oplogTailPosition `json:"positions"`
+}
+
+// oplogTailStateLock serializes the reads and writes of the state files, which may be shared by
+// the tailers of several targets.
+var oplogTailStateLock sync.Mutex
+
+// readOplogTailState reads a state file, a missing file is an empty state.
+func readOplogTailState(path string) (*oplogTailState, error) {
+	state := &oplogTailState{Positions: make(map[string]oplogTailPosition)}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+	if state.Positions == nil {
+		state.Positions = make(map[string]oplogTailPosition)
+	}
+	return state, nil
+}
+
+// loadOplogTailPosition returns the position of the target saved in the state file, false when
+// there is none.
+func loadOplogTailPosition(path, target string) (oplogTailPosition, bool, error) {
+	oplogTailStateLock.Lock()
+	defer oplogTailStateLock.Unlock()
+
+	state, err := readOplogTailState(path)
+	if err != nil {
+		return oplogTailPosition{}, false, err
+	}
+	position, ok := state.Positions[target]
+	return position, ok, nil
+}
+
+// saveOplogTailPosition saves the position of the target in the state file, keeping the positions
+// of the other targets. A file which can't be read is started over. The file is replaced at once
+// so that a crash doesn't leave half of it.
+func saveOplogTailPosition(path, target string, position oplogTailPosition) error {
+	oplogTailStateLock.Lock()
+	defer oplogTailStateLock.Unlock()
+
+	state, err := readOplogTailState(path)
+	if err != nil {
+		state = &oplogTailState{Positions: make(map[string]oplogTailPosition)}
+	}
+	state.Positions[target] = position
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func Test_OplogTailState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oplog_tail_state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	if _, ok, err := loadOplogTailPosition(path, "db1:27017"); ok || err != nil {
+		t.Fatalf("expected no position in a missing file, got %t (%v)", ok, err)
+	}
+
+	first := oplogTailPosition{Timestamp: bson.MongoTimestamp(1500000000<<32 | 3)}
+	second := oplogTailPosition{Timestamp: bson.MongoTimestamp(1500000100 << 32), ResumeToken: []byte{5, 0, 0, 0, 0}}
+	if err := saveOplogTailPosition(path, "db1:27017", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveOplogTailPosition(path, "db2:27017", second); err != nil {
+		t.Fatal(err)
+	}
+
+	position, ok, err := loadOplogTailPosition(path, "db1:27017")
+	if !ok || err != nil || position.Timestamp != first.Timestamp || position.resumeToken() != nil {
+		t.Errorf("expected the position of the first target to be kept, got %+v %t (%v)", position, ok, err)
+	}
+	position, ok, err = loadOplogTailPosition(path, "db2:27017")
+	if !ok || err != nil || position.Timestamp != second.Timestamp || position.resumeToken() == nil {
+		t.Errorf("expected the position of the second target, got %+v %t (%v)", position, ok, err)
+	}
+
+	// A corrupted file is started over.
+	if err := ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadOplogTailPosition(path, "db1:27017"); err == nil {
+		t.Error("expected an error reading a corrupted file")
+	}
+	if err := saveOplogTailPosition(path, "db1:27017", first); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := loadOplogTailPosition(path, "db1:27017"); !ok {
+		t.Error("expected the corrupted file to be replaced")
+	}
+}
+
+func Test_OplogTailStatsResume(t *testing.T) {
+	namespaces, _ := newOplogTailNamespaces(OplogTailOpts{})
+	stats := newOplogTailStats(namespaces)
+	now := time.Unix(1500000100, 0)
+	stats.resume(oplogTailPosition{Timestamp: bson.MongoTimestamp(1500000000 << 32)}, now)
+
+	stats.observeEntry("app.users", "i", 10, bson.MongoTimestamp(1500000050<<32))
+	stats.observeEntry("app.users", "i", 10, bson.MongoTimestamp(1500000099<<32|7))
+	stats.observeEntry("app.users", "i", 10, bson.MongoTimestamp(1500000100<<32))
+	if stats.replayed != 2 {
+		t.Errorf("expected the 2 entries written before resuming to be replayed, got %v", stats.replayed)
+	}
+	if stats.last() != bson.MongoTimestamp(1500000100<<32) {
+		t.Errorf("expected the position to move forward, got %v", stats.last())
+	}
+
+	stats.observeGap()
+	if stats.gaps != 1 || stats.last() != 0 {
+		t.Errorf("expected a gap dropping the position, got %v gaps at %v", stats.gaps, stats.last())
+	}
+}
@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/dcu/mongodb_exporter/shared"
+)
+
+func Test_OplogTailManager(t *testing.T) {
+	// The URI doesn't parse, so the tailers keep failing and waiting to restart.
+	opts := MongodbCollectorOpts{URI: "mongodb://localhost/?unknownOption=1", SessionManager: shared.NewSessionManager()}
+	manager := newOplogTailManager()
+
+	first, _ := manager.acquire(opts)
+	second, _ := manager.acquire(opts)
+	if first != second {
+		t.Fatal("expected a single tailer per target")
+	}
+	other, _ := manager.acquire(MongodbCollectorOpts{URI: "mongodb://otherhost/?unknownOption=1", SessionManager: opts.SessionManager})
+	if other == first {
+		t.Fatal("expected another tailer for another target")
+	}
+
+	manager.release(first)
+	select {
+	case <-first.done:
+		t.Fatal("expected the tailer to keep running while it is used")
+	default:
+	}
+	manager.release(second)
+	<-first.done
+
+	if third, _ := manager.acquire(opts); third == first {
+		t.Error("expected a new tailer once the previous one was stopped")
+	}
+	manager.stopAll()
+	<-other.done
+}
+
+func Test_OplogTailReload(t *testing.T) {
+	opts := MongodbCollectorOpts{URI: "mongodb://localhost/?unknownOption=1", SessionManager: shared.NewSessionManager()}
+	reload := func(previous *oplogTailCollector, opts MongodbCollectorOpts) *oplogTailCollector {
+		collector, err := newOplogTailCollector(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := collector.(*oplogTailCollector)
+		next.start()
+		previous.stop()
+		return next
+	}
+
+	first := &oplogTailCollector{opts: opts}
+	first.tailer, _ = oplogTails.acquire(opts)
+	tailer := first.tailer
+
+	// The same options keep the tailer, and its counters.
+	second := reload(first, opts)
+	if second.tailer != tailer {
+		t.Fatal("expected the tailer to be handed over when the options didn't change")
+	}
+	select {
+	case <-tailer.done:
+		t.Fatal("expected the tailer to keep running after the reload")
+	default:
+	}
+
+	// Other options restart it.
+	opts.OplogTail.ExcludeNamespaces = `^config\.`
+	third := reload(second, opts)
+	if third.tailer != nil {
+		t.Error("expected no tailer to be handed over when the options changed")
+	}
+	<-tailer.done
+
+	third.tailer, _ = oplogTails.acquire(opts)
+	if third.tailer == tailer || third.tailer.opts.OplogTail.ExcludeNamespaces != opts.OplogTail.ExcludeNamespaces {
+		t.Error("expected a new tailer with the new options")
+	}
+	third.stop()
+}
+
+func Test_OplogTailNamespaces(t *testing.T) {
+	namespaces, err := newOplogTailNamespaces(OplogTailOpts{
+		ExcludeNamespaces: `^config\.|\.system\.`,
+		MaxNamespaces:     2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		ns    string
+		label string
+		ok    bool
+	}{
+		{"config.transactions", "", false},
+		{"app.system.profile", "", false},
+		{"app.users", "app.users", true},
+		{"app.orders", "app.orders", true},
+		{"app.items", otherNamespace, true},
+		// The labels given before the cap was reached are kept.
+		{"app.users", "app.users", true},
+	}
+	for _, e := range expected {
+		if label, ok := namespaces.label(e.ns); label != e.label || ok != e.ok {
+			t.Errorf("expected %q to be labeled %q (%t), got %q (%t)", e.ns, e.label, e.ok, label, ok)
+		}
+	}
+
+	namespaces, err = newOplogTailNamespaces(OplogTailOpts{IncludeNamespaces: `^tenant`, AggregateByDatabase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label, ok := namespaces.label("tenant42.orders"); label != "tenant42" || !ok {
+		t.Errorf("expected the database label tenant42, got %q (%t)", label, ok)
+	}
+	if _, ok := namespaces.label("admin.system.users"); ok {
+		t.Error("expected the namespaces which aren't included to be skipped")
+	}
+
+	if err := (OplogTailOpts{ExcludeNamespaces: "("}).Validate(); err == nil {
+		t.Error("expected an invalid regular expression to be rejected")
+	}
+}
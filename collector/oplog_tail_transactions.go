@@ -0,0 +1,304 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxPendingTransactions caps the number of transactions whose entries are summed until they
+	// commit, the oldest one is dropped to make room for a new one once it is reached.
+	maxPendingTransactions = 1000
+
+	updateReplacement = "replacement"
+	updateModifier    = "modifier"
+)
+
+var (
+	oplogApplyOpsCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "applyops_ops_total"),
+		"The total number of operations folded into the applyOps entries of the oplog, of transactions or not, by ns/op.",
+		[]string{"ns", "op"}, nil,
+	)
+	oplogApplyOpsSize = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "applyops_ops_size_bytes_total"),
+		"The total size of the operations folded into the applyOps entries of the oplog by ns/op.",
+		[]string{"ns", "op"}, nil,
+	)
+	oplogUpdates = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "oplogtail", "updates_total"),
+		"The total number of updates by ns and type, replacement when they replace the whole document, modifier when they use update operators such as $set.",
+		[]string{"ns", "type"}, nil,
+	)
+)
+
+// oplogEntry is the part of an oplog entry counted by the tailer.
+type oplogEntry struct {
+	Timestamp bson.MongoTimestamp `bson:"ts"`
+	Op        string              `bson:"op"`
+	NS        string              `bson:"ns"`
+	Object    bson.Raw            `bson:"o"`
+	// LSID and TxnNumber identify the transaction of the entry, if any.
+	LSID      bson.Raw `bson:"lsid"`
+	TxnNumber int64    `bson:"txnNumber"`
+}
+
+// transaction returns the key of the transaction of the entry, false when it isn't part of one.
+func (entry *oplogEntry) transaction() (string, bool) {
+	if entry.LSID.Kind == 0 {
+		return "", false
+	}
+	return string(entry.LSID.Data) + "/" + strconv.FormatInt(entry.TxnNumber, 10), true
+}
+
+// oplogCommand is the object of a command entry. Commands are documents whose first field is
+// the name of the command.
+type oplogCommand struct {
+	Name string
+	// Collection is the value of the first field when it is a string, the collection of most of
+	// the DDL commands, and To the target namespace of renameCollection.
+	Collection string
+	To         string
+	// Index is the name of the index of createIndexes and dropIndexes.
+	Index string
+	// Ops are the operations of an applyOps command.
+	Ops []bson.Raw
+	// PartialTxn tells that more entries of the transaction follow, and Prepare that the
+	// transaction commits, or aborts, in a later entry.
+	PartialTxn bool
+	Prepare    bool
+}
+
+func decodeCommand(object bson.Raw) (*oplogCommand, error) {
+	fields := bson.RawD{}
+	if err := object.Unmarshal(&fields); err != nil {
+		return nil, err
+	}
+	command := &oplogCommand{}
+	for i, field := range fields {
+		var err error
+		switch {
+		case i == 0:
+			command.Name = field.Name
+			if field.Name == "applyOps" {
+				err = field.Value.Unmarshal(&command.Ops)
+			} else if field.Value.Kind == 0x02 {
+				err = field.Value.Unmarshal(&command.Collection)
+			}
+		case field.Name == "to" && field.Value.Kind == 0x02:
+			err = field.Value.Unmarshal(&command.To)
+		case (field.Name == "name" || field.Name == "index") && field.Value.Kind == 0x02:
+			err = field.Value.Unmarshal(&command.Index)
+		case field.Name == "partialTxn":
+			err = field.Value.Unmarshal(&command.PartialTxn)
+		case field.Name == "prepare":
+			err = field.Value.Unmarshal(&command.Prepare)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return command, nil
+}
+
+// updateType tells whether the object of an update entry replaces the whole document or uses
+// update operators, like $set or the $v/diff of MongoDB 5.0.
+func updateType(object bson.Raw) (string, error) {
+	fields := bson.RawD{}
+	if err := object.Unmarshal(&fields); err != nil {
+		return "", err
+	}
+	for _, field := range fields {
+		if strings.HasPrefix(field.Name, "$") {
+			return updateModifier, nil
+		}
+	}
+	return updateReplacement, nil
+}
+
+// pendingTransaction sums the entries of a transaction until it commits.
+type pendingTransaction struct {
+	// started is the timestamp of the first entry of the transaction.
+	started    bson.MongoTimestamp
+	size       int
+	statements int
+}
+
+// observeOplogEntry counts an oplog entry and the operations folded into it, and returns its
+// timestamp.
+func (o *OplogTailStats) observeOplogEntry(raw bson.Raw) (bson.MongoTimestamp, error) {
+	entry := oplogEntry{}
+	if err := raw.Unmarshal(&entry); err != nil {
+		return 0, err
+	}
+	o.observeEntry(entry.NS, entry.Op, len(raw.Data), entry.Timestamp)
+
+	switch entry.Op {
+	case "i":
+		// Until MongoDB 4.2 indexes are created by inserts into system.indexes.
+		if strings.HasSuffix(entry.NS, ".system.indexes") {
+			index := struct {
+				NS   string `bson:"ns"`
+				Name string `bson:"name"`
+			}{}
+			if err := entry.Object.Unmarshal(&index); err != nil {
+				return entry.Timestamp, err
+			}
+			collection := ""
+			if parts := strings.SplitN(index.NS, ".", 2); len(parts) == 2 {
+				collection = parts[1]
+			}
+			o.observeDDL(index.NS, entry.Timestamp, &oplogCommand{Name: "createIndexes", Collection: collection, Index: index.Name})
+		}
+	case "u":
+		kind, err := updateType(entry.Object)
+		if err != nil {
+			return entry.Timestamp, err
+		}
+		o.observeUpdate(entry.NS, kind)
+	case "c":
+		command, err := decodeCommand(entry.Object)
+		if err != nil {
+			return entry.Timestamp, err
+		}
+		if err := o.observeCommand(&entry, command, len(raw.Data)); err != nil {
+			return entry.Timestamp, err
+		}
+	}
+	return entry.Timestamp, nil
+}
+
+// observeCommand counts the DDL commands, the operations of an applyOps entry, and the size of
+// the transactions once they commit. The entries of a transaction are either a single applyOps, a
+// chain of applyOps with partialTxn set, or applyOps entries with prepare set followed by a
+// commitTransaction. The applyOps entries without a transaction, written by the applyOps command,
+// only count their operations.
+func (o *OplogTailStats) observeCommand(entry *oplogEntry, command *oplogCommand, size int) error {
+	key, inTransaction := entry.transaction()
+	switch command.Name {
+	case "applyOps":
+		for _, raw := range command.Ops {
+			op := oplogEntry{}
+			if err := raw.Unmarshal(&op); err != nil {
+				return err
+			}
+			o.observeApplyOp(op.NS, op.Op, len(raw.Data))
+			switch op.Op {
+			case "u":
+				kind, err := updateType(op.Object)
+				if err != nil {
+					return err
+				}
+				o.observeUpdate(op.NS, kind)
+			case "c":
+				// Since MongoDB 4.4 collections and indexes may be created in transactions.
+				inner, err := decodeCommand(op.Object)
+				if err != nil {
+					return err
+				}
+				o.observeDDL(op.NS, entry.Timestamp, inner)
+			}
+		}
+		if inTransaction && (command.PartialTxn || command.Prepare) {
+			o.addPendingTransaction(key, entry.Timestamp, size, len(command.Ops))
+		} else if inTransaction {
+			o.commitTransaction(key, size, len(command.Ops))
+		}
+	case "commitTransaction":
+		if inTransaction {
+			o.commitTransaction(key, size, 0)
+		}
+	case "abortTransaction":
+		if inTransaction {
+			o.abortTransaction(key)
+		}
+	default:
+		o.observeDDL(entry.NS, entry.Timestamp, command)
+	}
+	return nil
+}
+
+func (o *OplogTailStats) observeApplyOp(ns, op string, size int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	label, ok := o.namespaces.label(ns)
+	if !ok {
+		return
+	}
+	key := oplogTailKey{ns: label, op: op}
+	o.applyOpsCount[key]++
+	o.applyOpsSize[key] += float64(size)
+}
+
+func (o *OplogTailStats) observeUpdate(ns, kind string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	label, ok := o.namespaces.label(ns)
+	if !ok {
+		return
+	}
+	o.updates[oplogTailKey{ns: label, op: kind}]++
+}
+
+func (o *OplogTailStats) observeTransaction(size, statements int) {
+	o.transactionSizes.Observe(float64(size))
+	o.transactionStatements.Observe(float64(statements))
+}
+
+func (o *OplogTailStats) addPendingTransaction(key string, timestamp bson.MongoTimestamp, size, statements int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	pending, ok := o.pendingTransactions[key]
+	if !ok {
+		if len(o.pendingTransactions) >= maxPendingTransactions {
+			o.dropOldestPendingTransaction()
+		}
+		pending = &pendingTransaction{started: timestamp}
+		o.pendingTransactions[key] = pending
+	}
+	pending.size += size
+	pending.statements += statements
+}
+
+// dropOldestPendingTransaction drops the transaction which started first, most likely one whose
+// last entry was missed. The caller must hold o.lock.
+func (o *OplogTailStats) dropOldestPendingTransaction() {
+	oldestKey := ""
+	var oldest *pendingTransaction
+	for key, pending := range o.pendingTransactions {
+		if oldest == nil || pending.started < oldest.started {
+			oldestKey, oldest = key, pending
+		}
+	}
+	delete(o.pendingTransactions, oldestKey)
+}
+
+// commitTransaction observes a transaction with its last entry. The transactions whose first
+// entries weren't seen, as they were written before the tailer started, aren't observed, except
+// for the single entry ones.
+func (o *OplogTailStats) commitTransaction(key string, size, statements int) {
+	o.lock.Lock()
+	pending, ok := o.pendingTransactions[key]
+	delete(o.pendingTransactions, key)
+	o.lock.Unlock()
+
+	switch {
+	case ok:
+		o.observeTransaction(pending.size+size, pending.statements+statements)
+	case statements > 0:
+		o.observeTransaction(size, statements)
+	}
+}
+
+func (o *OplogTailStats) abortTransaction(key string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	delete(o.pendingTransactions, key)
+}
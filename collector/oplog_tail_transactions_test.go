@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func rawDocument(t *testing.T, doc interface{}) bson.Raw {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bson.Raw{Kind: 0x03, Data: data}
+}
+
+func transactionEntry(ts int64, txnNumber int64, object bson.D) bson.M {
+	return bson.M{
+		"ts": bson.MongoTimestamp(ts << 32), "op": "c", "ns": "admin.$cmd", "o": object,
+		"lsid": bson.M{"id": "session"}, "txnNumber": txnNumber,
+	}
+}
+
+func Test_OplogTailTransactions(t *testing.T) {
+	namespaces, _ := newOplogTailNamespaces(OplogTailOpts{})
+	stats := newOplogTailStats(namespaces)
+	insert := bson.M{"op": "i", "ns": "app.orders", "o": bson.M{"_id": 1}}
+	update := bson.M{"op": "u", "ns": "app.orders", "o": bson.M{"$set": bson.M{"a": 1}}, "o2": bson.M{"_id": 1}}
+
+	entries := []bson.M{
+		{"ts": bson.MongoTimestamp(1 << 32), "op": "u", "ns": "app.users", "o": bson.M{"_id": 1, "name": "x"}, "o2": bson.M{"_id": 1}},
+		{"ts": bson.MongoTimestamp(2 << 32), "op": "u", "ns": "app.users", "o": bson.D{{Name: "$v", Value: 2}, {Name: "diff", Value: bson.M{}}}, "o2": bson.M{"_id": 1}},
+		// applyOps outside of a transaction.
+		{"ts": bson.MongoTimestamp(3 << 32), "op": "c", "ns": "admin.$cmd", "o": bson.D{{Name: "applyOps", Value: []bson.M{insert, update}}}},
+		// A transaction over two entries.
+		transactionEntry(4, 1, bson.D{{Name: "applyOps", Value: []bson.M{insert, insert}}, {Name: "partialTxn", Value: true}}),
+		transactionEntry(5, 1, bson.D{{Name: "applyOps", Value: []bson.M{insert}}}),
+		// A prepared transaction, then an aborted one.
+		transactionEntry(6, 2, bson.D{{Name: "applyOps", Value: []bson.M{update}}, {Name: "prepare", Value: true}}),
+		transactionEntry(7, 2, bson.D{{Name: "commitTransaction", Value: 1}}),
+		transactionEntry(8, 3, bson.D{{Name: "applyOps", Value: []bson.M{insert}}, {Name: "prepare", Value: true}}),
+		transactionEntry(9, 3, bson.D{{Name: "abortTransaction", Value: 1}}),
+	}
+	for _, entry := range entries {
+		ts, err := stats.observeOplogEntry(rawDocument(t, entry))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts != entry["ts"] {
+			t.Errorf("expected the timestamp %v, got %v", entry["ts"], ts)
+		}
+	}
+
+	if count := stats.entryCount[oplogTailKey{ns: "admin.$cmd", op: "c"}]; count != 7 {
+		t.Errorf("expected 7 command entries, got %v", count)
+	}
+	if count := stats.applyOpsCount[oplogTailKey{ns: "app.orders", op: "i"}]; count != 5 {
+		t.Errorf("expected 5 inserts folded into applyOps, got %v", count)
+	}
+	if count := stats.applyOpsCount[oplogTailKey{ns: "app.orders", op: "u"}]; count != 2 {
+		t.Errorf("expected 2 updates folded into applyOps, got %v", count)
+	}
+	expectedUpdates := map[oplogTailKey]float64{
+		{ns: "app.users", op: updateReplacement}:  1,
+		{ns: "app.users", op: updateModifier}:     1,
+		{ns: "app.orders", op: updateModifier}:    2,
+		{ns: "app.orders", op: updateReplacement}: 0,
+	}
+	for key, expected := range expectedUpdates {
+		if count := stats.updates[key]; count != expected {
+			t.Errorf("expected %v updates of type %s on %s, got %v", expected, key.op, key.ns, count)
+		}
+	}
+
+	metric := &dto.Metric{}
+	stats.transactionStatements.Write(metric)
+	// The transaction over two entries and the prepared one, not the aborted one nor the applyOps
+	// outside of a transaction.
+	if count, sum := metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum(); count != 2 || sum != 4 {
+		t.Errorf("expected 2 transactions of 4 statements, got %v of %v", count, sum)
+	}
+	if len(stats.pendingTransactions) != 0 {
+		t.Errorf("expected no pending transaction, got %v", len(stats.pendingTransactions))
+	}
+}
+
+func Test_OplogTailPendingTransactions(t *testing.T) {
+	namespaces, _ := newOplogTailNamespaces(OplogTailOpts{})
+	stats := newOplogTailStats(namespaces)
+	// Transactions whose last entries were missed.
+	for i := 0; i < maxPendingTransactions; i++ {
+		stats.addPendingTransaction(strconv.Itoa(i), bson.MongoTimestamp(int64(1+i)<<32), 100, 1)
+	}
+
+	insert := bson.M{"op": "i", "ns": "app.orders", "o": bson.M{"_id": 1}}
+	entries := []bson.M{
+		transactionEntry(2000, 1, bson.D{{Name: "applyOps", Value: []bson.M{insert, insert}}, {Name: "partialTxn", Value: true}}),
+		transactionEntry(2001, 1, bson.D{{Name: "applyOps", Value: []bson.M{insert}}}),
+	}
+	for _, entry := range entries {
+		if _, err := stats.observeOplogEntry(rawDocument(t, entry)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metric := &dto.Metric{}
+	stats.transactionStatements.Write(metric)
+	if count, sum := metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum(); count != 1 || sum != 3 {
+		t.Errorf("expected a transaction of 3 statements, got %v of %v", count, sum)
+	}
+	if _, ok := stats.pendingTransactions["0"]; ok || len(stats.pendingTransactions) != maxPendingTransactions-1 {
+		t.Errorf("expected the oldest pending transaction to be dropped, got %v pending", len(stats.pendingTransactions))
+	}
+
+	stats.observeGap()
+	if len(stats.pendingTransactions) != 0 {
+		t.Errorf("expected a gap to drop the pending transactions, got %v", len(stats.pendingTransactions))
+	}
+}
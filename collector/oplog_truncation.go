@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	oplogTruncations = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "truncations_total"),
+		"The total number of times the oldest changes were truncated from the oplog.",
+		nil, nil,
+	)
+	oplogTruncationTime = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "truncation_time_seconds_total"),
+		"The total time spent truncating the oplog.",
+		nil, nil,
+	)
+	oplogTruncationStartupProcessing = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "replset_oplog", "truncation_startup_processing_seconds"),
+		"The time spent at startup finding the truncation points of the oplog, by method: scanning or sampling.",
+		[]string{"method"}, nil,
+	)
+)
+
+// OplogTruncation is the oplogTruncation section of serverStatus, new in version 4.0 with the
+// WiredTiger storage engine.
+type OplogTruncation struct {
+	TotalTimeProcessingMicros float64 `bson:"totalTimeProcessingMicros"`
+	ProcessingMethod          string  `bson:"processingMethod"`
+	TotalTimeTruncatingMicros float64 `bson:"totalTimeTruncatingMicros"`
+	TruncateCount             float64 `bson:"truncateCount"`
+}
+
+// Export exports the oplog truncation stats to be consumed by prometheus.
+func (truncation *OplogTruncation) Export(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(oplogTruncations, prometheus.CounterValue, truncation.TruncateCount)
+	ch <- prometheus.MustNewConstMetric(oplogTruncationTime, prometheus.CounterValue, truncation.TotalTimeTruncatingMicros/1e6)
+	if truncation.ProcessingMethod != "" {
+		ch <- prometheus.MustNewConstMetric(oplogTruncationStartupProcessing, prometheus.GaugeValue, truncation.TotalTimeProcessingMicros/1e6, truncation.ProcessingMethod)
+	}
+}
+
+// Describe describes the oplog truncation stats for prometheus.
+func (truncation *OplogTruncation) Describe(ch chan<- *prometheus.Desc) {
+	ch <- oplogTruncations
+	ch <- oplogTruncationTime
+	ch <- oplogTruncationStartupProcessing
+}
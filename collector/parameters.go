@@ -11,59 +11,46 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var (
-	metricMapping = map[string]prometheus.Gauge{}
-)
-
+// ParameterMetrics keeps the values of the setParameter options, by name.
 type ParameterMetrics struct {
+	Values map[string]float64
 }
 
 func (p *ParameterMetrics) Export(ch chan<- prometheus.Metric) {
-	for _, metric := range metricMapping {
-		metric.Collect(ch)
+	for parameter, value := range p.Values {
+		desc := prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "parameters", strings.ToLower(parameter)),
+			"A setParameter option in mongod",
+			nil, nil,
+		)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
 	}
 }
 
-func (p *ParameterMetrics) Describe(ch chan<- *prometheus.Desc) {
-	for _, metric := range metricMapping {
-		metric.Describe(ch)
-	}
-}
-
-func GetParameters(session *mgo.Session, parameters string) *ParameterMetrics {
+func GetParameters(session *mgo.Session, parameters string) (*ParameterMetrics, error) {
+	var lastErr error
+	metrics := &ParameterMetrics{Values: map[string]float64{}}
 	splitParameters := strings.Split(parameters, ",")
 	for _, parameter := range splitParameters {
-		if _, ok := metricMapping[parameter]; !ok {
-			metricMapping[parameter] = prometheus.NewGauge(prometheus.GaugeOpts{
-				Namespace: Namespace,
-				Subsystem: "parameters",
-				Name:      strings.ToLower(parameter),
-				Help:      "A setParameter option in mongod",
-			})
-		}
-		metric := metricMapping[parameter]
 		result := make(map[string]interface{})
-		err := session.DB("admin").Run(bson.D{{"getParameter", 1}, {parameter, 1}}, result)
+		err := session.DB("admin").Run(bson.D{{Name: "getParameter", Value: 1}, {Name: parameter, Value: 1}}, result)
 		if err != nil {
 			glog.Errorf("Failed to get parameter value for %v: %v", parameter, err)
+			lastErr = err
 			continue
 		}
 		if val, ok := result[parameter]; ok {
 			switch valTyped := val.(type) {
 			case int:
-				metric.Set(float64(valTyped))
+				metrics.Values[parameter] = float64(valTyped)
 			case int32:
-				metric.Set(float64(valTyped))
+				metrics.Values[parameter] = float64(valTyped)
 			case int64:
-				metric.Set(float64(valTyped))
+				metrics.Values[parameter] = float64(valTyped)
 			case float64:
-				metric.Set(valTyped)
+				metrics.Values[parameter] = valTyped
 			case bool:
-				var bit int8
-				if valTyped {
-					bit = 1
-				}
-				metric.Set(float64(bit))
+				metrics.Values[parameter] = boolToFloat64(valTyped)
 			default:
 				glog.Errorf("Unknown parameter value for %v: %v of type %v", parameter, valTyped, reflect.TypeOf(val))
 			}
@@ -71,5 +58,23 @@ func GetParameters(session *mgo.Session, parameters string) *ParameterMetrics {
 			glog.Errorf("Unexpected response from getParameter command: %v", result)
 		}
 	}
-	return &ParameterMetrics{}
+	return metrics, lastErr
+}
+
+func init() {
+	Register("parameter", newParameterCollector, true)
+}
+
+type parameterCollector struct {
+	parameters string
+}
+
+func newParameterCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &parameterCollector{parameters: opts.CollectParameters}, nil
+}
+
+func (c *parameterCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	parameterMetrics, err := GetParameters(session, c.parameters)
+	parameterMetrics.Export(ch)
+	return err
 }
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"fmt"
 	"time"
 
 	mgo "github.com/globalsign/mgo"
@@ -10,12 +11,11 @@ import (
 )
 
 var (
-	profileCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "profile",
-		Name:      "slow_query_30s_count",
-		Help:      "The number of slow queries in this database during last 30 seconds",
-	}, []string{"database"})
+	profileCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "profile", "slow_query_30s_count"),
+		"The number of slow queries in this database during last 30 seconds",
+		[]string{"database"}, nil,
+	)
 )
 
 type ProfileStatus struct {
@@ -24,22 +24,52 @@ type ProfileStatus struct {
 }
 
 func (profileStatus *ProfileStatus) Export(ch chan<- prometheus.Metric) {
-	profileCount.WithLabelValues(profileStatus.Name).Set(float64(profileStatus.Count))
-	profileCount.Collect(ch)
-	profileCount.Reset()
+	ch <- prometheus.MustNewConstMetric(profileCount, prometheus.GaugeValue, float64(profileStatus.Count), profileStatus.Name)
 }
 
 func (profileStatus *ProfileStatus) Describe(ch chan<- *prometheus.Desc) {
-	profileCount.Describe(ch)
+	ch <- profileCount
 }
 
-func CollectProfileStatus(session *mgo.Session, db string, ch chan<- prometheus.Metric) {
+func CollectProfileStatus(session *mgo.Session, db string, ch chan<- prometheus.Metric) error {
 	ts := time.Now().Add(-time.Duration(time.Second * 30))
 	count, err := session.DB(db).C("system.profile").Find(bson.M{"ts": bson.M{"$gt": ts}}).Count()
 	if err != nil {
-		glog.Error(err)
-		return
+		return err
 	}
 	profileStatus := ProfileStatus{db, count}
 	profileStatus.Export(ch)
+	return nil
+}
+
+func init() {
+	Register("profile", newProfileCollector, false)
+}
+
+type profileCollector struct{}
+
+func newProfileCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &profileCollector{}, nil
+}
+
+func (c *profileCollector) runsOnRouter() bool {
+	return false
+}
+
+func (c *profileCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	all, err := session.DatabaseNames()
+	if err != nil {
+		return fmt.Errorf("Failed to get database names: %s", err)
+	}
+	var lastErr error
+	for _, db := range all {
+		if db == "admin" || db == "test" {
+			continue
+		}
+		if err := CollectProfileStatus(session, db, ch); err != nil {
+			glog.Errorf("Failed to get profile status for db=%q: %s", db, err)
+			lastErr = err
+		}
+	}
+	return lastErr
 }
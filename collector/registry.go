@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/globalsign/mgo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a part of the mongodb metrics which is collected on its own, e.g. the server status
+// or the replica set status.
+type Collector interface {
+	// Update sends the metrics collected with the given session to the channel.
+	Update(session *mgo.Session, ch chan<- prometheus.Metric) error
+}
+
+// describer is implemented by the collectors which know their metrics upfront.
+type describer interface {
+	Describe(ch chan<- *prometheus.Desc)
+}
+
+// routerAware is implemented by the collectors which only work on either a mongod or a mongos
+// router, the others run on both.
+type routerAware interface {
+	// runsOnRouter tells whether the collector runs on mongos routers only, or on mongod only.
+	runsOnRouter() bool
+}
+
+// clusterWide is implemented by the router collectors whose metrics are the same from every router
+// of a sharded cluster, like the state of the balancer. The topology collector runs them on a
+// single router.
+type clusterWide interface {
+	clusterWide() bool
+}
+
+// background is implemented by the collectors which keep running between updates. They are
+// started and stopped with their MongodbCollector.
+type background interface {
+	start()
+	stop()
+}
+
+// Factory returns a new instance of a collector for the given options.
+type Factory func(opts MongodbCollectorOpts) (Collector, error)
+
+var (
+	registryLock      = sync.Mutex{}
+	factories         = make(map[string]Factory)
+	collectorsEnabled = make(map[string]*bool)
+)
+
+// Register makes a collector available under the given name. It adds the --collector.<name> and
+// --no-collector.<name> flags to enable and disable it, so it must be called before the flags are
+// parsed, usually from an init function.
+func Register(name string, factory Factory, defaultEnabled bool) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("collector %q is already registered", name))
+	}
+
+	enabled := defaultEnabled
+	factories[name] = factory
+	collectorsEnabled[name] = &enabled
+
+	flag.Var(&collectorFlag{enabled: &enabled, value: true}, "collector."+name, "Enable the "+name+" collector")
+	flag.Var(&collectorFlag{enabled: &enabled, value: false}, "no-collector."+name, "Disable the "+name+" collector")
+}
+
+// Collectors returns the names of all the registered collectors and whether they are enabled,
+// either by default or by their flags.
+func Collectors() map[string]bool {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	collectors := make(map[string]bool, len(collectorsEnabled))
+	for name, enabled := range collectorsEnabled {
+		collectors[name] = *enabled
+	}
+	return collectors
+}
+
+// EnabledCollectors returns the sorted names of the enabled collectors.
+func EnabledCollectors() []string {
+	var names []string
+	for name, enabled := range Collectors() {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newCollectors returns new instances of the given collectors, sorted by name.
+func newCollectors(names []string, opts MongodbCollectorOpts) (map[string]Collector, []string, error) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	collectors := make(map[string]Collector, len(names))
+	for _, name := range names {
+		factory, ok := factories[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown collector %q", name)
+		}
+		if _, ok := collectors[name]; ok {
+			continue
+		}
+		collector, err := factory(opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("collector %q: %s", name, err)
+		}
+		collectors[name] = collector
+	}
+
+	sorted := make([]string, 0, len(collectors))
+	for name := range collectors {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return collectors, sorted, nil
+}
+
+// collectorFlag is the value of both the --collector.<name> and --no-collector.<name> flags,
+// which share the same enabled state.
+type collectorFlag struct {
+	enabled *bool
+	// value is what the collector is set to when the flag is given.
+	value bool
+}
+
+func (f *collectorFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f *collectorFlag) String() string {
+	if f.enabled == nil {
+		return ""
+	}
+	return strconv.FormatBool(*f.enabled == f.value)
+}
+
+func (f *collectorFlag) Set(s string) error {
+	given, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*f.enabled = given == f.value
+	return nil
+}
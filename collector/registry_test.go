@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type nopCollector struct{}
+
+func (c *nopCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+func Test_CollectorFlag(t *testing.T) {
+	enabled := false
+	on := &collectorFlag{enabled: &enabled, value: true}
+	off := &collectorFlag{enabled: &enabled, value: false}
+
+	on.Set("true")
+	if !enabled {
+		t.Error("--collector.<name> should enable the collector")
+	}
+	off.Set("true")
+	if enabled {
+		t.Error("--no-collector.<name> should disable the collector")
+	}
+	off.Set("false")
+	if !enabled {
+		t.Error("--no-collector.<name>=false should enable the collector")
+	}
+	if err := on.Set("maybe"); err == nil {
+		t.Error("expected an error for a non boolean value")
+	}
+}
+
+func Test_Register(t *testing.T) {
+	Register("test_nop", func(opts MongodbCollectorOpts) (Collector, error) {
+		return &nopCollector{}, nil
+	}, false)
+
+	if enabled, ok := Collectors()["test_nop"]; !ok || enabled {
+		t.Errorf("expected test_nop to be registered and disabled, got %v %v", ok, enabled)
+	}
+
+	collectors, names, err := newCollectors([]string{"test_nop", "serverstatus"}, MongodbCollectorOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collectors) != 2 || names[0] != "serverstatus" || names[1] != "test_nop" {
+		t.Errorf("unexpected collectors %v", names)
+	}
+
+	if _, _, err := newCollectors([]string{"unknown"}, MongodbCollectorOpts{}); err == nil {
+		t.Error("expected an error for an unknown collector")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering test_nop twice to panic")
+		}
+	}()
+	Register("test_nop", nil, true)
+}
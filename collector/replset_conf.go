@@ -1,53 +1,92 @@
 package collector
 
 import (
-	"sync"
+	"fmt"
 
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/prometheus/pkg/labels"
 )
 
 var (
-	// Map to keep track of what members we have seen. With this we can remove
-	// metrics for members that are removed from the RS
-	members = make(map[uint64]prometheus.Labels)
-	// Lock for using these metrics
-	memberLock = sync.Mutex{}
-
-	memberHidden = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_hidden",
-		Help:      "This field conveys if the member is hidden (1) or not-hidden (0).",
-	}, []string{"id", "host"})
-	memberArbiter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_arbiter",
-		Help:      "This field conveys if the member is an arbiter (1) or not (0).",
-	}, []string{"id", "host"})
-	memberBuildIndexes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_build_indexes",
-		Help:      "This field conveys if the member is  builds indexes (1) or not (0).",
-	}, []string{"id", "host"})
-	memberPriority = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_priority",
-		Help:      "This field conveys the priority of a given member",
-	}, []string{"id", "host"})
-	memberVotes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_votes",
-		Help:      "This field conveys the number of votes of a given member",
-	}, []string{"id", "host"})
+	memberHidden = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_hidden"),
+		"This field conveys if the member is hidden (1) or not-hidden (0).",
+		[]string{"id", "host"}, nil,
+	)
+	memberArbiter = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_arbiter"),
+		"This field conveys if the member is an arbiter (1) or not (0).",
+		[]string{"id", "host"}, nil,
+	)
+	memberBuildIndexes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_build_indexes"),
+		"This field conveys if the member is  builds indexes (1) or not (0).",
+		[]string{"id", "host"}, nil,
+	)
+	memberPriority = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_priority"),
+		"This field conveys the priority of a given member",
+		[]string{"id", "host"}, nil,
+	)
+	memberVotes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_votes"),
+		"This field conveys the number of votes of a given member",
+		[]string{"id", "host"}, nil,
+	)
+	memberSecondaryDelay = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_secondary_delay_seconds"),
+		"How long a delayed member stays behind the primary, from secondaryDelaySecs or slaveDelay before MongoDB 5.0.",
+		[]string{"id", "host"}, nil,
+	)
+	memberTags = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_tags_info"),
+		"The tags of a given member, one series per tag.",
+		[]string{"id", "host", "tag", "value"}, nil,
+	)
+
+	settingsChainingAllowed = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "settings_chaining_allowed"),
+		"Whether secondaries may replicate from other secondaries.",
+		[]string{"set"}, nil,
+	)
+	settingsHeartbeatTimeout = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "settings_heartbeat_timeout_seconds"),
+		"How long the members wait for a heartbeat before they consider each other inaccessible.",
+		[]string{"set"}, nil,
+	)
+	settingsElectionTimeout = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "settings_election_timeout_seconds"),
+		"How long the members wait for the primary before they call an election.",
+		[]string{"set"}, nil,
+	)
+	settingsGetLastErrorDefaults = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "settings_get_last_error_defaults_info"),
+		"The default write concern of the replica set.",
+		[]string{"set", "w"}, nil,
+	)
+	settingsGetLastErrorDefaultsTimeout = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "settings_get_last_error_defaults_wtimeout_seconds"),
+		"The time limit of the default write concern of the replica set, 0 for none.",
+		[]string{"set"}, nil,
+	)
+
+	votingMembers = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "voting_members"),
+		"The number of configured members which vote in elections.",
+		[]string{"set"}, nil,
+	)
+	majorityVoteCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "majority_vote_count"),
+		"The number of votes needed to elect a primary.",
+		[]string{"set"}, nil,
+	)
+	faultTolerance = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "fault_tolerance"),
+		"How many more voting members can become unhealthy before no primary can be elected, negative when it is already the case.",
+		[]string{"set"}, nil,
+	)
 )
 
 // Although the docs say that it returns a map with id etc. it *actually* returns
@@ -58,28 +97,38 @@ type OuterReplSetConf struct {
 
 // ReplSetConf keeps the data returned by the GetReplSetConf method
 type ReplSetConf struct {
-	Id      string       `bson:"_id"`
-	Version int          `bson:"version"`
-	Members []MemberConf `bson:"members"`
+	Id       string              `bson:"_id"`
+	Version  int                 `bson:"version"`
+	Members  []MemberConf        `bson:"members"`
+	Settings ReplSetConfSettings `bson:"settings"`
 }
 
 /*
 Example:
-"settings" : {
-	"chainingAllowed" : true,
-	"heartbeatIntervalMillis" : 2000,
-	"heartbeatTimeoutSecs" : 10,
-	"electionTimeoutMillis" : 5000,
-	"getLastErrorModes" : {
-
-	},
-	"getLastErrorDefaults" : {
-		"w" : 1,
-		"wtimeout" : 0
+
+	"settings" : {
+		"chainingAllowed" : true,
+		"heartbeatIntervalMillis" : 2000,
+		"heartbeatTimeoutSecs" : 10,
+		"electionTimeoutMillis" : 5000,
+		"getLastErrorModes" : {
+
+		},
+		"getLastErrorDefaults" : {
+			"w" : 1,
+			"wtimeout" : 0
+		}
 	}
-}
 */
 type ReplSetConfSettings struct {
+	ChainingAllowed       *bool    `bson:"chainingAllowed"`
+	HeartbeatTimeoutSecs  *float64 `bson:"heartbeatTimeoutSecs"`
+	ElectionTimeoutMillis *float64 `bson:"electionTimeoutMillis"`
+	GetLastErrorDefaults  *struct {
+		// W is a number of members, "majority" or the name of a getLastErrorModes tag set.
+		W        interface{} `bson:"w"`
+		WTimeout float64     `bson:"wtimeout"`
+	} `bson:"getLastErrorDefaults"`
 }
 
 // Member represents an array element of ReplSetConf.Members
@@ -92,89 +141,109 @@ type MemberConf struct {
 	Priority     int32  `bson:"priority"`
 
 	Tags       map[string]string `bson:"tags"`
-	SlaveDelay float64           `bson:"saveDelay"`
-	Votes      int32             `bson:"votes"`
+	SlaveDelay float64           `bson:"slaveDelay"`
+	// SecondaryDelaySecs replaces SlaveDelay since MongoDB 5.0.
+	SecondaryDelaySecs float64 `bson:"secondaryDelaySecs"`
+	Votes              int32   `bson:"votes"`
+}
+
+// majority returns the number of voting members and how many votes a primary needs.
+func (replConf *ReplSetConf) majority() (voting int, majority int) {
+	for _, member := range replConf.Members {
+		if member.Votes > 0 {
+			voting++
+		}
+	}
+	return voting, voting/2 + 1
 }
 
 // Export exports the replSetGetStatus stati to be consumed by prometheus
 func (replConf *ReplSetConf) Export(ch chan<- prometheus.Metric) {
-	memberLock.Lock()
-	defer memberLock.Unlock()
-
-	// map to keep track of labelsets that we see in this pass
-	lsMap := make(map[uint64]struct{})
-
 	for _, member := range replConf.Members {
-		ls := prometheus.Labels{
-			"id":   replConf.Id,
-			"host": member.Host,
+		ch <- prometheus.MustNewConstMetric(memberHidden, prometheus.GaugeValue, boolToFloat64(member.Hidden), replConf.Id, member.Host)
+		ch <- prometheus.MustNewConstMetric(memberArbiter, prometheus.GaugeValue, boolToFloat64(member.ArbiterOnly), replConf.Id, member.Host)
+		ch <- prometheus.MustNewConstMetric(memberBuildIndexes, prometheus.GaugeValue, boolToFloat64(member.BuildIndexes), replConf.Id, member.Host)
+		ch <- prometheus.MustNewConstMetric(memberPriority, prometheus.GaugeValue, float64(member.Priority), replConf.Id, member.Host)
+		ch <- prometheus.MustNewConstMetric(memberVotes, prometheus.GaugeValue, float64(member.Votes), replConf.Id, member.Host)
+
+		delay := member.SecondaryDelaySecs
+		if delay == 0 {
+			delay = member.SlaveDelay
 		}
-		// Add the labelset to lsMap to keep track of what we have seen
-		lsHash := labels.FromMap(ls).Hash()
-		lsMap[lsHash] = struct{}{}
-		// If this is a new member, add it
-		if _, ok := members[lsHash]; !ok {
-			members[lsHash] = ls
-		}
-
-		if member.Hidden {
-			memberHidden.With(ls).Set(1)
-		} else {
-			memberHidden.With(ls).Set(0)
+		ch <- prometheus.MustNewConstMetric(memberSecondaryDelay, prometheus.GaugeValue, delay, replConf.Id, member.Host)
+		for tag, value := range member.Tags {
+			ch <- prometheus.MustNewConstMetric(memberTags, prometheus.GaugeValue, 1, replConf.Id, member.Host, tag, value)
 		}
+	}
 
-		if member.ArbiterOnly {
-			memberArbiter.With(ls).Set(1)
-		} else {
-			memberArbiter.With(ls).Set(0)
+	settings := replConf.Settings
+	if settings.ChainingAllowed != nil {
+		ch <- prometheus.MustNewConstMetric(settingsChainingAllowed, prometheus.GaugeValue, boolToFloat64(*settings.ChainingAllowed), replConf.Id)
+	}
+	if settings.HeartbeatTimeoutSecs != nil {
+		ch <- prometheus.MustNewConstMetric(settingsHeartbeatTimeout, prometheus.GaugeValue, *settings.HeartbeatTimeoutSecs, replConf.Id)
+	}
+	if settings.ElectionTimeoutMillis != nil {
+		ch <- prometheus.MustNewConstMetric(settingsElectionTimeout, prometheus.GaugeValue, *settings.ElectionTimeoutMillis/1000, replConf.Id)
+	}
+	if defaults := settings.GetLastErrorDefaults; defaults != nil {
+		w := "1"
+		if defaults.W != nil {
+			w = fmt.Sprint(defaults.W)
 		}
+		ch <- prometheus.MustNewConstMetric(settingsGetLastErrorDefaults, prometheus.GaugeValue, 1, replConf.Id, w)
+		ch <- prometheus.MustNewConstMetric(settingsGetLastErrorDefaultsTimeout, prometheus.GaugeValue, defaults.WTimeout/1000, replConf.Id)
+	}
 
-		if member.BuildIndexes {
-			memberBuildIndexes.With(ls).Set(1)
-		} else {
-			memberBuildIndexes.With(ls).Set(0)
-		}
+	voting, majority := replConf.majority()
+	ch <- prometheus.MustNewConstMetric(votingMembers, prometheus.GaugeValue, float64(voting), replConf.Id)
+	ch <- prometheus.MustNewConstMetric(majorityVoteCount, prometheus.GaugeValue, float64(majority), replConf.Id)
+}
 
-		memberPriority.With(ls).Set(float64(member.Priority))
-		memberVotes.With(ls).Set(float64(member.Votes))
+// ExportFaultTolerance exports how many more voting members can become unhealthy before the
+// majority is lost, according to the given status of the replica set.
+func (replConf *ReplSetConf) ExportFaultTolerance(status *ReplSetStatus, ch chan<- prometheus.Metric) {
+	healthy := make(map[string]bool, len(status.Members))
+	for _, member := range status.Members {
+		// The health of the member the status comes from is not reported.
+		healthy[member.Name] = member.Health == nil || *member.Health == 1
 	}
 
-	// Check if there are any members that have gone away that we should remove
-	for lsHash, ls := range members {
-		// If that labelset isn't there anymore -- remove it
-		if _, ok := lsMap[lsHash]; !ok {
-			memberHidden.Delete(ls)
-			memberArbiter.Delete(ls)
-			memberBuildIndexes.Delete(ls)
-			memberPriority.Delete(ls)
-			memberVotes.Delete(ls)
+	healthyVoting := 0
+	for _, member := range replConf.Members {
+		if member.Votes > 0 && healthy[member.Host] {
+			healthyVoting++
 		}
 	}
-
-	// collect metrics
-	memberHidden.Collect(ch)
-	memberArbiter.Collect(ch)
-	memberBuildIndexes.Collect(ch)
-	memberPriority.Collect(ch)
-	memberVotes.Collect(ch)
+	_, majority := replConf.majority()
+	ch <- prometheus.MustNewConstMetric(faultTolerance, prometheus.GaugeValue, float64(healthyVoting-majority), replConf.Id)
 }
 
 // Describe describes the replSetGetStatus metrics for prometheus
 func (replConf *ReplSetConf) Describe(ch chan<- *prometheus.Desc) {
-	memberHidden.Describe(ch)
-	memberArbiter.Describe(ch)
-	memberBuildIndexes.Describe(ch)
-	memberPriority.Describe(ch)
-	memberVotes.Describe(ch)
+	ch <- memberHidden
+	ch <- memberArbiter
+	ch <- memberBuildIndexes
+	ch <- memberPriority
+	ch <- memberVotes
+	ch <- memberSecondaryDelay
+	ch <- memberTags
+	ch <- settingsChainingAllowed
+	ch <- settingsHeartbeatTimeout
+	ch <- settingsElectionTimeout
+	ch <- settingsGetLastErrorDefaults
+	ch <- settingsGetLastErrorDefaultsTimeout
+	ch <- votingMembers
+	ch <- majorityVoteCount
+	ch <- faultTolerance
 }
 
 // GetReplSetConf returns the replica status info
-func GetReplSetConf(session *mgo.Session) *ReplSetConf {
+func GetReplSetConf(session *mgo.Session) (*ReplSetConf, error) {
 	result := &OuterReplSetConf{}
-	err := session.DB("admin").Run(bson.D{{"replSetGetConfig", 1}}, result)
+	err := session.DB("admin").Run(bson.D{{Name: "replSetGetConfig", Value: 1}}, result)
 	if err != nil {
-		glog.Errorf("Failed to get replSet config: %v", err)
-		return nil
+		return nil, err
 	}
-	return &result.Config
+	return &result.Config, nil
 }
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_ReplSetConf(t *testing.T) {
+	data, err := bson.Marshal(bson.M{
+		"_id": "rs0",
+		"members": []bson.M{
+			{"_id": 0, "host": "a:27017", "votes": 1, "tags": bson.M{"dc": "east"}},
+			{"_id": 1, "host": "b:27017", "votes": 1, "slaveDelay": int64(3600)},
+			{"_id": 2, "host": "c:27017", "votes": 1, "secondaryDelaySecs": int64(60)},
+			{"_id": 3, "host": "d:27017", "votes": 0},
+		},
+		"settings": bson.M{
+			"chainingAllowed":       false,
+			"electionTimeoutMillis": int64(10000),
+			"getLastErrorDefaults":  bson.M{"w": "majority", "wtimeout": 5000},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &ReplSetConf{}
+	if err := bson.Unmarshal(data, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.Members[1].SlaveDelay != 3600 || conf.Members[2].SecondaryDelaySecs != 60 {
+		t.Errorf("expected the delays to be decoded, got %+v", conf.Members)
+	}
+	if voting, majority := conf.majority(); voting != 3 || majority != 2 {
+		t.Errorf("expected 3 voting members and a majority of 2, got %d and %d", voting, majority)
+	}
+
+	checkGather(t, "replSetGetConfig", conf.Describe, conf.Export)
+
+	metrics := exportedConf(t, func(ch chan<- prometheus.Metric) { conf.Export(ch) })
+	if len(metrics[memberSecondaryDelay]) != 4 || metrics[memberSecondaryDelay][1].Gauge.GetValue() != 3600 || metrics[memberSecondaryDelay][2].Gauge.GetValue() != 60 {
+		t.Errorf("unexpected delays %v", metrics[memberSecondaryDelay])
+	}
+	if len(metrics[memberTags]) != 1 {
+		t.Errorf("expected a single tag, got %v", metrics[memberTags])
+	}
+	if value := metrics[settingsChainingAllowed][0].Gauge.GetValue(); value != 0 {
+		t.Errorf("expected chaining to be disallowed, got %f", value)
+	}
+	if value := metrics[settingsElectionTimeout][0].Gauge.GetValue(); value != 10 {
+		t.Errorf("expected an election timeout of 10s, got %f", value)
+	}
+	if label := metrics[settingsGetLastErrorDefaults][0].Label[1]; label.GetName() != "w" || label.GetValue() != "majority" {
+		t.Errorf("expected w=majority, got %v", label)
+	}
+	if len(metrics[settingsHeartbeatTimeout]) != 0 {
+		t.Errorf("expected no heartbeat timeout when it isn't set, got %v", metrics[settingsHeartbeatTimeout])
+	}
+
+	// c is down and d doesn't vote, so one more failure loses the majority.
+	down := int32(0)
+	status := &ReplSetStatus{Members: []Member{
+		{Name: "a:27017"},
+		{Name: "b:27017"},
+		{Name: "c:27017", Health: &down},
+		{Name: "d:27017"},
+	}}
+	metrics = exportedConf(t, func(ch chan<- prometheus.Metric) { conf.ExportFaultTolerance(status, ch) })
+	if value := metrics[faultTolerance][0].Gauge.GetValue(); value != 0 {
+		t.Errorf("expected a fault tolerance of 0, got %f", value)
+	}
+}
+
+func exportedConf(t *testing.T, export func(ch chan<- prometheus.Metric)) map[*prometheus.Desc][]*dto.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		export(ch)
+		close(ch)
+	}()
+
+	metrics := map[*prometheus.Desc][]*dto.Metric{}
+	for metric := range ch {
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatal(err)
+		}
+		metrics[metric.Desc()] = append(metrics[metric.Desc()], out)
+	}
+	return metrics
+}
@@ -1,120 +1,162 @@
 package collector
 
 import (
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
 	subsystem = "replset"
-	myState   = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "my_state",
-		Help:      "An integer between 0 and 10 that represents the replica state of the current member",
-	}, []string{"set"})
-
-	myReplicaLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "my_replica_lag",
-		Help:      "An integer shows the replication lag in seconds, -1 if no master found",
-	}, []string{"set"})
-
-	masterCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "master_count",
-		Help:      "The number of master, any value except 1 means something wrong",
-	}, []string{})
-
-	term = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "term",
-		Help:      "The election count for the replica set, as known to this replica set member",
-	}, []string{"set"})
-	numberOfMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "number_of_members",
-		Help:      "The number of replica set mebers",
-	}, []string{"set"})
-	heartbeatIntervalMillis = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "heartbeat_interval_millis",
-		Help:      "The frequency in milliseconds of the heartbeats",
-	}, []string{"set"})
-	memberHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_health",
-		Help:      "This field conveys if the member is up (1) or down (0).",
-	}, []string{"set", "name"})
-	memberState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_state",
-		Help:      "The value of state is an integer between 0 and 10 that represents the replica state of the member.",
-	}, []string{"set", "name"})
-	memberUptime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_uptime",
-		Help:      "The uptime field holds a value that reflects the number of seconds that this member has been online.",
-	}, []string{"set", "name"})
-	memberOptimeDate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_optime_date",
-		Help:      "The last entry from the oplog that this member applied.",
-	}, []string{"set", "name"})
-	memberElectionDate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_election_date",
-		Help:      "The timestamp the node was elected as replica leader",
-	}, []string{"set", "name"})
-	memberLastHeartbeat = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_last_heartbeat",
-		Help:      "The lastHeartbeat value provides an ISODate formatted date and time of the transmission time of last heartbeat received from this member",
-	}, []string{"set", "name"})
-	memberLastHeartbeatRecv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_last_heartbeat_recv",
-		Help:      "The lastHeartbeatRecv value provides an ISODate formatted date and time that the last heartbeat was received from this member",
-	}, []string{"set", "name"})
-	memberPingMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_ping_ms",
-		Help:      "The pingMs represents the number of milliseconds (ms) that a round-trip packet takes to travel between the remote member and the local instance.",
-	}, []string{"set", "name"})
-	memberConfigVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_config_version",
-		Help:      "The configVersion value is the replica set configuration version.",
-	}, []string{"set", "name"})
-	memberOptime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: subsystem,
-		Name:      "member_optime",
-		Help:      "Information regarding the last operation from the operation log that this member has applied.",
-	}, []string{"set", "name"})
-
-	// Lock for using these metrics
-	replsetStatsLock = sync.Mutex{}
+	myState   = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "my_state"),
+		"An integer between 0 and 10 that represents the replica state of the current member",
+		[]string{"set"}, nil,
+	)
+
+	myReplicaLag = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "my_replica_lag"),
+		"An integer shows the replication lag in seconds, -1 if no master found",
+		[]string{"set"}, nil,
+	)
+
+	masterCount = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "master_count"),
+		"The number of master, any value except 1 means something wrong",
+		[]string{}, nil,
+	)
+
+	term = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "term"),
+		"The election count for the replica set, as known to this replica set member",
+		[]string{"set"}, nil,
+	)
+	numberOfMembers = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "number_of_members"),
+		"The number of replica set mebers",
+		[]string{"set"}, nil,
+	)
+	heartbeatIntervalMillis = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "heartbeat_interval_millis"),
+		"The frequency in milliseconds of the heartbeats",
+		[]string{"set"}, nil,
+	)
+	memberHealth = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_health"),
+		"This field conveys if the member is up (1) or down (0).",
+		[]string{"set", "name"}, nil,
+	)
+	memberState = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_state"),
+		"The value of state is an integer between 0 and 10 that represents the replica state of the member.",
+		[]string{"set", "name"}, nil,
+	)
+	memberUptime = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_uptime"),
+		"The uptime field holds a value that reflects the number of seconds that this member has been online.",
+		[]string{"set", "name"}, nil,
+	)
+	memberOptimeDate = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_optime_date"),
+		"The last entry from the oplog that this member applied.",
+		[]string{"set", "name"}, nil,
+	)
+	memberElectionDate = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_election_date"),
+		"The timestamp the node was elected as replica leader",
+		[]string{"set", "name"}, nil,
+	)
+	memberLastHeartbeat = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_last_heartbeat"),
+		"The lastHeartbeat value provides an ISODate formatted date and time of the transmission time of last heartbeat received from this member",
+		[]string{"set", "name"}, nil,
+	)
+	memberLastHeartbeatRecv = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_last_heartbeat_recv"),
+		"The lastHeartbeatRecv value provides an ISODate formatted date and time that the last heartbeat was received from this member",
+		[]string{"set", "name"}, nil,
+	)
+	memberPingMs = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_ping_ms"),
+		"The pingMs represents the number of milliseconds (ms) that a round-trip packet takes to travel between the remote member and the local instance.",
+		[]string{"set", "name"}, nil,
+	)
+	memberConfigVersion = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_config_version"),
+		"The configVersion value is the replica set configuration version.",
+		[]string{"set", "name"}, nil,
+	)
+	memberOptime = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_optime"),
+		"Information regarding the last operation from the operation log that this member has applied.",
+		[]string{"set", "name"}, nil,
+	)
+	memberReplicationLag = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "member_replication_lag_seconds"),
+		"How far each secondary is behind the primary, from the wall time of their last applied operations when available, otherwise from their optimes.",
+		[]string{"set", "name"}, nil,
+	)
+
+	electionCandidateInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_info"),
+		"Why this member called the election that made it primary, new in version 4.2.",
+		[]string{"set", "reason"}, nil,
+	)
+	electionCandidateLastElection = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_last_election_timestamp_seconds"),
+		"When this member called the election that made it primary.",
+		[]string{"set"}, nil,
+	)
+	electionCandidateTerm = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_term"),
+		"The term of the election that made this member primary.",
+		[]string{"set"}, nil,
+	)
+	electionCandidateVotesNeeded = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_votes_needed"),
+		"The number of votes this member needed to win the election that made it primary.",
+		[]string{"set"}, nil,
+	)
+	electionCandidatePriority = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_priority"),
+		"The priority of this member when it called the election that made it primary.",
+		[]string{"set"}, nil,
+	)
+	electionCandidateCatchUpOps = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_catch_up_ops"),
+		"The number of operations this member applied to catch up after it was elected primary.",
+		[]string{"set"}, nil,
+	)
+	electionCandidateWriteAvailability = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_candidate_write_availability_seconds"),
+		"How long after the election this member, as primary, could acknowledge majority writes.",
+		[]string{"set"}, nil,
+	)
+	electionParticipantVoted = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_participant_voted_for_candidate"),
+		"Whether this member voted for the candidate of the last election it took part in, new in version 4.2.",
+		[]string{"set"}, nil,
+	)
+	electionParticipantTerm = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_participant_term"),
+		"The term of the last election this member took part in.",
+		[]string{"set"}, nil,
+	)
+	electionParticipantLastVote = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_participant_last_vote_timestamp_seconds"),
+		"When this member last voted.",
+		[]string{"set"}, nil,
+	)
+	electionParticipantPriority = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "election_participant_priority"),
+		"The priority of this member when it took part in the last election.",
+		[]string{"set"}, nil,
+	)
 )
 
 // ReplSetStatus keeps the data returned by the GetReplSetStatus method
@@ -125,6 +167,29 @@ type ReplSetStatus struct {
 	Term                    *int32    `bson:"term,omitempty"`
 	HeartbeatIntervalMillis *float64  `bson:"heartbeatIntervalMillis,omitempty"`
 	Members                 []Member  `bson:"members"`
+	// ElectionCandidateMetrics is only reported by a primary since version 4.2.
+	ElectionCandidateMetrics *ElectionCandidateMetrics `bson:"electionCandidateMetrics,omitempty"`
+	// ElectionParticipantMetrics is only reported by a secondary which voted, since version 4.2.
+	ElectionParticipantMetrics *ElectionParticipantMetrics `bson:"electionParticipantMetrics,omitempty"`
+}
+
+// ElectionCandidateMetrics describes the election which made the member primary.
+type ElectionCandidateMetrics struct {
+	LastElectionReason             string     `bson:"lastElectionReason"`
+	LastElectionDate               time.Time  `bson:"lastElectionDate"`
+	ElectionTerm                   float64    `bson:"electionTerm"`
+	NumVotesNeeded                 float64    `bson:"numVotesNeeded"`
+	PriorityAtElection             float64    `bson:"priorityAtElection"`
+	NumCatchUpOps                  *float64   `bson:"numCatchUpOps,omitempty"`
+	WMajorityWriteAvailabilityDate *time.Time `bson:"wMajorityWriteAvailabilityDate,omitempty"`
+}
+
+// ElectionParticipantMetrics describes the last election the member voted in.
+type ElectionParticipantMetrics struct {
+	VotedForCandidate  bool      `bson:"votedForCandidate"`
+	ElectionTerm       float64   `bson:"electionTerm"`
+	LastVoteDate       time.Time `bson:"lastVoteDate"`
+	PriorityAtElection float64   `bson:"priorityAtElection"`
 }
 
 // Member represents an array element of ReplSetStatus.Members
@@ -145,137 +210,282 @@ type Member struct {
 	PingMs               *float64    `bson:"pingMs,omitempty"`
 	SyncingTo            *string     `bson:"syncingTo,omitempty"`
 	ConfigVersion        *int32      `bson:"configVersion,omitempty"`
+	// LastAppliedWallTime is new in version 4.2
+	LastAppliedWallTime *time.Time `bson:"lastAppliedWallTime,omitempty"`
+}
+
+// optimeTimestamp returns the timestamp of the optime of the member, which is the timestamp itself
+// with protocolVersion 0 and {ts, t} with protocolVersion 1.
+func (member *Member) optimeTimestamp() (bson.MongoTimestamp, bool) {
+	switch optime := member.Optime.(type) {
+	case bson.MongoTimestamp:
+		return optime, true
+	case bson.M:
+		ts, ok := optime["ts"].(bson.MongoTimestamp)
+		return ts, ok
+	}
+	return 0, false
+}
+
+// replicationLag returns how far the member is behind the primary. The wall times of the last
+// applied operations have millisecond precision. The optimes only have second precision, as the
+// increment of their timestamps orders the operations within a second but doesn't tell when
+// they happened.
+func replicationLag(primary, member *Member) (float64, bool) {
+	if primary.LastAppliedWallTime != nil && member.LastAppliedWallTime != nil {
+		return primary.LastAppliedWallTime.Sub(*member.LastAppliedWallTime).Seconds(), true
+	}
+	primaryTs, ok := primary.optimeTimestamp()
+	if !ok {
+		return 0, false
+	}
+	memberTs, ok := member.optimeTimestamp()
+	if !ok {
+		return 0, false
+	}
+	return float64(int64(primaryTs>>32) - int64(memberTs>>32)), true
 }
 
 // Export exports the replSetGetStatus stati to be consumed by prometheus
 func (replStatus *ReplSetStatus) Export(ch chan<- prometheus.Metric) {
-	replsetStatsLock.Lock()
-	defer replsetStatsLock.Unlock()
-
-	myState.Reset()
-	myReplicaLag.Reset()
-	term.Reset()
-	numberOfMembers.Reset()
-	heartbeatIntervalMillis.Reset()
-	memberState.Reset()
-	memberHealth.Reset()
-	memberUptime.Reset()
-	memberOptimeDate.Reset()
-	memberElectionDate.Reset()
-	memberLastHeartbeat.Reset()
-	memberLastHeartbeatRecv.Reset()
-	memberPingMs.Reset()
-	memberConfigVersion.Reset()
-	masterCount.Reset()
-
-	myState.WithLabelValues(replStatus.Set).Set(float64(replStatus.MyState))
+	ch <- prometheus.MustNewConstMetric(myState, prometheus.GaugeValue, float64(replStatus.MyState), replStatus.Set)
 
 	// new in version 3.2
 	if replStatus.Term != nil {
-		term.WithLabelValues(replStatus.Set).Set(float64(*replStatus.Term))
+		ch <- prometheus.MustNewConstMetric(term, prometheus.GaugeValue, float64(*replStatus.Term), replStatus.Set)
 	}
-	numberOfMembers.WithLabelValues(replStatus.Set).Set(float64(len(replStatus.Members)))
+	ch <- prometheus.MustNewConstMetric(numberOfMembers, prometheus.GaugeValue, float64(len(replStatus.Members)), replStatus.Set)
 
 	// new in version 3.2
 	if replStatus.HeartbeatIntervalMillis != nil {
-		heartbeatIntervalMillis.WithLabelValues(replStatus.Set).Set(*replStatus.HeartbeatIntervalMillis)
+		ch <- prometheus.MustNewConstMetric(heartbeatIntervalMillis, prometheus.GaugeValue, *replStatus.HeartbeatIntervalMillis, replStatus.Set)
 	}
 
 	var (
 		primaryOpTime time.Time
 		myOpTime      time.Time
+		primary       *Member
 	)
 	mCount := 0
-	for _, member := range replStatus.Members {
-		ls := prometheus.Labels{
-			"set":  replStatus.Set,
-			"name": member.Name,
-		}
+	for i, member := range replStatus.Members {
 		if member.State == 1 {
 			primaryOpTime = member.OptimeDate
+			primary = &replStatus.Members[i]
 		}
 		if member.Self != nil && *member.Self {
 			myOpTime = member.OptimeDate
 		}
-		memberState.With(ls).Set(float64(member.State))
+		ch <- prometheus.MustNewConstMetric(memberState, prometheus.GaugeValue, float64(member.State), replStatus.Set, member.Name)
 		if member.State == 1 {
 			mCount += 1
 		}
 
 		// ReplSetStatus.Member.Health is not available on the node you're connected to
 		if member.Health != nil {
-			memberHealth.With(ls).Set(float64(*member.Health))
+			ch <- prometheus.MustNewConstMetric(memberHealth, prometheus.GaugeValue, float64(*member.Health), replStatus.Set, member.Name)
 		}
 
-		memberUptime.With(ls).Set(member.Uptime)
+		ch <- prometheus.MustNewConstMetric(memberUptime, prometheus.GaugeValue, member.Uptime, replStatus.Set, member.Name)
 
-		memberOptimeDate.With(ls).Set(float64(member.OptimeDate.Unix()))
+		ch <- prometheus.MustNewConstMetric(memberOptimeDate, prometheus.GaugeValue, float64(member.OptimeDate.Unix()), replStatus.Set, member.Name)
+		if ts, ok := member.optimeTimestamp(); ok {
+			ch <- prometheus.MustNewConstMetric(memberOptime, prometheus.GaugeValue, float64(ts>>32), replStatus.Set, member.Name)
+		}
 
 		// ReplSetGetStatus.Member.ElectionTime is only available on the PRIMARY
 		if member.ElectionDate != nil {
-			memberElectionDate.With(ls).Set(float64((*member.ElectionDate).Unix()))
+			ch <- prometheus.MustNewConstMetric(memberElectionDate, prometheus.GaugeValue, float64((*member.ElectionDate).Unix()), replStatus.Set, member.Name)
 		}
 		if member.LastHeartbeat != nil {
-			memberLastHeartbeat.With(ls).Set(float64((*member.LastHeartbeat).Unix()))
+			ch <- prometheus.MustNewConstMetric(memberLastHeartbeat, prometheus.GaugeValue, float64((*member.LastHeartbeat).Unix()), replStatus.Set, member.Name)
 		}
 		if member.LastHeartbeatRecv != nil {
-			memberLastHeartbeatRecv.With(ls).Set(float64((*member.LastHeartbeatRecv).Unix()))
+			ch <- prometheus.MustNewConstMetric(memberLastHeartbeatRecv, prometheus.GaugeValue, float64((*member.LastHeartbeatRecv).Unix()), replStatus.Set, member.Name)
 		}
 		if member.PingMs != nil {
-			memberPingMs.With(ls).Set(*member.PingMs)
+			ch <- prometheus.MustNewConstMetric(memberPingMs, prometheus.GaugeValue, *member.PingMs, replStatus.Set, member.Name)
 		}
 		if member.ConfigVersion != nil {
-			memberConfigVersion.With(ls).Set(float64(*member.ConfigVersion))
+			ch <- prometheus.MustNewConstMetric(memberConfigVersion, prometheus.GaugeValue, float64(*member.ConfigVersion), replStatus.Set, member.Name)
+		}
+	}
+	if primary != nil {
+		for i, member := range replStatus.Members {
+			// Arbiters don't replicate.
+			if member.State == 1 || member.State == 7 {
+				continue
+			}
+			if lag, ok := replicationLag(primary, &replStatus.Members[i]); ok {
+				ch <- prometheus.MustNewConstMetric(memberReplicationLag, prometheus.GaugeValue, lag, replStatus.Set, member.Name)
+			}
 		}
 	}
 	if !primaryOpTime.IsZero() && !myOpTime.IsZero() {
-		myReplicaLag.WithLabelValues(replStatus.Set).Set(float64(primaryOpTime.Unix() - myOpTime.Unix()))
+		ch <- prometheus.MustNewConstMetric(myReplicaLag, prometheus.GaugeValue, float64(primaryOpTime.Unix()-myOpTime.Unix()), replStatus.Set)
 	} else {
-		myReplicaLag.WithLabelValues(replStatus.Set).Set(-1.0)
+		ch <- prometheus.MustNewConstMetric(myReplicaLag, prometheus.GaugeValue, -1.0, replStatus.Set)
+	}
+	ch <- prometheus.MustNewConstMetric(masterCount, prometheus.GaugeValue, float64(mCount))
+
+	if candidate := replStatus.ElectionCandidateMetrics; candidate != nil {
+		ch <- prometheus.MustNewConstMetric(electionCandidateInfo, prometheus.GaugeValue, 1, replStatus.Set, candidate.LastElectionReason)
+		ch <- prometheus.MustNewConstMetric(electionCandidateLastElection, prometheus.GaugeValue, float64(candidate.LastElectionDate.Unix()), replStatus.Set)
+		ch <- prometheus.MustNewConstMetric(electionCandidateTerm, prometheus.GaugeValue, candidate.ElectionTerm, replStatus.Set)
+		ch <- prometheus.MustNewConstMetric(electionCandidateVotesNeeded, prometheus.GaugeValue, candidate.NumVotesNeeded, replStatus.Set)
+		ch <- prometheus.MustNewConstMetric(electionCandidatePriority, prometheus.GaugeValue, candidate.PriorityAtElection, replStatus.Set)
+		// The catch up and the majority write availability are only reported once they happened.
+		if candidate.NumCatchUpOps != nil {
+			ch <- prometheus.MustNewConstMetric(electionCandidateCatchUpOps, prometheus.GaugeValue, *candidate.NumCatchUpOps, replStatus.Set)
+		}
+		if candidate.WMajorityWriteAvailabilityDate != nil {
+			ch <- prometheus.MustNewConstMetric(electionCandidateWriteAvailability, prometheus.GaugeValue, candidate.WMajorityWriteAvailabilityDate.Sub(candidate.LastElectionDate).Seconds(), replStatus.Set)
+		}
+	}
+	if participant := replStatus.ElectionParticipantMetrics; participant != nil {
+		ch <- prometheus.MustNewConstMetric(electionParticipantVoted, prometheus.GaugeValue, boolToFloat64(participant.VotedForCandidate), replStatus.Set)
+		ch <- prometheus.MustNewConstMetric(electionParticipantTerm, prometheus.GaugeValue, participant.ElectionTerm, replStatus.Set)
+		ch <- prometheus.MustNewConstMetric(electionParticipantLastVote, prometheus.GaugeValue, float64(participant.LastVoteDate.Unix()), replStatus.Set)
+		ch <- prometheus.MustNewConstMetric(electionParticipantPriority, prometheus.GaugeValue, participant.PriorityAtElection, replStatus.Set)
 	}
-	masterCount.WithLabelValues().Set(float64(mCount))
-	// collect metrics
-	myState.Collect(ch)
-	myReplicaLag.Collect(ch)
-	term.Collect(ch)
-	numberOfMembers.Collect(ch)
-	heartbeatIntervalMillis.Collect(ch)
-	memberState.Collect(ch)
-	masterCount.Collect(ch)
-	memberHealth.Collect(ch)
-	memberUptime.Collect(ch)
-	memberOptimeDate.Collect(ch)
-	memberElectionDate.Collect(ch)
-	memberLastHeartbeat.Collect(ch)
-	memberLastHeartbeatRecv.Collect(ch)
-	memberPingMs.Collect(ch)
-	memberConfigVersion.Collect(ch)
 }
 
 // Describe describes the replSetGetStatus metrics for prometheus
 func (replStatus *ReplSetStatus) Describe(ch chan<- *prometheus.Desc) {
-	myState.Describe(ch)
-	myReplicaLag.Describe(ch)
-	term.Describe(ch)
-	numberOfMembers.Describe(ch)
-	heartbeatIntervalMillis.Describe(ch)
-	memberState.Describe(ch)
-	memberHealth.Describe(ch)
-	memberUptime.Describe(ch)
-	memberOptimeDate.Describe(ch)
-	memberElectionDate.Describe(ch)
-	memberLastHeartbeatRecv.Describe(ch)
-	memberPingMs.Describe(ch)
-	memberConfigVersion.Describe(ch)
+	ch <- myState
+	ch <- myReplicaLag
+	ch <- term
+	ch <- numberOfMembers
+	ch <- heartbeatIntervalMillis
+	ch <- memberState
+	ch <- memberHealth
+	ch <- memberUptime
+	ch <- memberOptimeDate
+	ch <- memberOptime
+	ch <- memberReplicationLag
+	ch <- memberElectionDate
+	ch <- memberLastHeartbeat
+	ch <- memberLastHeartbeatRecv
+	ch <- memberPingMs
+	ch <- memberConfigVersion
+	ch <- masterCount
+	ch <- electionCandidateInfo
+	ch <- electionCandidateLastElection
+	ch <- electionCandidateTerm
+	ch <- electionCandidateVotesNeeded
+	ch <- electionCandidatePriority
+	ch <- electionCandidateCatchUpOps
+	ch <- electionCandidateWriteAvailability
+	ch <- electionParticipantVoted
+	ch <- electionParticipantTerm
+	ch <- electionParticipantLastVote
+	ch <- electionParticipantPriority
 }
 
 // GetReplSetStatus returns the replica status info
-func GetReplSetStatus(session *mgo.Session) *ReplSetStatus {
+func GetReplSetStatus(session *mgo.Session) (*ReplSetStatus, error) {
 	result := &ReplSetStatus{}
-	err := session.DB("admin").Run(bson.D{{"replSetGetStatus", 1}}, result)
+	err := session.DB("admin").Run(bson.D{{Name: "replSetGetStatus", Value: 1}}, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func init() {
+	Register("replset", newReplSetCollector, true)
+}
+
+// replSetCollector collects both the replica set status and its configuration. It remembers the
+// primary and the state of every member between updates to count the changes.
+type replSetCollector struct {
+	lock sync.Mutex
+	// primaries are the last primary seen of each replica set.
+	primaries map[string]string
+	// states are the last state seen of each member, by replica set and name.
+	states map[string]map[string]string
+
+	primaryChanges   *prometheus.CounterVec
+	stateTransitions *prometheus.CounterVec
+}
+
+func newReplSetCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &replSetCollector{
+		primaries: make(map[string]string),
+		states:    make(map[string]map[string]string),
+		primaryChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "primary_changes_total",
+			Help:      "The total number of times another member became primary since the exporter started, as seen by this member.",
+		}, []string{"set"}),
+		stateTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "member_state_transitions_total",
+			Help:      "The total number of state changes of each member since the exporter started, as seen by this member.",
+		}, []string{"set", "name", "from", "to"}),
+	}, nil
+}
+
+func (c *replSetCollector) runsOnRouter() bool {
+	return false
+}
+
+func (c *replSetCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	replSetStatus, err := GetReplSetStatus(session)
 	if err != nil {
-		glog.Errorf("Failed to get replSet status: %v", err)
-		return nil
+		return err
+	}
+	replSetStatus.Export(ch)
+	c.observe(replSetStatus)
+	c.primaryChanges.Collect(ch)
+	c.stateTransitions.Collect(ch)
+
+	replSetConf, err := GetReplSetConf(session)
+	if err != nil {
+		return err
+	}
+	replSetConf.Export(ch)
+	replSetConf.ExportFaultTolerance(replSetStatus, ch)
+	return nil
+}
+
+// observe counts the changes of primary and of member states since the previous status. A member
+// which was primary changes to no primary until another one is elected, that only counts as a
+// primary change when the new primary is another member.
+func (c *replSetCollector) observe(status *ReplSetStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Export the counter of every replica set even before its first change.
+	c.primaryChanges.WithLabelValues(status.Set)
+
+	previous := c.states[status.Set]
+	states := make(map[string]string, len(status.Members))
+	for _, member := range status.Members {
+		state := member.StateStr
+		if state == "" {
+			state = strconv.Itoa(int(member.State))
+		}
+		states[member.Name] = state
+		if from, ok := previous[member.Name]; ok && from != state {
+			c.stateTransitions.WithLabelValues(status.Set, member.Name, from, state).Inc()
+		}
+
+		if member.State != 1 {
+			continue
+		}
+		if last := c.primaries[status.Set]; last != "" && last != member.Name {
+			c.primaryChanges.WithLabelValues(status.Set).Inc()
+		}
+		c.primaries[status.Set] = member.Name
 	}
-	return result
+	c.states[status.Set] = states
+}
+
+func (c *replSetCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.primaryChanges.Describe(ch)
+	c.stateTransitions.Describe(ch)
+	(&ReplSetStatus{}).Describe(ch)
+	(&ReplSetConf{}).Describe(ch)
 }
@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_ReplicationLag(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := func(seconds, increment int64) bson.MongoTimestamp {
+		return bson.MongoTimestamp(seconds<<32 | increment)
+	}
+
+	data, err := bson.Marshal(bson.M{
+		"set": "rs0",
+		"members": []bson.M{
+			// protocolVersion 1
+			{"name": "a:27017", "state": 1, "optime": bson.M{"ts": ts(1000, 5), "t": int64(3)}},
+			{"name": "b:27017", "state": 2, "optime": bson.M{"ts": ts(990, 1), "t": int64(3)}},
+			// protocolVersion 0
+			{"name": "c:27017", "state": 2, "optime": ts(997, 2)},
+			{"name": "d:27017", "state": 7},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := &ReplSetStatus{}
+	if err := bson.Unmarshal(data, status); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGather(t, "replSetGetStatus", status.Describe, status.Export)
+
+	lags := exportedLags(t, status)
+	expected := map[string]float64{"b:27017": 10, "c:27017": 3}
+	if len(lags) != len(expected) || lags["b:27017"] != 10 || lags["c:27017"] != 3 {
+		t.Errorf("expected lags %v, got %v", expected, lags)
+	}
+
+	// The wall times are preferred, with millisecond precision.
+	primaryWallTime, secondaryWallTime := now, now.Add(-1500*time.Millisecond)
+	status.Members[0].LastAppliedWallTime = &primaryWallTime
+	status.Members[1].LastAppliedWallTime = &secondaryWallTime
+	if lag := exportedLags(t, status)["b:27017"]; lag != 1.5 {
+		t.Errorf("expected a lag of 1.5s from the wall times, got %f", lag)
+	}
+}
+
+func exportedLags(t *testing.T, status *ReplSetStatus) map[string]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		status.Export(ch)
+		close(ch)
+	}()
+
+	lags := map[string]float64{}
+	for metric := range ch {
+		if metric.Desc() != memberReplicationLag {
+			continue
+		}
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range out.Label {
+			if label.GetName() == "name" {
+				lags[label.GetValue()] = out.Gauge.GetValue()
+			}
+		}
+	}
+	return lags
+}
+
+func Test_ReplSetObserve(t *testing.T) {
+	collector, err := newReplSetCollector(MongodbCollectorOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := collector.(*replSetCollector)
+
+	statuses := [][]Member{
+		{{Name: "a", State: 1, StateStr: "PRIMARY"}, {Name: "b", State: 2, StateStr: "SECONDARY"}},
+		// a steps down, then b is elected.
+		{{Name: "a", State: 2, StateStr: "SECONDARY"}, {Name: "b", State: 2, StateStr: "SECONDARY"}},
+		{{Name: "a", State: 2, StateStr: "SECONDARY"}, {Name: "b", State: 1, StateStr: "PRIMARY"}},
+		// b goes down and comes back as primary.
+		{{Name: "a", State: 2, StateStr: "SECONDARY"}, {Name: "b", State: 8, StateStr: "(not reachable/healthy)"}},
+		{{Name: "a", State: 2, StateStr: "SECONDARY"}, {Name: "b", State: 1, StateStr: "PRIMARY"}},
+	}
+	for _, members := range statuses {
+		c.observe(&ReplSetStatus{Set: "rs0", Members: members})
+	}
+
+	if changes := replSetCounterValue(t, c.primaryChanges.WithLabelValues("rs0")); changes != 1 {
+		t.Errorf("expected a single primary change, got %f", changes)
+	}
+	transitions := map[[3]string]float64{
+		{"a", "PRIMARY", "SECONDARY"}:               1,
+		{"b", "SECONDARY", "PRIMARY"}:               1,
+		{"b", "PRIMARY", "(not reachable/healthy)"}: 1,
+		{"b", "(not reachable/healthy)", "PRIMARY"}: 1,
+	}
+	for labels, expected := range transitions {
+		if count := replSetCounterValue(t, c.stateTransitions.WithLabelValues("rs0", labels[0], labels[1], labels[2])); count != expected {
+			t.Errorf("expected %f transitions of %v, got %f", expected, labels, count)
+		}
+	}
+}
+
+func Test_ElectionCandidateMetrics(t *testing.T) {
+	elected := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	available := elected.Add(2 * time.Second)
+	status := &ReplSetStatus{
+		Set: "rs0",
+		ElectionCandidateMetrics: &ElectionCandidateMetrics{
+			LastElectionReason:             "electionTimeout",
+			LastElectionDate:               elected,
+			WMajorityWriteAvailabilityDate: &available,
+		},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		status.Export(ch)
+		close(ch)
+	}()
+	found := false
+	for metric := range ch {
+		if metric.Desc() != electionCandidateWriteAvailability {
+			continue
+		}
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatal(err)
+		}
+		found = true
+		if value := out.Gauge.GetValue(); value != 2 {
+			t.Errorf("expected majority writes to be available 2s after the election, got %f", value)
+		}
+	}
+	if !found {
+		t.Error("expected the write availability to be exported")
+	}
+}
+
+func replSetCounterValue(t *testing.T, counter prometheus.Counter) float64 {
+	out := &dto.Metric{}
+	if err := counter.Write(out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Counter.GetValue()
+}
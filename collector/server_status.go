@@ -1,35 +1,76 @@
 package collector
 
 import (
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	instanceUptimeSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "instance",
-		Name:      "uptime_seconds",
-		Help:      "The value of the uptime field corresponds to the number of seconds that the mongos or mongod process has been active.",
-	})
-	instanceUptimeEstimateSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "instance",
-		Name:      "uptime_estimate_seconds",
-		Help:      "uptimeEstimate provides the uptime as calculated from MongoDB's internal course-grained time keeping system.",
-	})
-	instanceLocalTime = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "instance",
-		Name:      "local_time",
-		Help:      "The localTime value is the current time, according to the server, in UTC specified in an ISODate format.",
-	})
+	instanceUptimeSeconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "instance", "uptime_seconds"),
+		"The value of the uptime field corresponds to the number of seconds that the mongos or mongod process has been active.",
+		nil, nil,
+	)
+	instanceUptimeEstimateSeconds = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "instance", "uptime_estimate_seconds"),
+		"uptimeEstimate provides the uptime as calculated from MongoDB's internal course-grained time keeping system.",
+		nil, nil,
+	)
+	instanceLocalTime = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "instance", "local_time"),
+		"The localTime value is the current time, according to the server, in UTC specified in an ISODate format.",
+		nil, nil,
+	)
 )
 
+// serverStatusGroups maps the groups given by --groups.enabled to the serverStatus sections they
+// export. The uptime and local time are always exported.
+var serverStatusGroups = map[string][]string{
+	"asserts":             {"asserts"},
+	"durability":          {"dur"},
+	"background_flushing": {"backgroundFlushing"},
+	"connections":         {"connections"},
+	"extra_info":          {"extra_info"},
+	"session_cache":       {"logicalSessionRecordCache"},
+	"global_lock":         {"globalLock"},
+	"index_counters":      {"indexCounters"},
+	"locks":               {"locks"},
+	"network":             {"network"},
+	"op_counters":         {"opcounters"},
+	"op_counters_repl":    {"opcountersRepl"},
+	"tcmalloc":            {"tcmalloc"},
+	"memory":              {"mem"},
+	"metrics":             {"metrics"},
+	"cursors":             {"cursors"},
+	"sharding":            {"sharding", "shardingStatistics"},
+	"storage_engine":      {"storageEngine"},
+	"wiredtiger":          {"wiredTiger"},
+	"election_metrics":    {"electionMetrics"},
+	"oplog_truncation":    {"oplogTruncation"},
+}
+
+// DefaultEnabledGroups is the default of --groups.enabled, all the groups of serverStatusGroups.
+const DefaultEnabledGroups = "asserts,durability,background_flushing,connections,extra_info,session_cache,global_lock,index_counters,network,op_counters,op_counters_repl,tcmalloc,memory,locks,metrics,cursors,sharding,storage_engine,wiredtiger,election_metrics,oplog_truncation"
+
+// disabledServerStatusSections returns the sorted serverStatus sections of the groups which are
+// not enabled.
+func disabledServerStatusSections(enabledGroups map[string]bool) []string {
+	var sections []string
+	for group, groupSections := range serverStatusGroups {
+		if !enabledGroups[group] {
+			sections = append(sections, groupSections...)
+		}
+	}
+	sort.Strings(sections)
+	return sections
+}
+
 // ServerStatus keeps the data returned by the serverStatus() method.
 type ServerStatus struct {
 	Uptime         float64   `bson:"uptime"`
@@ -68,16 +109,16 @@ type ServerStatus struct {
 
 	StorageEngine *StorageEngineStats `bson:"storageEngine"`
 	WiredTiger    *WiredTigerStats    `bson:"wiredTiger"`
+
+	ElectionMetrics *ElectionMetrics `bson:"electionMetrics"`
+	OplogTruncation *OplogTruncation `bson:"oplogTruncation"`
 }
 
 // Export exports the server status to be consumed by prometheus.
 func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
-	instanceUptimeSeconds.Set(status.Uptime)
-	instanceUptimeEstimateSeconds.Set(status.Uptime)
-	instanceLocalTime.Set(float64(status.LocalTime.Unix()))
-	instanceUptimeSeconds.Collect(ch)
-	instanceUptimeEstimateSeconds.Collect(ch)
-	instanceLocalTime.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(instanceUptimeSeconds, prometheus.GaugeValue, status.Uptime)
+	ch <- prometheus.MustNewConstMetric(instanceUptimeEstimateSeconds, prometheus.GaugeValue, status.Uptime)
+	ch <- prometheus.MustNewConstMetric(instanceLocalTime, prometheus.GaugeValue, float64(status.LocalTime.Unix()))
 
 	if status.Asserts != nil {
 		status.Asserts.Export(ch)
@@ -136,23 +177,30 @@ func (status *ServerStatus) Export(ch chan<- prometheus.Metric) {
 	if status.WiredTiger != nil {
 		status.WiredTiger.Export(ch)
 	}
+	if status.ElectionMetrics != nil {
+		status.ElectionMetrics.Export(ch)
+	}
+	if status.OplogTruncation != nil {
+		status.OplogTruncation.Export(ch)
+	}
 	// If db.serverStatus().storageEngine does not exist (3.0+ only) and status.BackgroundFlushing does (MMAPv1 only), default to mmapv1
 	// https://docs.mongodb.com/v3.0/reference/command/serverStatus/#storageengine
-	if status.StorageEngine == nil && status.BackgroundFlushing != nil {
-		status.StorageEngine = &StorageEngineStats{
+	storageEngine := status.StorageEngine
+	if storageEngine == nil && status.BackgroundFlushing != nil {
+		storageEngine = &StorageEngineStats{
 			Name: "mmapv1",
 		}
 	}
-	if status.StorageEngine != nil {
-		status.StorageEngine.Export(ch)
+	if storageEngine != nil {
+		storageEngine.Export(ch)
 	}
 }
 
 // Describe describes the server status for prometheus.
 func (status *ServerStatus) Describe(ch chan<- *prometheus.Desc) {
-	instanceUptimeSeconds.Describe(ch)
-	instanceUptimeEstimateSeconds.Describe(ch)
-	instanceLocalTime.Describe(ch)
+	ch <- instanceUptimeSeconds
+	ch <- instanceUptimeEstimateSeconds
+	ch <- instanceLocalTime
 
 	if status.Asserts != nil {
 		status.Asserts.Describe(ch)
@@ -211,18 +259,122 @@ func (status *ServerStatus) Describe(ch chan<- *prometheus.Desc) {
 	if status.WiredTiger != nil {
 		status.WiredTiger.Describe(ch)
 	}
-	if status.StorageEngine != nil {
-		status.StorageEngine.Describe(ch)
+	if status.ElectionMetrics != nil {
+		status.ElectionMetrics.Describe(ch)
+	}
+	if status.OplogTruncation != nil {
+		status.OplogTruncation.Describe(ch)
+	}
+	if status.StorageEngine != nil || status.BackgroundFlushing != nil {
+		(&StorageEngineStats{}).Describe(ch)
+	}
+}
+
+// dropSections clears the given sections, so that they are not exported even when the server
+// returned them anyway.
+func (status *ServerStatus) dropSections(sections []string) {
+	drop := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		drop[section] = true
+	}
+
+	value := reflect.ValueOf(status).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("bson"), ",")[0]
+		if drop[name] {
+			field := value.Field(i)
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+}
+
+// serverStatusCommand returns the serverStatus command excluding the given sections, so that
+// mongod doesn't even compute them.
+func serverStatusCommand(excludedSections []string, maxTimeMS int64) bson.D {
+	command := bson.D{{Name: "serverStatus", Value: 1}, {Name: "recordStats", Value: 0}}
+	for _, section := range excludedSections {
+		command = append(command, bson.DocElem{Name: section, Value: 0})
+	}
+	return append(command, bson.DocElem{Name: "maxTimeMS", Value: maxTimeMS})
+}
+
+// getServerStatusRaw runs serverStatus without the sections of the disabled groups and returns
+// the undecoded result, so that it can be decoded both into a ServerStatus and a document.
+func getServerStatusRaw(session *mgo.Session, enabledGroups map[string]bool, maxTimeMS int64) (*bson.Raw, error) {
+	result := &bson.Raw{}
+	err := session.DB("admin").Run(serverStatusCommand(disabledServerStatusSections(enabledGroups), maxTimeMS), result)
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
 }
 
-// GetServerStatus returns the server status info.
-func GetServerStatus(session *mgo.Session, maxTimeMS int64) *ServerStatus {
+func decodeServerStatus(raw *bson.Raw, enabledGroups map[string]bool) (*ServerStatus, error) {
 	result := &ServerStatus{}
-	err := session.DB("admin").Run(bson.D{{"serverStatus", 1}, {"recordStats", 0}, {"maxTimeMS", maxTimeMS}}, result)
+	if err := raw.Unmarshal(result); err != nil {
+		return nil, err
+	}
+	result.dropSections(disabledServerStatusSections(enabledGroups))
+	return result, nil
+}
+
+func init() {
+	Register("serverstatus", newServerStatusCollector, true)
+}
+
+type serverStatusCollector struct {
+	enabledGroups map[string]bool
+	definitions   *MetricDefinitions
+	discover      bool
+	maxTimeMS     int64
+	durability    *durabilitySummaries
+}
+
+func newServerStatusCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &serverStatusCollector{
+		enabledGroups: opts.enabledGroups(),
+		definitions:   opts.MetricDefinitions,
+		discover:      opts.DiscoverServerStatus,
+		maxTimeMS:     opts.MaxTimeMS,
+		durability:    newDurabilitySummaries(),
+	}, nil
+}
+
+func (c *serverStatusCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
+	raw, err := getServerStatusRaw(session, c.enabledGroups, c.maxTimeMS)
 	if err != nil {
-		glog.Error("Failed to get server status.")
+		return err
+	}
+	serverStatus, err := decodeServerStatus(raw, c.enabledGroups)
+	if err != nil {
+		return err
+	}
+	serverStatus.Export(ch)
+	if serverStatus.Dur != nil {
+		c.durability.observe(serverStatus.Dur)
+		c.durability.Collect(ch)
+	}
+
+	if c.definitions == nil && !c.discover {
 		return nil
 	}
-	return result
+	doc := bson.M{}
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	if c.definitions != nil {
+		c.definitions.Export(doc, ch)
+	}
+	if c.discover {
+		discoverServerStatus(doc, ch)
+	}
+	return nil
+}
+
+func (c *serverStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&ServerStatus{}).Describe(ch)
+	c.durability.Describe(ch)
+	if c.definitions != nil {
+		c.definitions.Describe(ch)
+	}
 }
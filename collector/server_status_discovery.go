@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dcu/mongodb_exporter/shared"
+	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// curatedServerStatusPaths are the serverStatus paths decoded into the ServerStatus struct, which
+// are not discovered again.
+var curatedServerStatusPaths = curatedPaths(reflect.TypeOf(ServerStatus{}), "")
+
+// curatedPaths returns the dot separated bson paths of the fields of the given struct type. Nested
+// structs are walked, any other field covers its whole subtree.
+func curatedPaths(t reflect.Type, prefix string) map[string]bool {
+	paths := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+			for nested := range curatedPaths(fieldType, path+".") {
+				paths[nested] = true
+			}
+			continue
+		}
+		paths[path] = true
+	}
+	return paths
+}
+
+// discoverServerStatus exports every numeric and boolean value of serverStatus which is not
+// exported by the curated structs, named after its path, e.g. mongodb_ss_flow_control_enabled.
+func discoverServerStatus(serverStatus bson.M, ch chan<- prometheus.Metric) {
+	seen := map[string]bool{}
+	discoverDocument(serverStatus, nil, seen, ch)
+}
+
+func discoverDocument(doc bson.M, path []string, seen map[string]bool, ch chan<- prometheus.Metric) {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if len(path) == 0 && (key == "ok" || strings.HasPrefix(key, "$")) {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), key)
+		dottedPath := strings.Join(fieldPath, ".")
+		if curatedServerStatusPaths[dottedPath] {
+			continue
+		}
+
+		if nested, ok := doc[key].(bson.M); ok {
+			discoverDocument(nested, fieldPath, seen, ch)
+			continue
+		}
+		value, ok := metricValue(doc[key])
+		if !ok {
+			continue
+		}
+
+		// Different paths may end up with the same name, e.g. "a b" and "a_b", only the first is kept.
+		name := "mongodb_ss_" + shared.SnakeCase(strings.Join(fieldPath, "_"))
+		if seen[name] || !metricNameRegexp.MatchString(name) {
+			continue
+		}
+		seen[name] = true
+
+		desc := prometheus.NewDesc(name, "serverStatus."+dottedPath, nil, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.UntypedValue, value)
+	}
+}
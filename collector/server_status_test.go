@@ -1,9 +1,12 @@
 package collector
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/globalsign/mgo/bson"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func Test_ParserServerStatus(t *testing.T) {
@@ -71,3 +74,200 @@ func loadServerStatusFromBson(data []byte, status *ServerStatus) {
 		panic(err)
 	}
 }
+
+func Test_ServerStatusDisabledGroups(t *testing.T) {
+	enabled := map[string]bool{}
+	for group := range serverStatusGroups {
+		enabled[group] = true
+	}
+	enabled["tcmalloc"] = false
+	enabled["sharding"] = false
+
+	sections := disabledServerStatusSections(enabled)
+	if len(sections) != 3 || sections[0] != "sharding" || sections[1] != "shardingStatistics" || sections[2] != "tcmalloc" {
+		t.Errorf("unexpected disabled sections %v", sections)
+	}
+
+	command := serverStatusCommand(sections, 100)
+	if command[0].Name != "serverStatus" || command[len(command)-1].Name != "maxTimeMS" {
+		t.Errorf("unexpected command %v", command)
+	}
+	for _, section := range sections {
+		excluded := false
+		for _, elem := range command {
+			if elem.Name == section && elem.Value == 0 {
+				excluded = true
+			}
+		}
+		if !excluded {
+			t.Errorf("section %s was not excluded from %v", section, command)
+		}
+	}
+}
+
+func Test_ServerStatusDropSections(t *testing.T) {
+	data := LoadFixture("server_status.bson")
+
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(data, serverStatus)
+	serverStatus.dropSections(disabledServerStatusSections(map[string]bool{"asserts": true}))
+
+	if serverStatus.Asserts == nil {
+		t.Error("Asserts group should have been kept")
+	}
+	if serverStatus.Dur != nil || serverStatus.Locks != nil || serverStatus.Mem != nil {
+		t.Error("Disabled groups should have been dropped")
+	}
+	if serverStatus.Uptime == 0 {
+		t.Error("Uptime should always be kept")
+	}
+}
+
+func Test_DiscoverServerStatus(t *testing.T) {
+	doc := bson.M{}
+	if err := bson.Unmarshal(LoadFixture("server_status.bson"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["flowControl"] = bson.M{"enabled": true, "targetRateLimit": 1000000000}
+
+	ch := make(chan prometheus.Metric, 1000)
+	discoverServerStatus(doc, ch)
+	close(ch)
+
+	names := map[string]bool{}
+	for metric := range ch {
+		desc := metric.Desc().String()
+		names[desc[strings.Index(desc, `"`)+1:strings.Index(desc, `", help`)]] = true
+	}
+
+	for _, name := range []string{"mongodb_ss_uptime_millis", "mongodb_ss_write_backs_queued", "mongodb_ss_flow_control_enabled", "mongodb_ss_flow_control_target_rate_limit"} {
+		if !names[name] {
+			t.Errorf("%s was not discovered, got %v", name, names)
+		}
+	}
+	for _, name := range []string{"mongodb_ss_uptime", "mongodb_ss_asserts_user", "mongodb_ss_ok", "mongodb_ss_connections_current"} {
+		if names[name] {
+			t.Errorf("%s should have been skipped", name)
+		}
+	}
+}
+
+type serverStatusFixtureCollector struct {
+	status *ServerStatus
+}
+
+func (c *serverStatusFixtureCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.status.Describe(ch)
+}
+
+func (c *serverStatusFixtureCollector) Collect(ch chan<- prometheus.Metric) {
+	c.status.Export(ch)
+}
+
+func Test_ServerStatusConcurrentExport(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status.bson"), serverStatus)
+	checkGather(t, "serverStatus", serverStatus.Describe, serverStatus.Export)
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(&serverStatusFixtureCollector{status: serverStatus})
+
+	results := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			families, err := registry.Gather()
+			if err != nil {
+				t.Error(err)
+			}
+			series := 0
+			for _, family := range families {
+				series += len(family.Metric)
+			}
+			results <- series
+		}()
+	}
+
+	first, second := <-results, <-results
+	if first == 0 || first != second {
+		t.Errorf("expected both scrapes to return the same series, got %d and %d", first, second)
+	}
+}
+
+func Test_ShardingStatisticsNumHostsTargeted(t *testing.T) {
+	data, err := bson.Marshal(bson.M{
+		"shardingStatistics": bson.M{
+			"catalogCache": bson.M{"numDatabaseEntries": 2},
+			"numHostsTargeted": bson.M{
+				"find":   bson.M{"allShards": 3, "oneShard": 7},
+				"insert": bson.M{"oneShard": int64(11)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(data, serverStatus)
+	if serverStatus.ShardingStatistics == nil {
+		t.Fatal("ShardingStatistics group was not loaded")
+	}
+
+	targeted := serverStatus.ShardingStatistics.NumHostsTargeted
+	if targeted["find"]["allShards"] != 3 || targeted["find"]["oneShard"] != 7 || targeted["insert"]["oneShard"] != 11 {
+		t.Errorf("unexpected numHostsTargeted %v", targeted)
+	}
+}
+
+func Test_DefaultEnabledGroups(t *testing.T) {
+	defaults := map[string]bool{}
+	for _, group := range strings.Split(DefaultEnabledGroups, ",") {
+		if _, ok := serverStatusGroups[group]; !ok {
+			t.Errorf("unknown default group %s", group)
+		}
+		defaults[group] = true
+	}
+	for group := range serverStatusGroups {
+		if !defaults[group] {
+			t.Errorf("group %s is not enabled by default", group)
+		}
+	}
+}
+
+func Test_ServerStatusMetricTypes(t *testing.T) {
+	serverStatus := &ServerStatus{}
+	loadServerStatusFromBson(LoadFixture("server_status.bson"), serverStatus)
+
+	durability := newDurabilitySummaries()
+	durability.observe(serverStatus.Dur)
+	durability.observe(serverStatus.Dur)
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(&serverStatusFixtureCollector{status: serverStatus}, durability)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]dto.MetricType{
+		"mongodb_durability_early_commits":              dto.MetricType_SUMMARY,
+		"mongodb_durability_time_milliseconds":          dto.MetricType_SUMMARY,
+		"mongodb_metrics_repl_oplog_insert_num_total":   dto.MetricType_COUNTER,
+		"mongodb_metrics_repl_oplog_insert_bytes_total": dto.MetricType_COUNTER,
+	}
+	for _, family := range families {
+		metricType, ok := expected[family.GetName()]
+		if !ok {
+			continue
+		}
+		delete(expected, family.GetName())
+		if family.GetType() != metricType {
+			t.Errorf("expected %s to be a %s, got %s", family.GetName(), metricType, family.GetType())
+		}
+		if metricType == dto.MetricType_SUMMARY && family.Metric[0].Summary.GetSampleCount() != 2 {
+			t.Errorf("expected %s to observe both scrapes, got %d", family.GetName(), family.Metric[0].Summary.GetSampleCount())
+		}
+	}
+	if len(expected) != 0 {
+		t.Errorf("expected the metrics %v to be exported", expected)
+	}
+}
@@ -5,24 +5,21 @@ import (
 )
 
 var (
-	optimeTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding",
-		Name:      "last_seen_configserver_optime_timestamp",
-		Help:      "Last seen config server optime's timestamp",
-	})
-	optimeTerm = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding",
-		Name:      "last_seen_configserver_optime_term",
-		Help:      "Last seen config server optime's term",
-	})
-	maxChunkSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding",
-		Name:      "max_chunk_size_bytes",
-		Help:      "Maximum chunk size allowed in bytes",
-	})
+	optimeTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding", "last_seen_configserver_optime_timestamp"),
+		"Last seen config server optime's timestamp",
+		nil, nil,
+	)
+	optimeTerm = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding", "last_seen_configserver_optime_term"),
+		"Last seen config server optime's term",
+		nil, nil,
+	)
+	maxChunkSizeBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding", "max_chunk_size_bytes"),
+		"Maximum chunk size allowed in bytes",
+		nil, nil,
+	)
 )
 
 // Cursors are the cursor metrics
@@ -38,18 +35,15 @@ type LastSeenConfigServerOptime struct {
 
 // Export exports the data to prometheus.
 func (sharding *Sharding) Export(ch chan<- prometheus.Metric) {
-	optimeTimestamp.Set(sharding.LastSeenConfigServerOptime.Timestamp)
-	optimeTerm.Set(sharding.LastSeenConfigServerOptime.Term)
-	maxChunkSizeBytes.Set(sharding.MaxChunkSizeInBytes)
+	ch <- prometheus.MustNewConstMetric(optimeTimestamp, prometheus.GaugeValue, sharding.LastSeenConfigServerOptime.Timestamp)
+	ch <- prometheus.MustNewConstMetric(optimeTerm, prometheus.GaugeValue, sharding.LastSeenConfigServerOptime.Term)
+	ch <- prometheus.MustNewConstMetric(maxChunkSizeBytes, prometheus.GaugeValue, sharding.MaxChunkSizeInBytes)
 
-	optimeTimestamp.Collect(ch)
-	optimeTerm.Collect(ch)
-	maxChunkSizeBytes.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (sharding *Sharding) Describe(ch chan<- *prometheus.Desc) {
-	optimeTimestamp.Describe(ch)
-	optimeTerm.Describe(ch)
-	maxChunkSizeBytes.Describe(ch)
+	ch <- optimeTimestamp
+	ch <- optimeTerm
+	ch <- maxChunkSizeBytes
 }
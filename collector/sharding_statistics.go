@@ -5,91 +5,82 @@ import (
 )
 
 var (
-	countStaleConfigErrors = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "count_stale_config_errors_total",
-		Help:      "The total number of times that threads hit stale config exception. Since a stale config exception triggers a refresh of the metadata, this number is roughly proportional to the number of metadata refreshes.",
-	})
-	countDonorMoveChunkStarted = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "count_donor_move_chunk_started_total",
-		Help:      "The total number of times that the moveChunk command has started on the shard, of which this node is a member, as part of a chunk migration process. This increasing number does not consider whether the chunk migrations succeed or not.",
-	})
-	totalDonorChunkCloneTimeMillis = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "total_donor_chunk_clone_time_milliseconds",
-		Help:      "The cumulative time, in milliseconds, taken by the clone phase of the chunk migrations from this shard, of which this node is a member. Specifically, for each migration from this shard, the tracked time starts with the moveChunk command and ends before the destination shard enters a catch-up phase to apply changes that occurred during the chunk migrations.",
-	})
-	totalCriticalSectionCommitTimeMillis = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "total_critical_section_commit_time_milliseconds",
-		Help:      "The cumulative time, in milliseconds, taken by the update metadata phase of the chunk migrations from this shard, of which this node is a member. During the update metadata phase, all operations on the collection are blocked.",
-	})
-	totalCriticalSectionTimeMillis = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "total_critical_section_time_milliseconds",
-		Help:      "The cumulative time, in milliseconds, taken by the catch-up phase and the update metadata phase of the chunk migrations from this shard, of which this node is a member.",
-	})
+	countStaleConfigErrors = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "count_stale_config_errors_total"),
+		"The total number of times that threads hit stale config exception. Since a stale config exception triggers a refresh of the metadata, this number is roughly proportional to the number of metadata refreshes.",
+		nil, nil,
+	)
+	countDonorMoveChunkStarted = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "count_donor_move_chunk_started_total"),
+		"The total number of times that the moveChunk command has started on the shard, of which this node is a member, as part of a chunk migration process. This increasing number does not consider whether the chunk migrations succeed or not.",
+		nil, nil,
+	)
+	totalDonorChunkCloneTimeMillis = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "total_donor_chunk_clone_time_milliseconds"),
+		"The cumulative time, in milliseconds, taken by the clone phase of the chunk migrations from this shard, of which this node is a member. Specifically, for each migration from this shard, the tracked time starts with the moveChunk command and ends before the destination shard enters a catch-up phase to apply changes that occurred during the chunk migrations.",
+		nil, nil,
+	)
+	totalCriticalSectionCommitTimeMillis = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "total_critical_section_commit_time_milliseconds"),
+		"The cumulative time, in milliseconds, taken by the update metadata phase of the chunk migrations from this shard, of which this node is a member. During the update metadata phase, all operations on the collection are blocked.",
+		nil, nil,
+	)
+	totalCriticalSectionTimeMillis = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "total_critical_section_time_milliseconds"),
+		"The cumulative time, in milliseconds, taken by the catch-up phase and the update metadata phase of the chunk migrations from this shard, of which this node is a member.",
+		nil, nil,
+	)
 
-	catalogCacheNumDatabaseEntries = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_num_database_entries",
-		Help:      "The total number of database entries that are currently in the catalog cache.",
-	})
-	catalogCacheNumCollectionEntries = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_num_collection_entries",
-		Help:      "The total number of collection entries (across all databases) that are currently in the catalog cache.",
-	})
-	catalogCacheContStaleConfigErrors = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_count_stale_config_errors",
-		Help:      "The total number of times that threads hit stale config exception. A stale config exception triggers a refresh of the metadata.",
-	})
-	catalogCacheTotalRefreshWaitTime = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_total_refresh_wait_time_microseconds",
-		Help:      "The cumulative time, in microseconds, that threads had to wait for a refresh of the metadata.",
-	})
-	catalogCacheNumActiveIncrementalRefreshes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_num_active_incremental_refreshes",
-		Help:      "The number of incremental catalog cache refreshes that are currently waiting to complete.",
-	})
-	catalogCacheCountIncrementalRefreshesStarted = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_count_incremental_refreshes_started",
-		Help:      "    The cumulative number of incremental refreshes that have started.",
-	})
-	catalogCacheNumActiveFullRefreshes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_num_active_full_refreshes",
-		Help:      "The number of full catalog cache refreshes that are currently waiting to complete.",
-	})
-	catalogCacheCountFullRefreshesStarted = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_count_full_refreshes_started",
-		Help:      "The cumulative number of full refreshes that have started.",
-	})
-	catalogCacheCountFailedRefreshes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "sharding_statistics",
-		Name:      "catalog_cache_count_failed_refreshes",
-		Help:      "The cumulative number of full or incremental refreshes that have failed.",
-	})
+	catalogCacheNumDatabaseEntries = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_num_database_entries"),
+		"The total number of database entries that are currently in the catalog cache.",
+		nil, nil,
+	)
+	catalogCacheNumCollectionEntries = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_num_collection_entries"),
+		"The total number of collection entries (across all databases) that are currently in the catalog cache.",
+		nil, nil,
+	)
+	catalogCacheContStaleConfigErrors = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_count_stale_config_errors"),
+		"The total number of times that threads hit stale config exception. A stale config exception triggers a refresh of the metadata.",
+		nil, nil,
+	)
+	catalogCacheTotalRefreshWaitTime = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_total_refresh_wait_time_microseconds"),
+		"The cumulative time, in microseconds, that threads had to wait for a refresh of the metadata.",
+		nil, nil,
+	)
+	catalogCacheNumActiveIncrementalRefreshes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_num_active_incremental_refreshes"),
+		"The number of incremental catalog cache refreshes that are currently waiting to complete.",
+		nil, nil,
+	)
+	catalogCacheCountIncrementalRefreshesStarted = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_count_incremental_refreshes_started"),
+		"    The cumulative number of incremental refreshes that have started.",
+		nil, nil,
+	)
+	catalogCacheNumActiveFullRefreshes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_num_active_full_refreshes"),
+		"The number of full catalog cache refreshes that are currently waiting to complete.",
+		nil, nil,
+	)
+	catalogCacheCountFullRefreshesStarted = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_count_full_refreshes_started"),
+		"The cumulative number of full refreshes that have started.",
+		nil, nil,
+	)
+	numHostsTargeted = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "num_hosts_targeted_total"),
+		"The total number of operations a mongos router targeted at all shards, many shards, one shard or an unsharded collection, by operation.",
+		[]string{"op", "targeted"}, nil,
+	)
+	catalogCacheCountFailedRefreshes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "sharding_statistics", "catalog_cache_count_failed_refreshes"),
+		"The cumulative number of full or incremental refreshes that have failed.",
+		nil, nil,
+	)
 )
 
 // Cursors are the cursor metrics
@@ -100,6 +91,9 @@ type ShardingStatistics struct {
 	TotalCriticalSectionCommitTimeMillis int64 `bson:"totalCriticalSectionCommitTimeMillis"`
 	TotalCriticalSectionTimeMillis       int64 `bson:"totalCriticalSectionTimeMillis"`
 	CatalogCache                         `bson:"catalogCache"`
+	// NumHostsTargeted is only reported by mongos routers, by operation and by what was targeted:
+	// allShards, manyShards, oneShard or unsharded.
+	NumHostsTargeted map[string]map[string]int64 `bson:"numHostsTargeted"`
 }
 
 type CatalogCache struct {
@@ -116,51 +110,43 @@ type CatalogCache struct {
 
 // Export exports the data to prometheus.
 func (s *ShardingStatistics) Export(ch chan<- prometheus.Metric) {
-	countStaleConfigErrors.Set(float64(s.CountStaleConfigErrors))
-	countDonorMoveChunkStarted.Set(float64(s.CountDonorMoveChunkStarted))
-	totalDonorChunkCloneTimeMillis.Set(float64(s.TotalDonorChunkCloneTimeMillis))
-	totalCriticalSectionCommitTimeMillis.Set(float64(s.TotalCriticalSectionCommitTimeMillis))
-	totalCriticalSectionTimeMillis.Set(float64(s.TotalCriticalSectionTimeMillis))
-	catalogCacheNumDatabaseEntries.Set(float64(s.CatalogCache.NumDatabaseEntries))
-	catalogCacheNumCollectionEntries.Set(float64(s.CatalogCache.NumCollectionEntries))
-	catalogCacheContStaleConfigErrors.Set(float64(s.CatalogCache.CountStaleConfigErrors))
-	catalogCacheTotalRefreshWaitTime.Set(float64(s.CatalogCache.TotalRefreshWaitTimeMicros))
-	catalogCacheNumActiveIncrementalRefreshes.Set(float64(s.CatalogCache.NumActiveIncrementalRefreshes))
-	catalogCacheCountIncrementalRefreshesStarted.Set(float64(s.CatalogCache.CountIncrementalRefreshesStarted))
-	catalogCacheNumActiveFullRefreshes.Set(float64(s.CatalogCache.NumActiveFullRefreshes))
-	catalogCacheCountFullRefreshesStarted.Set(float64(s.CatalogCache.CountFullRefreshesStarted))
-	catalogCacheCountFailedRefreshes.Set(float64(s.CatalogCache.CountFailedRefreshes))
+	ch <- prometheus.MustNewConstMetric(countStaleConfigErrors, prometheus.GaugeValue, float64(s.CountStaleConfigErrors))
+	ch <- prometheus.MustNewConstMetric(countDonorMoveChunkStarted, prometheus.GaugeValue, float64(s.CountDonorMoveChunkStarted))
+	ch <- prometheus.MustNewConstMetric(totalDonorChunkCloneTimeMillis, prometheus.GaugeValue, float64(s.TotalDonorChunkCloneTimeMillis))
+	ch <- prometheus.MustNewConstMetric(totalCriticalSectionCommitTimeMillis, prometheus.GaugeValue, float64(s.TotalCriticalSectionCommitTimeMillis))
+	ch <- prometheus.MustNewConstMetric(totalCriticalSectionTimeMillis, prometheus.GaugeValue, float64(s.TotalCriticalSectionTimeMillis))
+	ch <- prometheus.MustNewConstMetric(catalogCacheNumDatabaseEntries, prometheus.GaugeValue, float64(s.CatalogCache.NumDatabaseEntries))
+	ch <- prometheus.MustNewConstMetric(catalogCacheNumCollectionEntries, prometheus.GaugeValue, float64(s.CatalogCache.NumCollectionEntries))
+	ch <- prometheus.MustNewConstMetric(catalogCacheContStaleConfigErrors, prometheus.GaugeValue, float64(s.CatalogCache.CountStaleConfigErrors))
+	ch <- prometheus.MustNewConstMetric(catalogCacheTotalRefreshWaitTime, prometheus.GaugeValue, float64(s.CatalogCache.TotalRefreshWaitTimeMicros))
+	ch <- prometheus.MustNewConstMetric(catalogCacheNumActiveIncrementalRefreshes, prometheus.GaugeValue, float64(s.CatalogCache.NumActiveIncrementalRefreshes))
+	ch <- prometheus.MustNewConstMetric(catalogCacheCountIncrementalRefreshesStarted, prometheus.GaugeValue, float64(s.CatalogCache.CountIncrementalRefreshesStarted))
+	ch <- prometheus.MustNewConstMetric(catalogCacheNumActiveFullRefreshes, prometheus.GaugeValue, float64(s.CatalogCache.NumActiveFullRefreshes))
+	ch <- prometheus.MustNewConstMetric(catalogCacheCountFullRefreshesStarted, prometheus.GaugeValue, float64(s.CatalogCache.CountFullRefreshesStarted))
+	ch <- prometheus.MustNewConstMetric(catalogCacheCountFailedRefreshes, prometheus.GaugeValue, float64(s.CatalogCache.CountFailedRefreshes))
+	for op, targets := range s.NumHostsTargeted {
+		for targeted, count := range targets {
+			ch <- prometheus.MustNewConstMetric(numHostsTargeted, prometheus.CounterValue, float64(count), op, targeted)
+		}
+	}
 
-	countStaleConfigErrors.Collect(ch)
-	countDonorMoveChunkStarted.Collect(ch)
-	totalDonorChunkCloneTimeMillis.Collect(ch)
-	totalCriticalSectionCommitTimeMillis.Collect(ch)
-	totalCriticalSectionTimeMillis.Collect(ch)
-	catalogCacheNumDatabaseEntries.Collect(ch)
-	catalogCacheNumCollectionEntries.Collect(ch)
-	catalogCacheContStaleConfigErrors.Collect(ch)
-	catalogCacheTotalRefreshWaitTime.Collect(ch)
-	catalogCacheNumActiveIncrementalRefreshes.Collect(ch)
-	catalogCacheCountIncrementalRefreshesStarted.Collect(ch)
-	catalogCacheNumActiveFullRefreshes.Collect(ch)
-	catalogCacheCountFullRefreshesStarted.Collect(ch)
-	catalogCacheCountFailedRefreshes.Collect(ch)
 }
 
 // Describe describes the metrics for prometheus
 func (s *ShardingStatistics) Describe(ch chan<- *prometheus.Desc) {
-	countStaleConfigErrors.Describe(ch)
-	countDonorMoveChunkStarted.Describe(ch)
-	totalDonorChunkCloneTimeMillis.Describe(ch)
-	totalCriticalSectionCommitTimeMillis.Describe(ch)
-	totalCriticalSectionTimeMillis.Describe(ch)
-	catalogCacheNumDatabaseEntries.Describe(ch)
-	catalogCacheNumCollectionEntries.Describe(ch)
-	catalogCacheContStaleConfigErrors.Describe(ch)
-	catalogCacheTotalRefreshWaitTime.Describe(ch)
-	catalogCacheNumActiveIncrementalRefreshes.Describe(ch)
-	catalogCacheCountIncrementalRefreshesStarted.Describe(ch)
-	catalogCacheNumActiveFullRefreshes.Describe(ch)
-	catalogCacheCountFullRefreshesStarted.Describe(ch)
-	catalogCacheCountFailedRefreshes.Describe(ch)
+	ch <- countStaleConfigErrors
+	ch <- countDonorMoveChunkStarted
+	ch <- totalDonorChunkCloneTimeMillis
+	ch <- totalCriticalSectionCommitTimeMillis
+	ch <- totalCriticalSectionTimeMillis
+	ch <- catalogCacheNumDatabaseEntries
+	ch <- catalogCacheNumCollectionEntries
+	ch <- catalogCacheContStaleConfigErrors
+	ch <- catalogCacheTotalRefreshWaitTime
+	ch <- catalogCacheNumActiveIncrementalRefreshes
+	ch <- catalogCacheCountIncrementalRefreshesStarted
+	ch <- catalogCacheNumActiveFullRefreshes
+	ch <- catalogCacheCountFullRefreshesStarted
+	ch <- catalogCacheCountFailedRefreshes
+	ch <- numHostsTargeted
 }
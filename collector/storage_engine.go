@@ -19,11 +19,11 @@ import (
 )
 
 var (
-	storageEngine = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "storage_engine",
-		Help:      "The storage engine used by the MongoDB instance",
-	}, []string{"engine"})
+	storageEngine = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "storage_engine"),
+		"The storage engine used by the MongoDB instance",
+		[]string{"engine"}, nil,
+	)
 )
 
 // StorageEngineStats
@@ -33,11 +33,10 @@ type StorageEngineStats struct {
 
 // Export exports the data to prometheus.
 func (stats *StorageEngineStats) Export(ch chan<- prometheus.Metric) {
-	storageEngine.WithLabelValues(stats.Name).Set(1)
-	storageEngine.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(storageEngine, prometheus.GaugeValue, 1, stats.Name)
 }
 
 // Describe describes the metrics for prometheus
 func (stats *StorageEngineStats) Describe(ch chan<- *prometheus.Desc) {
-	storageEngine.Describe(ch)
+	ch <- storageEngine
 }
@@ -5,45 +5,45 @@ import (
 )
 
 var (
-	tcmallocGeneral = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_generic_heap",
-		Help:      "High-level summary metricsInternal metrics from tcmalloc",
-	}, []string{"type"})
-	tcmallocPageheapBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_pageheap_bytes",
-		Help:      "Sizes for tcpmalloc pageheaps",
-	}, []string{"type"})
-	tcmallocPageheapCounts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_pageheap_count",
-		Help:      "Sizes for tcpmalloc pageheaps",
-	}, []string{"type"})
-
-	tcmallocCacheBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_cache_bytes",
-		Help:      "Sizes for tcpmalloc caches in bytes",
-	}, []string{"cache", "type"})
-
-	tcmallocAggressiveDecommit = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_aggressive_memory_decommit",
-		Help:      "Whether aggressive_memory_decommit is on",
-	})
-
-	tcmallocSpinlogkTotalDelayNs = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_spinlock_total_delay_ns",
-		Help:      "Total ns spent on the tcmalloc spinlock",
-	})
-
-	tcmallocFreeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "tcmalloc_free_bytes",
-		Help:      "Total free bytes of tcmalloc",
-	})
+	tcmallocGeneral = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_generic_heap"),
+		"High-level summary metricsInternal metrics from tcmalloc",
+		[]string{"type"}, nil,
+	)
+	tcmallocPageheapBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_pageheap_bytes"),
+		"Sizes for tcpmalloc pageheaps",
+		[]string{"type"}, nil,
+	)
+	tcmallocPageheapCounts = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_pageheap_count"),
+		"Sizes for tcpmalloc pageheaps",
+		[]string{"type"}, nil,
+	)
+
+	tcmallocCacheBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_cache_bytes"),
+		"Sizes for tcpmalloc caches in bytes",
+		[]string{"cache", "type"}, nil,
+	)
+
+	tcmallocAggressiveDecommit = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_aggressive_memory_decommit"),
+		"Whether aggressive_memory_decommit is on",
+		nil, nil,
+	)
+
+	tcmallocSpinlogkTotalDelayNs = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_spinlock_total_delay_ns"),
+		"Total ns spent on the tcmalloc spinlock",
+		nil, nil,
+	)
+
+	tcmallocFreeBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "tcmalloc_free_bytes"),
+		"Total free bytes of tcmalloc",
+		nil, nil,
+	)
 )
 
 // TCMallocStats tracks the mem stats metrics.
@@ -83,50 +83,43 @@ type DetailedTCMallocStats struct {
 // Export exports the data to prometheus.
 func (m *TCMallocStats) Export(ch chan<- prometheus.Metric) {
 	// Generic metrics
-	tcmallocGeneral.WithLabelValues("allocated").Set(m.Generic.CurrentAllocatedBytes)
-	tcmallocGeneral.WithLabelValues("total").Set(m.Generic.HeapSize)
-	tcmallocGeneral.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(tcmallocGeneral, prometheus.GaugeValue, m.Generic.CurrentAllocatedBytes, "allocated")
+	ch <- prometheus.MustNewConstMetric(tcmallocGeneral, prometheus.GaugeValue, m.Generic.HeapSize, "total")
 
 	// Pageheap
-	tcmallocPageheapBytes.WithLabelValues("free").Set(m.Details.PageheapFreeBytes)
-	tcmallocPageheapBytes.WithLabelValues("unmapped").Set(m.Details.PageheapUnmappedBytes)
-	tcmallocPageheapBytes.WithLabelValues("comitted").Set(m.Details.PageheapComittedBytes)
-	tcmallocPageheapBytes.WithLabelValues("total_commit").Set(m.Details.PageheapTotalCommitBytes)
-	tcmallocPageheapBytes.WithLabelValues("total_decommit").Set(m.Details.PageheapTotalDecommitBytes)
-	tcmallocPageheapBytes.WithLabelValues("total_reserve").Set(m.Details.PageheapTotalReserveBytes)
-	tcmallocPageheapBytes.Collect(ch)
-
-	tcmallocPageheapCounts.WithLabelValues("scavenge").Set(m.Details.PageheapScavengeCount)
-	tcmallocPageheapCounts.WithLabelValues("commit").Set(m.Details.PageheapCommitCount)
-	tcmallocPageheapCounts.WithLabelValues("decommit").Set(m.Details.PageheapDecommitCount)
-	tcmallocPageheapCounts.WithLabelValues("reserve").Set(m.Details.PageheapReserveCount)
-	tcmallocPageheapCounts.Collect(ch)
-
-	tcmallocCacheBytes.WithLabelValues("thread_cache", "max_total").Set(m.Details.MaxTotalThreadCacheBytes)
-	tcmallocCacheBytes.WithLabelValues("thread_cache", "current_total").Set(m.Details.CurrentTotalThreadCacheBytes)
-	tcmallocCacheBytes.WithLabelValues("central_cache", "free").Set(m.Details.CentralCacheFreeBytes)
-	tcmallocCacheBytes.WithLabelValues("transfer_cache", "free").Set(m.Details.TransferCacheFreeBytes)
-	tcmallocCacheBytes.WithLabelValues("thread_cache", "free").Set(m.Details.ThreadCacheFreeBytes)
-	tcmallocCacheBytes.Collect(ch)
-
-	tcmallocAggressiveDecommit.Set(m.Details.AggressiveMemoryDecommit)
-	tcmallocAggressiveDecommit.Collect(ch)
-
-	tcmallocSpinlogkTotalDelayNs.Set(m.Details.SpinlockTotalDelayNS)
-	tcmallocSpinlogkTotalDelayNs.Collect(ch)
-
-	tcmallocFreeBytes.Set(m.Details.TotalFreeBytes)
-	tcmallocFreeBytes.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapBytes, prometheus.GaugeValue, m.Details.PageheapFreeBytes, "free")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapBytes, prometheus.GaugeValue, m.Details.PageheapUnmappedBytes, "unmapped")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapBytes, prometheus.GaugeValue, m.Details.PageheapComittedBytes, "comitted")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapBytes, prometheus.GaugeValue, m.Details.PageheapTotalCommitBytes, "total_commit")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapBytes, prometheus.GaugeValue, m.Details.PageheapTotalDecommitBytes, "total_decommit")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapBytes, prometheus.GaugeValue, m.Details.PageheapTotalReserveBytes, "total_reserve")
+
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapCounts, prometheus.GaugeValue, m.Details.PageheapScavengeCount, "scavenge")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapCounts, prometheus.GaugeValue, m.Details.PageheapCommitCount, "commit")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapCounts, prometheus.GaugeValue, m.Details.PageheapDecommitCount, "decommit")
+	ch <- prometheus.MustNewConstMetric(tcmallocPageheapCounts, prometheus.GaugeValue, m.Details.PageheapReserveCount, "reserve")
+
+	ch <- prometheus.MustNewConstMetric(tcmallocCacheBytes, prometheus.GaugeValue, m.Details.MaxTotalThreadCacheBytes, "thread_cache", "max_total")
+	ch <- prometheus.MustNewConstMetric(tcmallocCacheBytes, prometheus.GaugeValue, m.Details.CurrentTotalThreadCacheBytes, "thread_cache", "current_total")
+	ch <- prometheus.MustNewConstMetric(tcmallocCacheBytes, prometheus.GaugeValue, m.Details.CentralCacheFreeBytes, "central_cache", "free")
+	ch <- prometheus.MustNewConstMetric(tcmallocCacheBytes, prometheus.GaugeValue, m.Details.TransferCacheFreeBytes, "transfer_cache", "free")
+	ch <- prometheus.MustNewConstMetric(tcmallocCacheBytes, prometheus.GaugeValue, m.Details.ThreadCacheFreeBytes, "thread_cache", "free")
+
+	ch <- prometheus.MustNewConstMetric(tcmallocAggressiveDecommit, prometheus.GaugeValue, m.Details.AggressiveMemoryDecommit)
+
+	ch <- prometheus.MustNewConstMetric(tcmallocSpinlogkTotalDelayNs, prometheus.GaugeValue, m.Details.SpinlockTotalDelayNS)
+
+	ch <- prometheus.MustNewConstMetric(tcmallocFreeBytes, prometheus.GaugeValue, m.Details.TotalFreeBytes)
 
 }
 
 // Describe describes the metrics for prometheus
 func (m *TCMallocStats) Describe(ch chan<- *prometheus.Desc) {
-	tcmallocGeneral.Describe(ch)
-	tcmallocPageheapBytes.Describe(ch)
-	tcmallocPageheapCounts.Describe(ch)
-	tcmallocCacheBytes.Describe(ch)
-	tcmallocAggressiveDecommit.Describe(ch)
-	tcmallocSpinlogkTotalDelayNs.Describe(ch)
-	tcmallocFreeBytes.Describe(ch)
+	ch <- tcmallocGeneral
+	ch <- tcmallocPageheapBytes
+	ch <- tcmallocPageheapCounts
+	ch <- tcmallocCacheBytes
+	ch <- tcmallocAggressiveDecommit
+	ch <- tcmallocSpinlogkTotalDelayNs
+	ch <- tcmallocFreeBytes
 }
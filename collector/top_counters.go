@@ -13,26 +13,26 @@ var (
 )
 
 var (
-	topTimeSecondsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "top_time_seconds_total",
-		Help:      "The top command provides operation time, in seconds, for each database collection",
-	}, []string{"type", "database", "collection"})
-	topCountTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "top_count_total",
-		Help:      "The top command provides operation count for each database collection",
-	}, []string{"type", "database", "collection"})
-	topTimeSecondsAggregateTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "top_time_seconds_aggregate_total",
-		Help:      "An aggregate counter for top time seconds for read/write (does not include locks)",
-	}, []string{"type"})
-	topCountAggregateTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Name:      "top_count_aggregate_total",
-		Help:      "An aggregate counter for top operations for read/write (does not include locks)",
-	}, []string{"type"})
+	topTimeSecondsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "top_time_seconds_total"),
+		"The top command provides operation time, in seconds, for each database collection",
+		[]string{"type", "database", "collection"}, nil,
+	)
+	topCountTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "top_count_total"),
+		"The top command provides operation count for each database collection",
+		[]string{"type", "database", "collection"}, nil,
+	)
+	topTimeSecondsAggregateTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "top_time_seconds_aggregate_total"),
+		"An aggregate counter for top time seconds for read/write (does not include locks)",
+		[]string{"type"}, nil,
+	)
+	topCountAggregateTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "top_count_aggregate_total"),
+		"An aggregate counter for top operations for read/write (does not include locks)",
+		[]string{"type"}, nil,
+	)
 )
 
 // TopStatsMap is a map of top stats
@@ -91,27 +91,23 @@ func (topStats TopStatsMap) Export(ch chan<- prometheus.Metric) {
 				totalWriteOps += op_count
 			}
 
-			topTimeSecondsTotal.WithLabelValues(metric_type, database, collection).Set(op_time_second)
-			topCountTotal.WithLabelValues(metric_type, database, collection).Set(op_count)
+			ch <- prometheus.MustNewConstMetric(topTimeSecondsTotal, prometheus.GaugeValue, op_time_second, metric_type, database, collection)
+			ch <- prometheus.MustNewConstMetric(topCountTotal, prometheus.GaugeValue, op_count, metric_type, database, collection)
 		}
 	}
 
 	// Set aggregate metrics
-	topTimeSecondsAggregateTotal.WithLabelValues("Read").Set(totalReadSeconds)
-	topTimeSecondsAggregateTotal.WithLabelValues("Write").Set(totalWriteSeconds)
-	topCountAggregateTotal.WithLabelValues("Read").Set(totalReadOps)
-	topCountAggregateTotal.WithLabelValues("Write").Set(totalWriteOps)
-
-	topTimeSecondsTotal.Collect(ch)
-	topCountTotal.Collect(ch)
-	topTimeSecondsAggregateTotal.Collect(ch)
-	topCountAggregateTotal.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(topTimeSecondsAggregateTotal, prometheus.GaugeValue, totalReadSeconds, "Read")
+	ch <- prometheus.MustNewConstMetric(topTimeSecondsAggregateTotal, prometheus.GaugeValue, totalWriteSeconds, "Write")
+	ch <- prometheus.MustNewConstMetric(topCountAggregateTotal, prometheus.GaugeValue, totalReadOps, "Read")
+	ch <- prometheus.MustNewConstMetric(topCountAggregateTotal, prometheus.GaugeValue, totalWriteOps, "Write")
+
 }
 
 // Describe describes the metrics for prometheus
 func (tops TopStatsMap) Describe(ch chan<- *prometheus.Desc) {
-	topTimeSecondsTotal.Describe(ch)
-	topCountTotal.Describe(ch)
-	topTimeSecondsAggregateTotal.Describe(ch)
-	topCountAggregateTotal.Describe(ch)
+	ch <- topTimeSecondsTotal
+	ch <- topCountTotal
+	ch <- topTimeSecondsAggregateTotal
+	ch <- topCountAggregateTotal
 }
@@ -3,7 +3,6 @@ package collector
 import (
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -15,7 +14,7 @@ type TopStatus struct {
 // GetTopStats fetches top stats
 func GetTopStats(session *mgo.Session) (*TopStatus, error) {
 	results := &TopStatus{}
-	err := session.DB("admin").Run(bson.D{{"top", 1}}, &results)
+	err := session.DB("admin").Run(bson.D{{Name: "top", Value: 1}}, &results)
 	return results, err
 }
 
@@ -29,13 +28,29 @@ func (status *TopStatus) Describe(ch chan<- *prometheus.Desc) {
 	status.TopStats.Describe(ch)
 }
 
-// GetTopStatus fetches top stats
-func GetTopStatus(session *mgo.Session) *TopStatus {
+func init() {
+	Register("top", newTopCollector, false)
+}
+
+type topCollector struct{}
+
+func newTopCollector(opts MongodbCollectorOpts) (Collector, error) {
+	return &topCollector{}, nil
+}
+
+func (c *topCollector) runsOnRouter() bool {
+	return false
+}
+
+func (c *topCollector) Update(session *mgo.Session, ch chan<- prometheus.Metric) error {
 	topStatus, err := GetTopStats(session)
 	if err != nil {
-		glog.Error("Failed to get top status.")
-		return nil
+		return err
 	}
+	topStatus.Export(ch)
+	return nil
+}
 
-	return topStatus
+func (c *topCollector) Describe(ch chan<- *prometheus.Desc) {
+	(&TopStatus{}).Describe(ch)
 }
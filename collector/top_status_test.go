@@ -20,6 +20,7 @@ func Test_ParserTopStatus(t *testing.T) {
 
 	topStatus := &TopStatus{}
 	loadTopStatusFromBson(data, topStatus)
+	checkGather(t, "top", topStatus.Describe, topStatus.Export)
 
 	topStats := topStatus.TopStats["dummy.users"]
 
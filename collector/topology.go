@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// Node is a mongod or mongos found while discovering the topology of a deployment.
+type Node struct {
+	Addr       string
+	ReplicaSet string
+	// Shard is the name of the shard of the node, "config" for the config servers.
+	Shard string
+	// Role is primary, secondary, arbiter, mongos or standalone, or empty when the replica set
+	// of the node couldn't be reached.
+	Role string
+}
+
+// isMasterResult is the part of the isMaster response used to find the other members of a
+// deployment.
+type isMasterResult struct {
+	SetName  string   `bson:"setName"`
+	Hosts    []string `bson:"hosts"`
+	Passives []string `bson:"passives"`
+	Arbiters []string `bson:"arbiters"`
+	Primary  string   `bson:"primary"`
+	Msg      string   `bson:"msg"`
+	// ArbiterOnly tells whether the node answering isMaster is an arbiter.
+	ArbiterOnly bool `bson:"arbiterOnly"`
+	// LocalTime is the time according to the node answering isMaster.
+	LocalTime time.Time `bson:"localTime"`
+}
+
+// isMongos tells whether isMaster was answered by a mongos router.
+func (result *isMasterResult) isMongos() bool {
+	return result.Msg == "isdbgrid"
+}
+
+// members returns the members of the replica set described by the isMaster response.
+func (result *isMasterResult) members(shard string) []Node {
+	var nodes []Node
+	for _, addr := range append(append([]string{}, result.Hosts...), result.Passives...) {
+		role := "secondary"
+		if addr == result.Primary {
+			role = "primary"
+		}
+		nodes = append(nodes, Node{Addr: addr, ReplicaSet: result.SetName, Shard: shard, Role: role})
+	}
+	for _, addr := range result.Arbiters {
+		nodes = append(nodes, Node{Addr: addr, ReplicaSet: result.SetName, Shard: shard, Role: "arbiter"})
+	}
+	return nodes
+}
+
+func getIsMaster(session *mgo.Session) (*isMasterResult, error) {
+	result := &isMasterResult{}
+	err := session.DB("admin").Run(bson.D{{Name: "isMaster", Value: 1}}, result)
+	return result, err
+}
+
+// shardEntry is a document of config.shards.
+type shardEntry struct {
+	ID   string `bson:"_id"`
+	Host string `bson:"host"`
+}
+
+// shardMap is the response of getShardMap, whose map holds the connection string of the config
+// servers under "config".
+type shardMap struct {
+	Map map[string]string `bson:"map"`
+}
+
+// DiscoverTopology finds the nodes of the deployment the session is connected to. The members of
+// a replica set come from isMaster. Behind a mongos, the shards come from config.shards and the
+// config servers from getShardMap, their members are found by dialing them with dial. The seeds
+// are the addresses the session was dialed with, used for mongos and standalone nodes.
+func DiscoverTopology(session *mgo.Session, seeds []string, dial func(addr string) (*mgo.Session, error)) ([]Node, error) {
+	isMaster, err := getIsMaster(session)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []Node
+	switch {
+	case isMaster.isMongos():
+		for _, seed := range seeds {
+			nodes = append(nodes, Node{Addr: seed, Role: "mongos"})
+		}
+
+		var shards []shardEntry
+		if err := session.DB("config").C("shards").Find(nil).All(&shards); err != nil {
+			return nil, fmt.Errorf("couldn't list the shards: %s", err)
+		}
+		for _, shard := range shards {
+			nodes = append(nodes, discoverReplicaSet(shard.Host, shard.ID, dial)...)
+		}
+
+		result := &shardMap{}
+		if err := session.DB("admin").Run(bson.D{{Name: "getShardMap", Value: 1}}, result); err != nil {
+			return nil, fmt.Errorf("couldn't get the shard map: %s", err)
+		}
+		if config, ok := result.Map["config"]; ok {
+			nodes = append(nodes, discoverReplicaSet(config, "config", dial)...)
+		}
+
+	case isMaster.SetName != "":
+		nodes = isMaster.members("")
+
+	default:
+		for _, seed := range seeds {
+			nodes = append(nodes, Node{Addr: seed, Role: "standalone"})
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Addr < nodes[j].Addr })
+	return nodes, nil
+}
+
+// discoverReplicaSet finds the members of the replica set given by a connection string like
+// "rs0/host1:27018,host2:27018", asking the hosts in turn. When none of them answers, the hosts
+// of the connection string are returned without a role.
+func discoverReplicaSet(connection, shard string, dial func(addr string) (*mgo.Session, error)) []Node {
+	setName, hosts := parseReplicaSetConnection(connection)
+	for _, host := range hosts {
+		session, err := dial(host)
+		if err != nil {
+			continue
+		}
+		isMaster, err := getIsMaster(session)
+		session.Close()
+		if err != nil {
+			continue
+		}
+		if isMaster.SetName == "" {
+			return []Node{{Addr: host, Shard: shard, Role: "standalone"}}
+		}
+		return isMaster.members(shard)
+	}
+
+	var nodes []Node
+	for _, host := range hosts {
+		nodes = append(nodes, Node{Addr: host, ReplicaSet: setName, Shard: shard})
+	}
+	return nodes
+}
+
+// parseReplicaSetConnection splits a connection string like "rs0/host1:27018,host2:27018" into
+// the replica set name and the hosts. The name is empty when there is no slash.
+func parseReplicaSetConnection(connection string) (string, []string) {
+	setName := ""
+	if i := strings.Index(connection, "/"); i >= 0 {
+		setName, connection = connection[:i], connection[i+1:]
+	}
+	var hosts []string
+	for _, host := range strings.Split(connection, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return setName, hosts
+}
+
+// nodeURI returns the URI of the seed with its hosts replaced by the given address, keeping the
+// credentials, database and options but the replica set name.
+func nodeURI(seed, addr string) string {
+	rest := strings.TrimPrefix(seed, "mongodb://")
+
+	credentials := ""
+	hostsEnd := strings.IndexAny(rest, "/?")
+	if hostsEnd < 0 {
+		hostsEnd = len(rest)
+	}
+	if i := strings.LastIndex(rest[:hostsEnd], "@"); i >= 0 {
+		credentials, rest = rest[:i+1], rest[i+1:]
+		hostsEnd -= i + 1
+	}
+	suffix := rest[hostsEnd:]
+
+	if i := strings.Index(suffix, "?"); i >= 0 {
+		var options []string
+		for _, option := range strings.Split(suffix[i+1:], "&") {
+			if option != "" && !strings.HasPrefix(option, "replicaSet=") {
+				options = append(options, option)
+			}
+		}
+		suffix = suffix[:i]
+		if len(options) > 0 {
+			suffix += "?" + strings.Join(options, "&")
+		}
+	}
+
+	return "mongodb://" + credentials + addr + suffix
+}
+
+// seedAddrs returns the hosts of the given URI.
+func seedAddrs(uri string) ([]string, error) {
+	dialInfo, err := mgo.ParseURL(uri)
+	if err != nil {
+		return nil, err
+	}
+	return dialInfo.Addrs, nil
+}
+
+// uriHosts returns the hosts of the given URI joined by commas, without the credentials, to be
+// logged.
+func uriHosts(uri string) string {
+	addrs, err := seedAddrs(uri)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(addrs, ",")
+}
@@ -0,0 +1,295 @@
+package collector
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dcu/mongodb_exporter/shared"
+	"github.com/globalsign/mgo"
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultDiscoveryInterval is how often the topology is discovered again when no interval is given.
+const DefaultDiscoveryInterval = time.Minute
+
+var (
+	topologyDiscoverySuccessDesc = prometheus.NewDesc(
+		"mongodb_exporter_topology_discovery_success",
+		"Whether the last discovery of the topology from the seed succeeded.",
+		nil, nil,
+	)
+	topologyNodesDesc = prometheus.NewDesc(
+		"mongodb_exporter_topology_nodes",
+		"The number of discovered nodes, by role.",
+		[]string{"role"}, nil,
+	)
+)
+
+// topologyLabels are added to the metrics of every discovered node, prefixed with node_ when the
+// metric already has a label of the same name.
+var topologyLabels = []string{"instance", "rs", "shard", "role"}
+
+// TopologyCollector discovers the nodes of a deployment from a seed, a replica set member or a
+// mongos, and scrapes each of them directly with its own MongodbCollector. The metrics of every
+// node are labeled with its address, replica set, shard and role. The cluster-wide collectors of
+// the routers, like the balancer, only run on one of them.
+type TopologyCollector struct {
+	opts     MongodbCollectorOpts
+	interval time.Duration
+	// template describes the metrics of the nodes.
+	template *MongodbCollector
+
+	lock       sync.Mutex
+	nodes      map[string]*discoveredNode
+	discovered time.Time
+	success    bool
+	started    bool
+}
+
+// discoveredNode is a discovered node and the collector scraping it.
+type discoveredNode struct {
+	node      Node
+	collector *MongodbCollector
+	// clusterWide tells whether the cluster-wide collectors run on the node.
+	clusterWide bool
+}
+
+// NewTopologyCollector returns a collector discovering the topology from the URI of the options
+// every interval, DefaultDiscoveryInterval when zero.
+func NewTopologyCollector(opts MongodbCollectorOpts, interval time.Duration) *TopologyCollector {
+	if opts.SessionManager == nil {
+		opts.SessionManager = shared.NewSessionManager()
+	}
+	if opts.Collectors == nil {
+		opts.Collectors = EnabledCollectors()
+	}
+	if interval <= 0 {
+		interval = DefaultDiscoveryInterval
+	}
+
+	return &TopologyCollector{
+		opts:     opts,
+		interval: interval,
+		template: NewMongodbCollector(opts),
+		nodes:    make(map[string]*discoveredNode),
+	}
+}
+
+// Describe describes the metrics of the nodes and of the discovery.
+func (c *TopologyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- topologyDiscoverySuccessDesc
+	ch <- topologyNodesDesc
+	c.template.Describe(ch)
+}
+
+// Collect discovers the topology again when it is older than the interval, then collects all the
+// nodes concurrently.
+func (c *TopologyCollector) Collect(ch chan<- prometheus.Metric) {
+	nodes, success := c.refresh()
+
+	ch <- prometheus.MustNewConstMetric(topologyDiscoverySuccessDesc, prometheus.GaugeValue, boolToFloat64(success))
+	roles := map[string]int{}
+	for _, node := range nodes {
+		roles[node.node.Role]++
+	}
+	for role, count := range roles {
+		ch <- prometheus.MustNewConstMetric(topologyNodesDesc, prometheus.GaugeValue, float64(count), role)
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *discoveredNode) {
+			defer wg.Done()
+			collectNode(node, ch)
+		}(node)
+	}
+	wg.Wait()
+}
+
+func collectNode(node *discoveredNode, ch chan<- prometheus.Metric) {
+	labels := make([]*dto.LabelPair, len(topologyLabels))
+	for i, value := range []string{node.node.Addr, node.node.ReplicaSet, node.node.Shard, node.node.Role} {
+		labels[i] = &dto.LabelPair{Name: proto.String(topologyLabels[i]), Value: proto.String(value)}
+	}
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		node.collector.Collect(metrics)
+		close(metrics)
+	}()
+	for metric := range metrics {
+		ch <- &labeledMetric{Metric: metric, labels: labels}
+	}
+}
+
+// Start starts the background collection of the nodes, see MongodbCollector.Start.
+func (c *TopologyCollector) Start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.started = true
+	for _, node := range c.nodes {
+		node.collector.Start()
+	}
+}
+
+// Stop stops the background collection of the nodes.
+func (c *TopologyCollector) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.started = false
+	for _, node := range c.nodes {
+		node.collector.Stop()
+	}
+}
+
+// refresh discovers the topology when it is due and returns the nodes to collect. The previous
+// nodes are kept when the discovery fails, the seed itself is collected when there are none.
+func (c *TopologyCollector) refresh() ([]*discoveredNode, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if time.Since(c.discovered) >= c.interval {
+		nodes, err := c.discover()
+		c.discovered = time.Now()
+		c.success = err == nil
+		if err != nil {
+			glog.Errorf("Couldn't discover the topology: %s", err)
+		} else {
+			c.update(nodes)
+		}
+		if seeds, err := seedAddrs(c.opts.URI); len(c.nodes) == 0 && err == nil {
+			c.update([]Node{{Addr: strings.Join(seeds, ",")}})
+		}
+	}
+
+	nodes := make([]*discoveredNode, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes, c.success
+}
+
+func (c *TopologyCollector) discover() ([]Node, error) {
+	seeds, err := seedAddrs(c.opts.URI)
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.opts.SessionManager.Session(c.opts.toSessionOps())
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	session.SetSocketTimeout(c.opts.collectorTimeout(""))
+
+	return DiscoverTopology(session, seeds, func(addr string) (*mgo.Session, error) {
+		return c.opts.SessionManager.Session(c.nodeOpts(addr).toSessionOps())
+	})
+}
+
+// update replaces the nodes, keeping the collectors of the nodes which are still there. The
+// collectors of a router are replaced when the cluster-wide collectors move to or from it. The
+// caller must hold c.lock.
+func (c *TopologyCollector) update(nodes []Node) {
+	router := c.clusterWideRouter(nodes)
+	current := make(map[string]*discoveredNode, len(nodes))
+	for _, node := range nodes {
+		clusterWide := node.Role != "mongos" || node.Addr == router
+		existing, ok := c.nodes[node.Addr]
+		if ok && existing.clusterWide == clusterWide {
+			existing.node = node
+			current[node.Addr] = existing
+			continue
+		}
+		if ok {
+			existing.collector.Stop()
+		} else {
+			glog.Infof("Discovered %s (rs=%q, shard=%q, role=%q)", node.Addr, node.ReplicaSet, node.Shard, node.Role)
+		}
+
+		opts := c.nodeOpts(node.Addr)
+		if !clusterWide {
+			opts.Collectors = c.withoutClusterWide(opts.Collectors)
+		}
+		collector := NewMongodbCollector(opts)
+		if c.started {
+			collector.Start()
+		}
+		current[node.Addr] = &discoveredNode{node: node, collector: collector, clusterWide: clusterWide}
+	}
+	for addr, node := range c.nodes {
+		if _, ok := current[addr]; !ok {
+			node.collector.Stop()
+			glog.Infof("%s left the topology", addr)
+		}
+	}
+	c.nodes = current
+}
+
+// clusterWideRouter returns the router the cluster-wide collectors run on: the current one while
+// it stays in the topology, the first one by address otherwise. The caller must hold c.lock.
+func (c *TopologyCollector) clusterWideRouter(nodes []Node) string {
+	router := ""
+	for _, node := range nodes {
+		if node.Role != "mongos" {
+			continue
+		}
+		if existing, ok := c.nodes[node.Addr]; ok && existing.clusterWide && existing.node.Role == "mongos" {
+			return node.Addr
+		}
+		if router == "" || node.Addr < router {
+			router = node.Addr
+		}
+	}
+	return router
+}
+
+// withoutClusterWide returns the collectors besides the cluster-wide ones.
+func (c *TopologyCollector) withoutClusterWide(collectors []string) []string {
+	var result []string
+	for _, name := range collectors {
+		if wide, ok := c.template.collectors[name].(clusterWide); ok && wide.clusterWide() {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// nodeOpts returns the options to scrape a single node.
+func (c *TopologyCollector) nodeOpts(addr string) MongodbCollectorOpts {
+	opts := c.opts
+	opts.URI = nodeURI(c.opts.URI, addr)
+	return opts
+}
+
+// labeledMetric adds the labels of a discovered node to a metric of its collector. Labels the
+// metric already has, like the rs of connpoolstats, are kept, and the label of the node is renamed
+// to node_<name> instead, e.g. node_rs.
+type labeledMetric struct {
+	prometheus.Metric
+	labels []*dto.LabelPair
+}
+
+func (m *labeledMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(out.Label))
+	for _, label := range out.Label {
+		existing[label.GetName()] = true
+	}
+	for _, label := range m.labels {
+		if existing[label.GetName()] {
+			label = &dto.LabelPair{Name: proto.String("node_" + label.GetName()), Value: label.Value}
+		}
+		out.Label = append(out.Label, label)
+	}
+	sort.Sort(prometheus.LabelPairSorter(out.Label))
+	return nil
+}
@@ -19,153 +19,131 @@ import (
 )
 
 var (
-	wtBlockManagerBlocksTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_blockmanager",
-		Name:      "blocks_total",
-		Help:      "The total number of blocks read by the WiredTiger BlockManager",
-	}, []string{"type"})
-	wtBlockManagerBytesTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_blockmanager",
-		Name:      "bytes_total",
-		Help:      "The total number of bytes read by the WiredTiger BlockManager",
-	}, []string{"type"})
+	wtBlockManagerBlocksTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_blockmanager", "blocks_total"),
+		"The total number of blocks read by the WiredTiger BlockManager",
+		[]string{"type"}, nil,
+	)
+	wtBlockManagerBytesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_blockmanager", "bytes_total"),
+		"The total number of bytes read by the WiredTiger BlockManager",
+		[]string{"type"}, nil,
+	)
 )
 
 var (
-	wtCachePages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "pages",
-		Help:      "The current number of pages in the WiredTiger Cache",
-	}, []string{"type"})
-	wtCachePagesTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "pages_total",
-		Help:      "The total number of pages read into/from the WiredTiger Cache",
-	}, []string{"type"})
-	wtCacheBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "bytes",
-		Help:      "The current size of data in the WiredTiger Cache in bytes",
-	}, []string{"type"})
-	wtCacheMaxBytes = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "max_bytes",
-		Help:      "The maximum size of data in the WiredTiger Cache in bytes",
-	})
-	wtCacheBytesTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "bytes_total",
-		Help:      "The total number of bytes read into/from the WiredTiger Cache",
-	}, []string{"type"})
-	wtCacheEvictedTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "evicted_total",
-		Help:      "The total number of pages evicted from the WiredTiger Cache",
-	}, []string{"type"})
-	wtCachePercentOverhead = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_cache",
-		Name:      "overhead_percent",
-		Help:      "The percentage overhead of the WiredTiger Cache",
-	})
+	wtCachePages = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "pages"),
+		"The current number of pages in the WiredTiger Cache",
+		[]string{"type"}, nil,
+	)
+	wtCachePagesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "pages_total"),
+		"The total number of pages read into/from the WiredTiger Cache",
+		[]string{"type"}, nil,
+	)
+	wtCacheBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "bytes"),
+		"The current size of data in the WiredTiger Cache in bytes",
+		[]string{"type"}, nil,
+	)
+	wtCacheMaxBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "max_bytes"),
+		"The maximum size of data in the WiredTiger Cache in bytes",
+		nil, nil,
+	)
+	wtCacheBytesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "bytes_total"),
+		"The total number of bytes read into/from the WiredTiger Cache",
+		[]string{"type"}, nil,
+	)
+	wtCacheEvictedTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "evicted_total"),
+		"The total number of pages evicted from the WiredTiger Cache",
+		[]string{"type"}, nil,
+	)
+	wtCachePercentOverhead = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_cache", "overhead_percent"),
+		"The percentage overhead of the WiredTiger Cache",
+		nil, nil,
+	)
 )
 
 var (
-	wtTransactionsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_transactions",
-		Name:      "total",
-		Help:      "The total number of transactions WiredTiger has handled",
-	}, []string{"type"})
-	wtTransactionsTotalCheckpointMs = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_transactions",
-		Name:      "checkpoint_milliseconds_total",
-		Help:      "The total time in milliseconds transactions have checkpointed in WiredTiger",
-	})
-	wtTransactionsCheckpointMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_transactions",
-		Name:      "checkpoint_milliseconds",
-		Help:      "The time in milliseconds transactions have checkpointed in WiredTiger",
-	}, []string{"type"})
-	wtTransactionsCheckpointsRunning = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_transactions",
-		Name:      "running_checkpoints",
-		Help:      "The number of currently running checkpoints in WiredTiger",
-	})
+	wtTransactionsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_transactions", "total"),
+		"The total number of transactions WiredTiger has handled",
+		[]string{"type"}, nil,
+	)
+	wtTransactionsTotalCheckpointMs = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_transactions", "checkpoint_milliseconds_total"),
+		"The total time in milliseconds transactions have checkpointed in WiredTiger",
+		nil, nil,
+	)
+	wtTransactionsCheckpointMs = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_transactions", "checkpoint_milliseconds"),
+		"The time in milliseconds transactions have checkpointed in WiredTiger",
+		[]string{"type"}, nil,
+	)
+	wtTransactionsCheckpointsRunning = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_transactions", "running_checkpoints"),
+		"The number of currently running checkpoints in WiredTiger",
+		nil, nil,
+	)
 )
 
 var (
-	wtLogRecordsScannedTotal = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_log",
-		Name:      "records_scanned_total",
-		Help:      "The total number of records scanned by log scan in the WiredTiger log",
-	})
-	wtLogRecordsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_log",
-		Name:      "records_total",
-		Help:      "The total number of compressed/uncompressed records written to the WiredTiger log",
-	}, []string{"type"})
-	wtLogBytesTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_log",
-		Name:      "bytes_total",
-		Help:      "The total number of bytes written to the WiredTiger log",
-	}, []string{"type"})
-	wtLogOperationsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_log",
-		Name:      "operations_total",
-		Help:      "The total number of WiredTiger log operations",
-	}, []string{"type"})
+	wtLogRecordsScannedTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_log", "records_scanned_total"),
+		"The total number of records scanned by log scan in the WiredTiger log",
+		nil, nil,
+	)
+	wtLogRecordsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_log", "records_total"),
+		"The total number of compressed/uncompressed records written to the WiredTiger log",
+		[]string{"type"}, nil,
+	)
+	wtLogBytesTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_log", "bytes_total"),
+		"The total number of bytes written to the WiredTiger log",
+		[]string{"type"}, nil,
+	)
+	wtLogOperationsTotal = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_log", "operations_total"),
+		"The total number of WiredTiger log operations",
+		[]string{"type"}, nil,
+	)
 )
 
 var (
-	wtOpenCursors = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_session",
-		Name:      "open_cursors_total",
-		Help:      "The total number of cursors opened in WiredTiger",
-	})
-	wtOpenSessions = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_session",
-		Name:      "open_sessions_total",
-		Help:      "The total number of sessions opened in WiredTiger",
-	})
+	wtOpenCursors = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_session", "open_cursors_total"),
+		"The total number of cursors opened in WiredTiger",
+		nil, nil,
+	)
+	wtOpenSessions = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_session", "open_sessions_total"),
+		"The total number of sessions opened in WiredTiger",
+		nil, nil,
+	)
 )
 
 var (
-	wtConcurrentTransactionsOut = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_concurrent_transactions",
-		Name:      "out_tickets",
-		Help:      "The number of tickets that are currently in use (out) in WiredTiger",
-	}, []string{"type"})
-	wtConcurrentTransactionsAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_concurrent_transactions",
-		Name:      "available_tickets",
-		Help:      "The number of tickets that are available in WiredTiger",
-	}, []string{"type"})
-	wtConcurrentTransactionsTotalTickets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: Namespace,
-		Subsystem: "wiredtiger_concurrent_transactions",
-		Name:      "total_tickets",
-		Help:      "The total number of tickets that are available in WiredTiger",
-	}, []string{"type"})
+	wtConcurrentTransactionsOut = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_concurrent_transactions", "out_tickets"),
+		"The number of tickets that are currently in use (out) in WiredTiger",
+		[]string{"type"}, nil,
+	)
+	wtConcurrentTransactionsAvailable = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_concurrent_transactions", "available_tickets"),
+		"The number of tickets that are available in WiredTiger",
+		[]string{"type"}, nil,
+	)
+	wtConcurrentTransactionsTotalTickets = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "wiredtiger_concurrent_transactions", "total_tickets"),
+		"The total number of tickets that are available in WiredTiger",
+		[]string{"type"}, nil,
+	)
 )
 
 // blockmanager stats
@@ -180,18 +158,18 @@ type WTBlockManagerStats struct {
 }
 
 func (stats *WTBlockManagerStats) Export(ch chan<- prometheus.Metric) {
-	wtBlockManagerBlocksTotal.WithLabelValues("read").Set(stats.BlocksRead)
-	wtBlockManagerBlocksTotal.WithLabelValues("read_mapped").Set(stats.MappedBlocksRead)
-	wtBlockManagerBlocksTotal.WithLabelValues("pre_loaded").Set(stats.BlocksPreLoaded)
-	wtBlockManagerBlocksTotal.WithLabelValues("written").Set(stats.BlocksWritten)
-	wtBlockManagerBytesTotal.WithLabelValues("read").Set(stats.BytesRead)
-	wtBlockManagerBytesTotal.WithLabelValues("read_mapped").Set(stats.MappedBytesRead)
-	wtBlockManagerBytesTotal.WithLabelValues("written").Set(stats.BytesWritten)
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBlocksTotal, prometheus.GaugeValue, stats.BlocksRead, "read")
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBlocksTotal, prometheus.GaugeValue, stats.MappedBlocksRead, "read_mapped")
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBlocksTotal, prometheus.GaugeValue, stats.BlocksPreLoaded, "pre_loaded")
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBlocksTotal, prometheus.GaugeValue, stats.BlocksWritten, "written")
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBytesTotal, prometheus.GaugeValue, stats.BytesRead, "read")
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBytesTotal, prometheus.GaugeValue, stats.MappedBytesRead, "read_mapped")
+	ch <- prometheus.MustNewConstMetric(wtBlockManagerBytesTotal, prometheus.GaugeValue, stats.BytesWritten, "written")
 }
 
 func (stats *WTBlockManagerStats) Describe(ch chan<- *prometheus.Desc) {
-	wtBlockManagerBlocksTotal.Describe(ch)
-	wtBlockManagerBytesTotal.Describe(ch)
+	ch <- wtBlockManagerBlocksTotal
+	ch <- wtBlockManagerBytesTotal
 }
 
 // cache stats
@@ -213,29 +191,29 @@ type WTCacheStats struct {
 }
 
 func (stats *WTCacheStats) Export(ch chan<- prometheus.Metric) {
-	wtCachePagesTotal.WithLabelValues("read").Set(stats.PagesReadInto)
-	wtCachePagesTotal.WithLabelValues("written").Set(stats.PagesWrittenFrom)
-	wtCacheBytesTotal.WithLabelValues("read").Set(stats.BytesReadInto)
-	wtCacheBytesTotal.WithLabelValues("written").Set(stats.BytesWrittenFrom)
-	wtCacheEvictedTotal.WithLabelValues("modified").Set(stats.EvictedModified)
-	wtCacheEvictedTotal.WithLabelValues("unmodified").Set(stats.EvictedUnmodified)
-	wtCachePages.WithLabelValues("total").Set(stats.PagesTotal)
-	wtCachePages.WithLabelValues("dirty").Set(stats.PagesDirty)
-	wtCacheBytes.WithLabelValues("total").Set(stats.BytesTotal)
-	wtCacheBytes.WithLabelValues("dirty").Set(stats.BytesDirty)
-	wtCacheBytes.WithLabelValues("internal_pages").Set(stats.BytesInternalPages)
-	wtCacheBytes.WithLabelValues("leaf_pages").Set(stats.BytesLeafPages)
-	wtCacheMaxBytes.Set(stats.MaxBytes)
-	wtCachePercentOverhead.Set(stats.PercentOverhead)
+	ch <- prometheus.MustNewConstMetric(wtCachePagesTotal, prometheus.GaugeValue, stats.PagesReadInto, "read")
+	ch <- prometheus.MustNewConstMetric(wtCachePagesTotal, prometheus.GaugeValue, stats.PagesWrittenFrom, "written")
+	ch <- prometheus.MustNewConstMetric(wtCacheBytesTotal, prometheus.GaugeValue, stats.BytesReadInto, "read")
+	ch <- prometheus.MustNewConstMetric(wtCacheBytesTotal, prometheus.GaugeValue, stats.BytesWrittenFrom, "written")
+	ch <- prometheus.MustNewConstMetric(wtCacheEvictedTotal, prometheus.GaugeValue, stats.EvictedModified, "modified")
+	ch <- prometheus.MustNewConstMetric(wtCacheEvictedTotal, prometheus.GaugeValue, stats.EvictedUnmodified, "unmodified")
+	ch <- prometheus.MustNewConstMetric(wtCachePages, prometheus.GaugeValue, stats.PagesTotal, "total")
+	ch <- prometheus.MustNewConstMetric(wtCachePages, prometheus.GaugeValue, stats.PagesDirty, "dirty")
+	ch <- prometheus.MustNewConstMetric(wtCacheBytes, prometheus.GaugeValue, stats.BytesTotal, "total")
+	ch <- prometheus.MustNewConstMetric(wtCacheBytes, prometheus.GaugeValue, stats.BytesDirty, "dirty")
+	ch <- prometheus.MustNewConstMetric(wtCacheBytes, prometheus.GaugeValue, stats.BytesInternalPages, "internal_pages")
+	ch <- prometheus.MustNewConstMetric(wtCacheBytes, prometheus.GaugeValue, stats.BytesLeafPages, "leaf_pages")
+	ch <- prometheus.MustNewConstMetric(wtCacheMaxBytes, prometheus.GaugeValue, stats.MaxBytes)
+	ch <- prometheus.MustNewConstMetric(wtCachePercentOverhead, prometheus.GaugeValue, stats.PercentOverhead)
 }
 
 func (stats *WTCacheStats) Describe(ch chan<- *prometheus.Desc) {
-	wtCachePagesTotal.Describe(ch)
-	wtCacheEvictedTotal.Describe(ch)
-	wtCachePages.Describe(ch)
-	wtCacheBytes.Describe(ch)
-	wtCacheMaxBytes.Describe(ch)
-	wtCachePercentOverhead.Describe(ch)
+	ch <- wtCachePagesTotal
+	ch <- wtCacheEvictedTotal
+	ch <- wtCachePages
+	ch <- wtCacheBytes
+	ch <- wtCacheMaxBytes
+	ch <- wtCachePercentOverhead
 }
 
 // log stats
@@ -258,25 +236,25 @@ type WTLogStats struct {
 }
 
 func (stats *WTLogStats) Export(ch chan<- prometheus.Metric) {
-	wtLogRecordsTotal.WithLabelValues("compressed").Set(stats.RecordsCompressed)
-	wtLogRecordsTotal.WithLabelValues("uncompressed").Set(stats.RecordsUncompressed)
-	wtLogBytesTotal.WithLabelValues("payload").Set(stats.BytesPayloadData)
-	wtLogBytesTotal.WithLabelValues("written").Set(stats.BytesWritten)
-	wtLogOperationsTotal.WithLabelValues("read").Set(stats.LogReads)
-	wtLogOperationsTotal.WithLabelValues("write").Set(stats.LogWrites)
-	wtLogOperationsTotal.WithLabelValues("scan").Set(stats.LogScans)
-	wtLogOperationsTotal.WithLabelValues("scan_double").Set(stats.LogScansDouble)
-	wtLogOperationsTotal.WithLabelValues("sync").Set(stats.LogSyncs)
-	wtLogOperationsTotal.WithLabelValues("sync_dir").Set(stats.LogSyncDirs)
-	wtLogOperationsTotal.WithLabelValues("flush").Set(stats.LogFlushes)
-	wtLogRecordsScannedTotal.Set(stats.RecordsProcessedLogScan)
+	ch <- prometheus.MustNewConstMetric(wtLogRecordsTotal, prometheus.GaugeValue, stats.RecordsCompressed, "compressed")
+	ch <- prometheus.MustNewConstMetric(wtLogRecordsTotal, prometheus.GaugeValue, stats.RecordsUncompressed, "uncompressed")
+	ch <- prometheus.MustNewConstMetric(wtLogBytesTotal, prometheus.GaugeValue, stats.BytesPayloadData, "payload")
+	ch <- prometheus.MustNewConstMetric(wtLogBytesTotal, prometheus.GaugeValue, stats.BytesWritten, "written")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogReads, "read")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogWrites, "write")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogScans, "scan")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogScansDouble, "scan_double")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogSyncs, "sync")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogSyncDirs, "sync_dir")
+	ch <- prometheus.MustNewConstMetric(wtLogOperationsTotal, prometheus.GaugeValue, stats.LogFlushes, "flush")
+	ch <- prometheus.MustNewConstMetric(wtLogRecordsScannedTotal, prometheus.GaugeValue, stats.RecordsProcessedLogScan)
 }
 
 func (stats *WTLogStats) Describe(ch chan<- *prometheus.Desc) {
-	wtLogRecordsTotal.Describe(ch)
-	wtLogBytesTotal.Describe(ch)
-	wtLogOperationsTotal.Describe(ch)
-	wtLogRecordsScannedTotal.Describe(ch)
+	ch <- wtLogRecordsTotal
+	ch <- wtLogBytesTotal
+	ch <- wtLogOperationsTotal
+	ch <- wtLogRecordsScannedTotal
 }
 
 // session stats
@@ -286,13 +264,13 @@ type WTSessionStats struct {
 }
 
 func (stats *WTSessionStats) Export(ch chan<- prometheus.Metric) {
-	wtOpenCursors.Set(stats.Cursors)
-	wtOpenSessions.Set(stats.Sessions)
+	ch <- prometheus.MustNewConstMetric(wtOpenCursors, prometheus.GaugeValue, stats.Cursors)
+	ch <- prometheus.MustNewConstMetric(wtOpenSessions, prometheus.GaugeValue, stats.Sessions)
 }
 
 func (stats *WTSessionStats) Describe(ch chan<- *prometheus.Desc) {
-	wtOpenCursors.Describe(ch)
-	wtOpenSessions.Describe(ch)
+	ch <- wtOpenCursors
+	ch <- wtOpenSessions
 }
 
 // transaction stats
@@ -310,21 +288,21 @@ type WTTransactionStats struct {
 }
 
 func (stats *WTTransactionStats) Export(ch chan<- prometheus.Metric) {
-	wtTransactionsTotal.WithLabelValues("begins").Set(stats.Begins)
-	wtTransactionsTotal.WithLabelValues("checkpoints").Set(stats.Checkpoints)
-	wtTransactionsTotal.WithLabelValues("committed").Set(stats.Committed)
-	wtTransactionsTotal.WithLabelValues("rolledback").Set(stats.RolledBack)
-	wtTransactionsCheckpointMs.WithLabelValues("min").Set(stats.CheckpointMinMs)
-	wtTransactionsCheckpointMs.WithLabelValues("max").Set(stats.CheckpointMaxMs)
-	wtTransactionsTotalCheckpointMs.Set(stats.CheckpointTotalMs)
-	wtTransactionsCheckpointsRunning.Set(stats.CheckpointsRunning)
+	ch <- prometheus.MustNewConstMetric(wtTransactionsTotal, prometheus.GaugeValue, stats.Begins, "begins")
+	ch <- prometheus.MustNewConstMetric(wtTransactionsTotal, prometheus.GaugeValue, stats.Checkpoints, "checkpoints")
+	ch <- prometheus.MustNewConstMetric(wtTransactionsTotal, prometheus.GaugeValue, stats.Committed, "committed")
+	ch <- prometheus.MustNewConstMetric(wtTransactionsTotal, prometheus.GaugeValue, stats.RolledBack, "rolledback")
+	ch <- prometheus.MustNewConstMetric(wtTransactionsCheckpointMs, prometheus.GaugeValue, stats.CheckpointMinMs, "min")
+	ch <- prometheus.MustNewConstMetric(wtTransactionsCheckpointMs, prometheus.GaugeValue, stats.CheckpointMaxMs, "max")
+	ch <- prometheus.MustNewConstMetric(wtTransactionsTotalCheckpointMs, prometheus.GaugeValue, stats.CheckpointTotalMs)
+	ch <- prometheus.MustNewConstMetric(wtTransactionsCheckpointsRunning, prometheus.GaugeValue, stats.CheckpointsRunning)
 }
 
 func (stats *WTTransactionStats) Describe(ch chan<- *prometheus.Desc) {
-	wtTransactionsTotal.Describe(ch)
-	wtTransactionsTotalCheckpointMs.Describe(ch)
-	wtTransactionsCheckpointMs.Describe(ch)
-	wtTransactionsCheckpointsRunning.Describe(ch)
+	ch <- wtTransactionsTotal
+	ch <- wtTransactionsTotalCheckpointMs
+	ch <- wtTransactionsCheckpointMs
+	ch <- wtTransactionsCheckpointsRunning
 }
 
 // concurrenttransaction stats
@@ -340,18 +318,18 @@ type WTConcurrentTransactionsStats struct {
 }
 
 func (stats *WTConcurrentTransactionsStats) Export(ch chan<- prometheus.Metric) {
-	wtConcurrentTransactionsOut.WithLabelValues("read").Set(stats.Read.Out)
-	wtConcurrentTransactionsOut.WithLabelValues("write").Set(stats.Write.Out)
-	wtConcurrentTransactionsAvailable.WithLabelValues("read").Set(stats.Read.Available)
-	wtConcurrentTransactionsAvailable.WithLabelValues("write").Set(stats.Write.Available)
-	wtConcurrentTransactionsTotalTickets.WithLabelValues("read").Set(stats.Read.TotalTickets)
-	wtConcurrentTransactionsTotalTickets.WithLabelValues("write").Set(stats.Write.TotalTickets)
+	ch <- prometheus.MustNewConstMetric(wtConcurrentTransactionsOut, prometheus.GaugeValue, stats.Read.Out, "read")
+	ch <- prometheus.MustNewConstMetric(wtConcurrentTransactionsOut, prometheus.GaugeValue, stats.Write.Out, "write")
+	ch <- prometheus.MustNewConstMetric(wtConcurrentTransactionsAvailable, prometheus.GaugeValue, stats.Read.Available, "read")
+	ch <- prometheus.MustNewConstMetric(wtConcurrentTransactionsAvailable, prometheus.GaugeValue, stats.Write.Available, "write")
+	ch <- prometheus.MustNewConstMetric(wtConcurrentTransactionsTotalTickets, prometheus.GaugeValue, stats.Read.TotalTickets, "read")
+	ch <- prometheus.MustNewConstMetric(wtConcurrentTransactionsTotalTickets, prometheus.GaugeValue, stats.Write.TotalTickets, "write")
 }
 
 func (stats *WTConcurrentTransactionsStats) Describe(ch chan<- *prometheus.Desc) {
-	wtConcurrentTransactionsOut.Describe(ch)
-	wtConcurrentTransactionsAvailable.Describe(ch)
-	wtConcurrentTransactionsTotalTickets.Describe(ch)
+	ch <- wtConcurrentTransactionsOut
+	ch <- wtConcurrentTransactionsAvailable
+	ch <- wtConcurrentTransactionsTotalTickets
 }
 
 // WiredTiger stats
@@ -405,31 +383,4 @@ func (stats *WiredTigerStats) Export(ch chan<- prometheus.Metric) {
 		stats.ConcurrentTransactions.Export(ch)
 	}
 
-	wtBlockManagerBlocksTotal.Collect(ch)
-	wtBlockManagerBytesTotal.Collect(ch)
-
-	wtCachePagesTotal.Collect(ch)
-	wtCacheBytesTotal.Collect(ch)
-	wtCacheEvictedTotal.Collect(ch)
-	wtCachePages.Collect(ch)
-	wtCacheBytes.Collect(ch)
-	wtCacheMaxBytes.Collect(ch)
-	wtCachePercentOverhead.Collect(ch)
-
-	wtTransactionsTotal.Collect(ch)
-	wtTransactionsTotalCheckpointMs.Collect(ch)
-	wtTransactionsCheckpointMs.Collect(ch)
-	wtTransactionsCheckpointsRunning.Collect(ch)
-
-	wtLogRecordsTotal.Collect(ch)
-	wtLogBytesTotal.Collect(ch)
-	wtLogOperationsTotal.Collect(ch)
-	wtLogRecordsScannedTotal.Collect(ch)
-
-	wtOpenCursors.Collect(ch)
-	wtOpenSessions.Collect(ch)
-
-	wtConcurrentTransactionsOut.Collect(ch)
-	wtConcurrentTransactionsAvailable.Collect(ch)
-	wtConcurrentTransactionsTotalTickets.Collect(ch)
 }
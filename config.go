@@ -0,0 +1,438 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/dcu/mongodb_exporter/collector"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// config is the content of the file given by --config.file. Everything left out falls back to the
+// command line flags.
+type config struct {
+	Web     webConfig    `yaml:"web"`
+	MongoDB targetConfig `yaml:"mongodb"`
+	// Groups are the serverStatus groups to export.
+	Groups                []string                    `yaml:"groups"`
+	CollectorTimeout      time.Duration               `yaml:"collector_timeout"`
+	CollectInterval       time.Duration               `yaml:"collect_interval"`
+	DiscoverTopology      *bool                       `yaml:"discover_topology"`
+	DiscoveryInterval     time.Duration               `yaml:"discovery_interval"`
+	Collectors            map[string]*collectorConfig `yaml:"collectors"`
+	ServerStatusDiscovery *bool                       `yaml:"serverstatus_discovery"`
+	MetricDefinitionsFile string                      `yaml:"metric_definitions_file"`
+	// Modules replace the ones of --probe.modules-file when given.
+	Modules map[string]*probeModule `yaml:"modules"`
+}
+
+// webConfig are the HTTP settings. Only the basic auth credentials are applied on reload, the
+// listener keeps its address, paths and TLS files until the exporter is restarted.
+type webConfig struct {
+	ListenAddress string          `yaml:"listen_address"`
+	MetricsPath   string          `yaml:"metrics_path"`
+	ProbePath     string          `yaml:"probe_path"`
+	TLSCert       string          `yaml:"tls_cert"`
+	TLSPrivateKey string          `yaml:"tls_private_key"`
+	TLSClientCa   string          `yaml:"tls_client_ca"`
+	BasicAuth     basicAuthConfig `yaml:"basic_auth"`
+}
+
+type basicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// targetConfig is the target scraped on the metrics path.
+type targetConfig struct {
+	URI              string `yaml:"uri"`
+	connectionConfig `yaml:",inline"`
+}
+
+// collectorConfig are the options of a single collector.
+type collectorConfig struct {
+	Enabled *bool         `yaml:"enabled"`
+	Timeout time.Duration `yaml:"timeout"`
+	// Parameters are the setParameters collected by the parameter collector.
+	Parameters []string `yaml:"parameters"`
+	// The options of the oplog_tail collector, see collector.OplogTailOpts.
+	IncludeNamespaces   string        `yaml:"include_namespaces"`
+	ExcludeNamespaces   string        `yaml:"exclude_namespaces"`
+	AggregateByDatabase *bool         `yaml:"aggregate_by_database"`
+	MaxNamespaces       *int          `yaml:"max_namespaces"`
+	Backend             string        `yaml:"backend"`
+	StateFile           string        `yaml:"state_file"`
+	CheckpointInterval  time.Duration `yaml:"checkpoint_interval"`
+	// Window is how far back the oplog_sample collector aggregates the oplog.
+	Window time.Duration `yaml:"window"`
+}
+
+// hasOplogTailOptions tells whether any of the options of the oplog_tail collector is set.
+func (c *collectorConfig) hasOplogTailOptions() bool {
+	return c.IncludeNamespaces != "" || c.ExcludeNamespaces != "" || c.AggregateByDatabase != nil || c.MaxNamespaces != nil || c.Backend != "" ||
+		c.StateFile != "" || c.CheckpointInterval != 0
+}
+
+// applyOplogTail applies the options of the oplog_tail collector which are set.
+func (c *collectorConfig) applyOplogTail(opts *collector.OplogTailOpts) {
+	if c.IncludeNamespaces != "" {
+		opts.IncludeNamespaces = c.IncludeNamespaces
+	}
+	if c.ExcludeNamespaces != "" {
+		opts.ExcludeNamespaces = c.ExcludeNamespaces
+	}
+	if c.AggregateByDatabase != nil {
+		opts.AggregateByDatabase = *c.AggregateByDatabase
+	}
+	if c.MaxNamespaces != nil {
+		opts.MaxNamespaces = *c.MaxNamespaces
+	}
+	if c.Backend != "" {
+		opts.Backend = c.Backend
+	}
+	if c.StateFile != "" {
+		opts.StateFile = c.StateFile
+	}
+	if c.CheckpointInterval != 0 {
+		opts.CheckpointInterval = c.CheckpointInterval
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func webConfigFromFlags() webConfig {
+	return webConfig{
+		ListenAddress: *listenAddressFlag,
+		MetricsPath:   *metricsPathFlag,
+		ProbePath:     *probePathFlag,
+		TLSCert:       *webTLSCert,
+		TLSPrivateKey: *webTLSPrivateKey,
+		TLSClientCa:   *webTLSClientCa,
+		BasicAuth: basicAuthConfig{
+			Username: *authUserFlag,
+			Password: *authPassFlag,
+		},
+	}
+}
+
+// override overrides the settings with the fields of the given config which are set.
+func (web *webConfig) override(in webConfig) {
+	if in.ListenAddress != "" {
+		web.ListenAddress = in.ListenAddress
+	}
+	if in.MetricsPath != "" {
+		web.MetricsPath = in.MetricsPath
+	}
+	if in.ProbePath != "" {
+		web.ProbePath = in.ProbePath
+	}
+	if in.TLSCert != "" {
+		web.TLSCert = in.TLSCert
+	}
+	if in.TLSPrivateKey != "" {
+		web.TLSPrivateKey = in.TLSPrivateKey
+	}
+	if in.TLSClientCa != "" {
+		web.TLSClientCa = in.TLSClientCa
+	}
+	if in.BasicAuth.Username != "" || in.BasicAuth.Password != "" {
+		web.BasicAuth = in.BasicAuth
+	}
+}
+
+// exporterState is everything that is replaced when the configuration is reloaded. Requests take
+// the state once, so that a scrape in flight finishes with the configuration it started with.
+type exporterState struct {
+	web       webConfig
+	opts      collector.MongodbCollectorOpts
+	collector scrapeCollector
+	modules   map[string]*probeModule
+}
+
+// scrapeCollector collects the metrics served on the metrics path, either of the configured target
+// or of the topology discovered from it.
+type scrapeCollector interface {
+	prometheus.Collector
+	Start()
+	Stop()
+}
+
+var state atomic.Value
+
+func currentState() *exporterState {
+	return state.Load().(*exporterState)
+}
+
+// newExporterState resolves the configuration on top of the flags and checks it, nothing is
+// replaced when it returns an error.
+func newExporterState(cfg *config) (*exporterState, error) {
+	web := webConfigFromFlags()
+	web.override(cfg.Web)
+
+	opts := collectorOptsFromFlags()
+	if cfg.MongoDB.URI != "" {
+		opts.URI = cfg.MongoDB.URI
+	}
+	cfg.MongoDB.apply(&opts)
+
+	if cfg.Groups != nil {
+		opts.EnabledGroups = make(map[string]bool)
+		for _, group := range cfg.Groups {
+			opts.EnabledGroups[group] = true
+		}
+	}
+	if cfg.CollectorTimeout != 0 {
+		opts.CollectorTimeout = cfg.CollectorTimeout
+	}
+	if cfg.CollectInterval != 0 {
+		opts.CollectInterval = cfg.CollectInterval
+	}
+	if cfg.ServerStatusDiscovery != nil {
+		opts.DiscoverServerStatus = *cfg.ServerStatusDiscovery
+	}
+	if cfg.MetricDefinitionsFile != "" {
+		definitions, err := collector.LoadMetricDefinitions(cfg.MetricDefinitionsFile)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load metric definitions from %s: %s", cfg.MetricDefinitionsFile, err)
+		}
+		opts.MetricDefinitions = definitions
+	}
+	if err := applyCollectorConfigs(&opts, cfg.Collectors); err != nil {
+		return nil, err
+	}
+
+	modules := cfg.Modules
+	if modules == nil {
+		var err error
+		if modules, err = loadProbeModules(*probeModulesFileFlag); err != nil {
+			return nil, fmt.Errorf("couldn't load probe modules from %s: %s", *probeModulesFileFlag, err)
+		}
+	}
+	if err := validateProbeModules(modules, opts); err != nil {
+		return nil, err
+	}
+
+	discoverTopology := *mongodbDiscoverTopology
+	if cfg.DiscoverTopology != nil {
+		discoverTopology = *cfg.DiscoverTopology
+	}
+	discoveryInterval := *mongodbDiscoveryInterval
+	if cfg.DiscoveryInterval != 0 {
+		discoveryInterval = cfg.DiscoveryInterval
+	}
+
+	var scrape scrapeCollector
+	if discoverTopology {
+		scrape = collector.NewTopologyCollector(opts, discoveryInterval)
+	} else {
+		scrape = collector.NewMongodbCollector(opts)
+	}
+
+	return &exporterState{
+		web:       web,
+		opts:      opts,
+		collector: scrape,
+		modules:   modules,
+	}, nil
+}
+
+// applyCollectorConfigs enables or disables the configured collectors and applies their options.
+func applyCollectorConfigs(opts *collector.MongodbCollectorOpts, configs map[string]*collectorConfig) error {
+	registered := collector.Collectors()
+	current := opts.Collectors
+	if current == nil {
+		current = collector.EnabledCollectors()
+	}
+	enabled := make(map[string]bool)
+	for _, name := range current {
+		enabled[name] = true
+	}
+	timeouts := make(map[string]time.Duration)
+	for name, timeout := range opts.CollectorTimeouts {
+		timeouts[name] = timeout
+	}
+
+	for name, c := range configs {
+		if _, ok := registered[name]; !ok {
+			return fmt.Errorf("unknown collector %q", name)
+		}
+		if c == nil {
+			continue
+		}
+		if c.Enabled != nil {
+			enabled[name] = *c.Enabled
+		}
+		if c.Timeout != 0 {
+			timeouts[name] = c.Timeout
+		}
+		if c.Parameters != nil {
+			if name != "parameter" {
+				return fmt.Errorf("collector %q has no parameters", name)
+			}
+			opts.CollectParameters = strings.Join(c.Parameters, ",")
+		}
+		if c.hasOplogTailOptions() {
+			if name != "oplog_tail" {
+				return fmt.Errorf("collector %q has no oplog tail options", name)
+			}
+			c.applyOplogTail(&opts.OplogTail)
+		}
+		if c.Window != 0 {
+			if name != "oplog_sample" {
+				return fmt.Errorf("collector %q has no window", name)
+			}
+			opts.OplogSampleWindow = c.Window
+		}
+	}
+	if err := opts.OplogTail.Validate(); err != nil {
+		return fmt.Errorf("collector \"oplog_tail\": %s", err)
+	}
+
+	names := []string{}
+	for name, on := range enabled {
+		if on {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	opts.Collectors = names
+	opts.CollectorTimeouts = timeouts
+	return nil
+}
+
+var (
+	configReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mongodb_exporter",
+		Subsystem: "config",
+		Name:      "reloads_total",
+		Help:      "The total number of configuration reloads, by result.",
+	}, []string{"result"})
+	configLastReloadSuccessful = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "mongodb_exporter",
+		Subsystem: "config",
+		Name:      "last_reload_successful",
+		Help:      "Whether the last configuration reload succeeded.",
+	})
+	configLastReloadSuccessTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "mongodb_exporter",
+		Subsystem: "config",
+		Name:      "last_reload_success_timestamp_seconds",
+		Help:      "When the configuration was last loaded successfully.",
+	})
+)
+
+// reloadLock serializes the reloads, so that a slow one can't replace the state of a later one.
+var reloadLock sync.Mutex
+
+// reloadConfig loads --config.file again and swaps the state when it is valid.
+func reloadConfig() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	err := loadState()
+	if err != nil {
+		configReloadsTotal.WithLabelValues("failure").Inc()
+		configLastReloadSuccessful.Set(0)
+		return err
+	}
+	configReloadsTotal.WithLabelValues("success").Inc()
+	return nil
+}
+
+func loadState() error {
+	cfg, err := loadConfig(*configFileFlag)
+	if err != nil {
+		return fmt.Errorf("couldn't load %s: %s", *configFileFlag, err)
+	}
+	newState, err := newExporterState(cfg)
+	if err != nil {
+		return err
+	}
+
+	old, ok := state.Load().(*exporterState)
+	if ok {
+		basicAuth := newState.web.BasicAuth
+		newState.web.BasicAuth = old.web.BasicAuth
+		if newState.web != old.web {
+			glog.Warningf("The web settings, except basic auth, only change when the exporter is restarted")
+		}
+		newState.web = old.web
+		newState.web.BasicAuth = basicAuth
+	}
+
+	newState.collector.Start()
+	state.Store(newState)
+	if ok {
+		old.collector.Stop()
+	}
+	configLastReloadSuccessful.Set(1)
+	configLastReloadSuccessTimestamp.Set(float64(time.Now().Unix()))
+	return nil
+}
+
+// handleReloadSignals reloads the configuration whenever the process receives a SIGHUP.
+func handleReloadSignals() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := reloadConfig(); err != nil {
+				glog.Errorf("Couldn't reload the configuration: %s", err)
+				continue
+			}
+			glog.Info("Reloaded the configuration")
+		}
+	}()
+}
+
+// reloadHandler reloads the configuration on POST requests.
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := reloadConfig(); err != nil {
+		glog.Errorf("Couldn't reload the configuration: %s", err)
+		http.Error(w, fmt.Sprintf("Couldn't reload the configuration: %s", err), http.StatusInternalServerError)
+		return
+	}
+	glog.Info("Reloaded the configuration")
+}
+
+// reloadableCollector forwards to the collector of the current state, which changes on reload.
+type reloadableCollector struct{}
+
+func (reloadableCollector) Describe(ch chan<- *prometheus.Desc) {
+	currentState().collector.Describe(ch)
+}
+
+func (reloadableCollector) Collect(ch chan<- prometheus.Metric) {
+	currentState().collector.Collect(ch)
+}
@@ -4,20 +4,15 @@ go 1.16
 
 require (
 	github.com/beorn7/perks v0.0.0-20180321164747-3a771d992973 // indirect
-	github.com/cespare/xxhash v1.1.0 // indirect
 	github.com/globalsign/mgo v0.0.0-20190408092501-909ae7ce1a7b
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
-	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/golang/protobuf v1.2.0
 	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
 	github.com/pkg/errors v0.8.1-0.20180311214515-816c9085562c // indirect
 	github.com/prometheus/client_golang v0.8.0
-	github.com/prometheus/client_model v0.0.0-20180712105110-5c3871d89910 // indirect
+	github.com/prometheus/client_model v0.0.0-20180712105110-5c3871d89910
 	github.com/prometheus/common v0.0.0-20180801064454-c7de2306084e // indirect
 	github.com/prometheus/procfs v0.0.0-20180725123919-05ee40e3a273 // indirect
-	github.com/prometheus/prometheus v2.4.3-0.20181002125257-6932030aa1fd+incompatible
-	github.com/rwynn/gtm v0.0.0-20181025170138-ff28f9494a23
-	github.com/serialx/hashring v0.0.0-20180504054112-49a4782e9908 // indirect
-	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
 
-replace github.com/rwynn/gtm => github.com/jacksontj/gtm v0.0.0-20181025170138-ff28f9494a23
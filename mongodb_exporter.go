@@ -7,7 +7,10 @@ import (
 	slog "log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dcu/mongodb_exporter/collector"
@@ -24,9 +27,13 @@ func mongodbDefaultURI() string {
 	return "mongodb://localhost:27017"
 }
 
+// ddlEventsPath serves the recent DDL events seen by the oplog tailers.
+const ddlEventsPath = "/ddl-events"
+
 var (
 	listenAddressFlag = flag.String("web.listen-address", ":9001", "Address on which to expose metrics and web interface.")
 	metricsPathFlag   = flag.String("web.metrics-path", "/metrics", "Path under which to expose metrics.")
+	probePathFlag     = flag.String("web.probe-path", "/probe", "Path under which to expose metrics of arbitrary targets, e.g. /probe?target=host:port&module=name.")
 	webTLSCert        = flag.String("web.tls-cert", "", "Path to PEM file that conains the certificate (and optionally also the private key in PEM format).\n"+
 		"    \tThis should include the whole certificate chain.\n"+
 		"    \tIf provided: The web socket will be a HTTPS socket.\n"+
@@ -46,35 +53,111 @@ var (
 		"    \tIf not provided: System default CAs are used.")
 	mongodbTLSDisableHostnameValidation = flag.Bool("mongodb.tls-disable-hostname-validation", false, "Do hostname validation for server connection.")
 	mongodbTLSAuth                      = flag.Bool("mongodb.tls-auth", false, "Do TLS based authentication for server connection.")
-	enabledGroupsFlag                   = flag.String("groups.enabled", "asserts,durability,background_flushing,connections,extra_info,global_lock,index_counters,network,op_counters,op_counters_repl,memory,locks,metrics", "Comma-separated list of groups to use, for more info see: docs.mongodb.org/manual/reference/command/serverStatus/")
+	enabledGroupsFlag                   = flag.String("groups.enabled", collector.DefaultEnabledGroups, "Comma-separated list of serverStatus groups to export, the others are excluded from the serverStatus command. For more info see: docs.mongodb.org/manual/reference/command/serverStatus/")
 	authUserFlag                        = flag.String("auth.user", "", "Username for basic auth.")
 	authPassFlag                        = flag.String("auth.pass", "", "Password for basic auth.")
 	mongodbUserName                     = flag.String("mongodb.username", "", "Username to connect to Mongodb")
 	mongodbAuthMechanism                = flag.String("mongodb.mechanism", "", "auth mechanism to connect to Mongodb (ie: MONGODB-X509)")
-	mongodbCollectOplog                 = flag.Bool("mongodb.collect.oplog", true, "collect Mongodb Oplog status")
-	mongodbCollectOplogTail             = flag.Bool("mongodb.collect.oplog_tail", false, "tail Mongodb Oplog to get stats")
-	mongodbCollectReplSet               = flag.Bool("mongodb.collect.replset", true, "collect Mongodb replica set status")
-	mongodbCollectTopMetrics            = flag.Bool("mongodb.collect.top", false, "collect Mongodb Top metrics")
-	mongodbCollectDatabaseMetrics       = flag.Bool("mongodb.collect.database", false, "collect MongoDB database metrics")
-	mongodbCollectCollectionMetrics     = flag.Bool("mongodb.collect.collection", false, "Collect MongoDB collection metrics")
-	mongodbCollectProfileMetrics        = flag.Bool("mongodb.collect.profile", false, "Collect MongoDB profile metrics")
-	mongodbCollectConnPoolStats         = flag.Bool("mongodb.collect.connpoolstats", false, "Collect MongoDB connpoolstats")
-	mongodbCollectParameterMetrics      = flag.Bool("mongodb.collect.parameter", true, "Collect MongoDB parameter metrics")
 	mongodbCollectParameters            = flag.String("mongodb.collect.parameter.parameters", "cursorTimeoutMillis", "Comma-separated list of setParameters to collect values for")
+	mongodbOplogTailIncludeNamespaces   = flag.String("mongodb.collect.oplog_tail.include-namespaces", "", "Regular expression matching the namespaces whose oplog entries are counted by the oplog_tail and oplog_sample collectors, all of them when empty.")
+	mongodbOplogTailExcludeNamespaces   = flag.String("mongodb.collect.oplog_tail.exclude-namespaces", "", "Regular expression matching the namespaces whose oplog entries are not counted by the oplog_tail and oplog_sample collectors, e.g. ^config\\.|\\.system\\.")
+	mongodbOplogTailAggregateByDatabase = flag.Bool("mongodb.collect.oplog_tail.aggregate-by-database", false, "Label the oplog entries counted by the oplog_tail and oplog_sample collectors with their database instead of their namespace.")
+	mongodbOplogTailMaxNamespaces       = flag.Int("mongodb.collect.oplog_tail.max-namespaces", 1000, "Most distinct ns labels exported by the oplog_tail and oplog_sample collectors, the entries of the namespaces seen after are labeled __other__. 0 doesn't cap them.")
+	mongodbOplogTailStateFile           = flag.String("mongodb.collect.oplog_tail.state-file", "", "File where the oplog_tail collector saves its position, to resume from it after a restart. The position isn't saved when empty.")
+	mongodbOplogTailCheckpointInterval  = flag.Duration("mongodb.collect.oplog_tail.checkpoint-interval", 10*time.Second, "How often the oplog_tail collector saves its position to the state file.")
+	mongodbOplogTailDDLEvents           = flag.Int("mongodb.collect.oplog_tail.ddl-events", collector.DefaultDDLEventsCapacity, "Number of recent DDL events seen by the oplog_tail collector served as JSON on "+ddlEventsPath+".")
+	mongodbOplogTailBackend             = flag.String("mongodb.collect.oplog_tail.backend", collector.OplogTailBackendOplog, "How the oplog_tail collector reads the oplog entries: oplog tails local.oplog.rs, changestream watches a change stream of the whole cluster, which also works on mongos routers.")
+	mongodbOplogSampleWindow            = flag.Duration("mongodb.collect.oplog_sample.window", collector.DefaultOplogSampleWindow, "How far back the oplog_sample collector aggregates the oplog entries on every scrape.")
 	mongodbSocketTimeout                = flag.Duration("mongodb.socket-timeout", 0, "timeout for socket operations to mongodb")
 	mongodbMaxTimeMS                    = flag.Duration("mongodb.maxtimems", 0, "maxTimeMs set for blocking database commands")
+	mongodbCollectorTimeout             = flag.Duration("mongodb.collector-timeout", collector.DefaultCollectorTimeout, "how long each collector may take before its metrics are dropped from the scrape")
+	mongodbCollectorTimeouts            = flag.String("mongodb.collector-timeouts", "", "Comma-separated list of collector=duration overriding mongodb.collector-timeout for single collectors, e.g. collection=30s")
+	mongodbCollectInterval              = flag.Duration("mongodb.collect-interval", 0, "Collect in the background at this interval and serve the last complete collection on scrapes, instead of collecting on every scrape.")
+	mongodbDiscoverTopology             = flag.Bool("mongodb.discover-topology", false, "Use mongodb.uri as a seed: discover every member of its replica set, or every shard and config server behind a mongos, and scrape each of them directly.")
+	mongodbDiscoveryInterval            = flag.Duration("mongodb.discovery-interval", collector.DefaultDiscoveryInterval, "How often the topology is discovered again when mongodb.discover-topology is set.")
+	mongodbDiscoverServerStatus         = flag.Bool("mongodb.serverstatus-discovery", false, "Export every numeric and boolean serverStatus field which is not exported otherwise, named mongodb_ss_<path>.")
+	metricDefinitionsFileFlag           = flag.String("metrics.definitions-file", "", "Path to a groups.yml-style file defining extra metrics exported from serverStatus by their BSON paths.")
+	probeModulesFileFlag                = flag.String("probe.modules-file", "", "Path to a YAML file with named modules (credentials, TLS files, enabled collectors) used by the probe endpoint.")
+	configFileFlag                      = flag.String("config.file", "", "Path to a YAML configuration file overriding the flags. It is reloaded on SIGHUP or a POST to /-/reload.")
 	version                             = flag.Bool("version", false, "Print mongodb_exporter version")
 )
 
+// legacyCollectorFlags maps the flags which used to enable collectors, before they could be
+// enabled with --collector.<name>, to the names of the collectors.
+var legacyCollectorFlags = map[string]string{
+	"mongodb.collect.oplog":         "oplog",
+	"mongodb.collect.oplog_tail":    "oplog_tail",
+	"mongodb.collect.replset":       "replset",
+	"mongodb.collect.top":           "top",
+	"mongodb.collect.database":      "database",
+	"mongodb.collect.collection":    "collection",
+	"mongodb.collect.profile":       "profile",
+	"mongodb.collect.connpoolstats": "connpoolstats",
+	"mongodb.collect.parameter":     "parameter",
+}
+
+func init() {
+	defaults := collector.Collectors()
+	for flagName, name := range legacyCollectorFlags {
+		flag.Bool(flagName, defaults[name], "Deprecated, use --collector."+name+" or --no-collector."+name)
+	}
+}
+
+// enabledCollectors are the names of the collectors enabled by the flags.
+var enabledCollectors []string
+
+// parseEnabledCollectors returns the collectors enabled by the --collector.<name> flags, applying
+// the deprecated --mongodb.collect.* flags for the collectors whose new flags were not given.
+func parseEnabledCollectors() []string {
+	enabled := collector.Collectors()
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "collector.") || strings.HasPrefix(f.Name, "no-collector.") {
+			given[f.Name[strings.Index(f.Name, ".")+1:]] = true
+		}
+	})
+	flag.Visit(func(f *flag.Flag) {
+		name, ok := legacyCollectorFlags[f.Name]
+		if !ok || given[name] {
+			return
+		}
+		glog.Warningf("--%s is deprecated, use --collector.%s or --no-collector.%s", f.Name, name, name)
+		enabled[name] = f.Value.(flag.Getter).Get().(bool)
+	})
+
+	var names []string
+	for name, on := range enabled {
+		if on {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectorTimeouts is the parsed value of --mongodb.collector-timeouts.
+var collectorTimeouts map[string]time.Duration
+
+// metricDefinitions are loaded from --metrics.definitions-file.
+var metricDefinitions *collector.MetricDefinitions
+
+// sessionManager keeps the sessions to all the scraped and probed targets.
+var sessionManager = shared.NewSessionManager()
+
+// basicAuthHandler checks the basic auth credentials of the current configuration, if any.
 type basicAuthHandler struct {
-	handler  http.HandlerFunc
-	user     string
-	password string
+	handler http.HandlerFunc
 }
 
 func (h *basicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := currentState().web.BasicAuth
+	if auth.Username == "" || auth.Password == "" {
+		h.handler(w, r)
+		return
+	}
+
 	user, password, ok := r.BasicAuth()
-	if !ok || password != h.password || user != h.user {
+	if !ok || password != auth.Password || user != auth.Username {
 		w.Header().Set("WWW-Authenticate", "Basic realm=\"metrics\"")
 		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
 		return
@@ -83,51 +166,48 @@ func (h *basicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-func hasUserAndPassword() bool {
-	return *authUserFlag != "" && *authPassFlag != ""
+func withBasicAuth(handler http.Handler) http.Handler {
+	return &basicAuthHandler{handler: handler.ServeHTTP}
 }
 
 func prometheusHandler() http.Handler {
-	handler := prometheus.Handler()
-	if hasUserAndPassword() {
-		handler = &basicAuthHandler{
-			handler:  prometheus.Handler().ServeHTTP,
-			user:     *authUserFlag,
-			password: *authPassFlag,
-		}
-	}
-
-	return handler
+	return withBasicAuth(prometheus.Handler())
 }
 
 func startWebServer() {
 	handler := prometheusHandler()
+	web := currentState().web
 
 	registerCollector()
 
-	http.Handle(*metricsPathFlag, handler)
+	http.Handle(web.MetricsPath, handler)
+	http.Handle(web.ProbePath, withBasicAuth(probeHandler()))
+	http.Handle("/-/reload", withBasicAuth(http.HandlerFunc(reloadHandler)))
+	http.Handle(ddlEventsPath, withBasicAuth(collector.DDLEventsHandler()))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 <head><title>MongoDB Exporter</title></head>
 <body>
 <h1>MongoDB Exporter</h1>
-<p><a href='` + *metricsPathFlag + `'>Metrics</a></p>
+<p><a href='` + web.MetricsPath + `'>Metrics</a></p>
+<p><a href='` + web.ProbePath + `?target=localhost:27017'>Probe localhost:27017</a></p>
+<p><a href='` + ddlEventsPath + `'>Recent DDL events</a></p>
 </body>
 </html>`))
 	})
 
 	server := &http.Server{
-		Addr:     *listenAddressFlag,
+		Addr:     web.ListenAddress,
 		ErrorLog: createHTTPServerLogWrapper(),
 	}
 
 	var err error
-	if len(*webTLSCert) > 0 {
+	if len(web.TLSCert) > 0 {
 		clientValidation := "no"
-		if len(*webTLSClientCa) > 0 && len(*webTLSCert) > 0 {
-			certificates, err := shared.LoadCertificatesFrom(*webTLSClientCa)
+		if len(web.TLSClientCa) > 0 && len(web.TLSCert) > 0 {
+			certificates, err := shared.LoadCertificatesFrom(web.TLSClientCa)
 			if err != nil {
-				glog.Fatalf("Couldn't load client CAs from %s. Got: %s", *webTLSClientCa, err)
+				glog.Fatalf("Couldn't load client CAs from %s. Got: %s", web.TLSClientCa, err)
 			}
 			server.TLSConfig = &tls.Config{
 				ClientCAs:  certificates,
@@ -135,12 +215,12 @@ func startWebServer() {
 			}
 			clientValidation = "yes"
 		}
-		targetTLSPrivateKey := *webTLSPrivateKey
+		targetTLSPrivateKey := web.TLSPrivateKey
 		if len(targetTLSPrivateKey) <= 0 {
-			targetTLSPrivateKey = *webTLSCert
+			targetTLSPrivateKey = web.TLSCert
 		}
 		fmt.Printf("Listening on %s (scheme=HTTPS, secured=TLS, clientValidation=%s)\n", server.Addr, clientValidation)
-		err = server.ListenAndServeTLS(*webTLSCert, targetTLSPrivateKey)
+		err = server.ListenAndServeTLS(web.TLSCert, targetTLSPrivateKey)
 	} else {
 		fmt.Printf("Listening on %s (scheme=HTTP, secured=no, clientValidation=no)\n", server.Addr)
 		err = server.ListenAndServe()
@@ -151,30 +231,43 @@ func startWebServer() {
 	}
 }
 
+func collectorOptsFromFlags() collector.MongodbCollectorOpts {
+	return collector.MongodbCollectorOpts{
+		URI:                   *mongodbURIFlag,
+		TLSCertificateFile:    *mongodbTLSCert,
+		TLSPrivateKeyFile:     *mongodbTLSPrivateKey,
+		TLSCaFile:             *mongodbTLSCa,
+		TLSHostnameValidation: !(*mongodbTLSDisableHostnameValidation),
+		TLSAuth:               *mongodbTLSAuth,
+		Collectors:            enabledCollectors,
+		CollectParameters:     *mongodbCollectParameters,
+		UserName:              *mongodbUserName,
+		AuthMechanism:         *mongodbAuthMechanism,
+		SocketTimeout:         *mongodbSocketTimeout,
+		MaxTimeMS:             int64(*mongodbMaxTimeMS / time.Millisecond),
+		CollectorTimeout:      *mongodbCollectorTimeout,
+		MetricDefinitions:     metricDefinitions,
+		DiscoverServerStatus:  *mongodbDiscoverServerStatus,
+		CollectorTimeouts:     collectorTimeouts,
+		SessionManager:        sessionManager,
+		CollectInterval:       *mongodbCollectInterval,
+		OplogTail: collector.OplogTailOpts{
+			IncludeNamespaces:   *mongodbOplogTailIncludeNamespaces,
+			ExcludeNamespaces:   *mongodbOplogTailExcludeNamespaces,
+			AggregateByDatabase: *mongodbOplogTailAggregateByDatabase,
+			MaxNamespaces:       *mongodbOplogTailMaxNamespaces,
+			Backend:             *mongodbOplogTailBackend,
+			StateFile:           *mongodbOplogTailStateFile,
+			CheckpointInterval:  *mongodbOplogTailCheckpointInterval,
+		},
+		OplogSampleWindow: *mongodbOplogSampleWindow,
+	}
+}
+
 func registerCollector() {
-	mongodbCollector := collector.NewMongodbCollector(collector.MongodbCollectorOpts{
-		URI:                      *mongodbURIFlag,
-		TLSCertificateFile:       *mongodbTLSCert,
-		TLSPrivateKeyFile:        *mongodbTLSPrivateKey,
-		TLSCaFile:                *mongodbTLSCa,
-		TLSHostnameValidation:    !(*mongodbTLSDisableHostnameValidation),
-		TLSAuth:                  *mongodbTLSAuth,
-		CollectOplog:             *mongodbCollectOplog,
-		TailOplog:                *mongodbCollectOplogTail,
-		CollectReplSet:           *mongodbCollectReplSet,
-		CollectTopMetrics:        *mongodbCollectTopMetrics,
-		CollectDatabaseMetrics:   *mongodbCollectDatabaseMetrics,
-		CollectCollectionMetrics: *mongodbCollectCollectionMetrics,
-		CollectProfileMetrics:    *mongodbCollectProfileMetrics,
-		CollectConnPoolStats:     *mongodbCollectConnPoolStats,
-		CollectParameterMetrics:  *mongodbCollectParameterMetrics,
-		CollectParameters:        *mongodbCollectParameters,
-		UserName:                 *mongodbUserName,
-		AuthMechanism:            *mongodbAuthMechanism,
-		SocketTimeout:            *mongodbSocketTimeout,
-		MaxTimeMS:                int64(*mongodbMaxTimeMS / time.Millisecond),
-	})
-	prometheus.MustRegister(mongodbCollector)
+	prometheus.MustRegister(reloadableCollector{})
+	prometheus.MustRegister(sessionManager)
+	prometheus.MustRegister(configReloadsTotal, configLastReloadSuccessful, configLastReloadSuccessTimestamp)
 }
 
 type bufferedLogWriter struct {
@@ -198,5 +291,40 @@ func main() {
 	}
 	shared.ParseEnabledGroups(*enabledGroupsFlag)
 
+	enabledCollectors = parseEnabledCollectors()
+	collector.SetDDLEventsCapacity(*mongodbOplogTailDDLEvents)
+
+	var err error
+	if *metricDefinitionsFileFlag != "" {
+		if metricDefinitions, err = collector.LoadMetricDefinitions(*metricDefinitionsFileFlag); err != nil {
+			glog.Fatalf("Couldn't load metric definitions from %s. Got: %s", *metricDefinitionsFileFlag, err)
+		}
+	}
+	if collectorTimeouts, err = collector.ParseCollectorTimeouts(*mongodbCollectorTimeouts); err != nil {
+		glog.Fatalf("Couldn't parse --mongodb.collector-timeouts. Got: %s", err)
+	}
+	if err = loadState(); err != nil {
+		glog.Fatalf("Couldn't load the configuration. Got: %s", err)
+	}
+	handleReloadSignals()
+	handleShutdownSignals()
+
 	startWebServer()
 }
+
+// handleShutdownSignals stops the collectors and the oplog tailers before exiting on SIGINT and
+// SIGTERM.
+func handleShutdownSignals() {
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-term
+		glog.Infof("Received %s, shutting down", sig)
+		// Keep a reload from starting new collectors meanwhile.
+		reloadLock.Lock()
+		currentState().collector.Stop()
+		collector.StopOplogTailers()
+		glog.Flush()
+		os.Exit(0)
+	}()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dcu/mongodb_exporter/collector"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// connectionConfig describes how to connect to a target. Empty fields keep the values given on
+// the command line.
+type connectionConfig struct {
+	UserName                     string        `yaml:"username"`
+	Password                     string        `yaml:"password"`
+	AuthMechanism                string        `yaml:"auth_mechanism"`
+	TLSCertificateFile           string        `yaml:"tls_cert"`
+	TLSPrivateKeyFile            string        `yaml:"tls_private_key"`
+	TLSCaFile                    string        `yaml:"tls_ca"`
+	TLSDisableHostnameValidation *bool         `yaml:"tls_disable_hostname_validation"`
+	TLSAuth                      *bool         `yaml:"tls_auth"`
+	SocketTimeout                time.Duration `yaml:"socket_timeout"`
+	MaxTime                      time.Duration `yaml:"max_time"`
+}
+
+// apply overrides the connection options with the fields which are set.
+func (c *connectionConfig) apply(opts *collector.MongodbCollectorOpts) {
+	if c.UserName != "" {
+		opts.UserName = c.UserName
+	}
+	if c.Password != "" {
+		opts.Password = c.Password
+	}
+	if c.AuthMechanism != "" {
+		opts.AuthMechanism = c.AuthMechanism
+	}
+	if c.TLSCertificateFile != "" {
+		opts.TLSCertificateFile = c.TLSCertificateFile
+	}
+	if c.TLSPrivateKeyFile != "" {
+		opts.TLSPrivateKeyFile = c.TLSPrivateKeyFile
+	}
+	if c.TLSCaFile != "" {
+		opts.TLSCaFile = c.TLSCaFile
+	}
+	if c.TLSDisableHostnameValidation != nil {
+		opts.TLSHostnameValidation = !*c.TLSDisableHostnameValidation
+	}
+	if c.TLSAuth != nil {
+		opts.TLSAuth = *c.TLSAuth
+	}
+	if c.SocketTimeout != 0 {
+		opts.SocketTimeout = c.SocketTimeout
+	}
+	if c.MaxTime != 0 {
+		opts.MaxTimeMS = int64(c.MaxTime / time.Millisecond)
+	}
+}
+
+// probeModule describes how to connect to a probed target and what to collect from it.
+// Empty fields fall back to the configuration of the exporter.
+type probeModule struct {
+	connectionConfig  `yaml:",inline"`
+	Collectors        []string `yaml:"collectors"`
+	CollectParameters string   `yaml:"collect_parameters"`
+}
+
+// probeModules is the content of the file given by --probe.modules-file.
+type probeModules struct {
+	Modules map[string]*probeModule `yaml:"modules"`
+}
+
+func loadProbeModules(path string) (map[string]*probeModule, error) {
+	if path == "" {
+		return map[string]*probeModule{}, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &probeModules{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, err
+	}
+	return config.Modules, nil
+}
+
+// validateProbeModules checks that every module can be used on top of the given options.
+func validateProbeModules(modules map[string]*probeModule, base collector.MongodbCollectorOpts) error {
+	for name, module := range modules {
+		if module == nil {
+			return fmt.Errorf("module %q is empty", name)
+		}
+		if _, err := module.collectorOpts(base, "localhost"); err != nil {
+			return fmt.Errorf("module %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// probeTargetURI turns the target given in the probe request into a MongoDB URI.
+func probeTargetURI(target string) string {
+	if strings.HasPrefix(target, "mongodb://") {
+		return target
+	}
+	return "mongodb://" + target
+}
+
+// collectorOpts builds the collector options used to scrape the given target with this module,
+// on top of the options of the exporter. The credentials of the exporter are not used: the target
+// is chosen by whoever sends the request, so they only come from the module.
+func (module *probeModule) collectorOpts(base collector.MongodbCollectorOpts, target string) (collector.MongodbCollectorOpts, error) {
+	opts := base
+	opts.URI = probeTargetURI(target)
+	opts.UserName = ""
+	opts.Password = ""
+	opts.AuthMechanism = ""
+	opts.TLSCertificateFile = ""
+	opts.TLSPrivateKeyFile = ""
+	opts.TLSAuth = false
+	// The oplog tailer is never started for probes: it is a long running process, which would
+	// keep running for every target ever probed.
+	opts.Collectors = withoutCollector(opts.Collectors, "oplog_tail")
+	// Probes are collected once, for the request.
+	opts.CollectInterval = 0
+
+	module.apply(&opts)
+	if module.CollectParameters != "" {
+		opts.CollectParameters = module.CollectParameters
+	}
+
+	if module.Collectors == nil {
+		return opts, nil
+	}
+
+	registered := collector.Collectors()
+	for _, name := range module.Collectors {
+		if name == "oplog_tail" {
+			return opts, fmt.Errorf("collector %q is not supported for probes", name)
+		}
+		if _, ok := registered[name]; !ok {
+			return opts, fmt.Errorf("unknown collector %q", name)
+		}
+	}
+	opts.Collectors = module.Collectors
+
+	return opts, nil
+}
+
+func withoutCollector(collectors []string, name string) []string {
+	result := []string{}
+	for _, collector := range collectors {
+		if collector != name {
+			result = append(result, collector)
+		}
+	}
+	return result
+}
+
+// probeHandler serves the metrics of the target given in the request from a registry that only
+// lives for the duration of the request.
+func probeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := currentState()
+		params := r.URL.Query()
+		target := params.Get("target")
+		if target == "" {
+			http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+			return
+		}
+
+		module := &probeModule{}
+		moduleName := params.Get("module")
+		if moduleName != "" {
+			var ok bool
+			if module, ok = state.modules[moduleName]; !ok {
+				http.Error(w, fmt.Sprintf("Unknown module %q", moduleName), http.StatusBadRequest)
+				return
+			}
+		}
+
+		opts, err := module.collectorOpts(state.opts, target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		glog.V(1).Infof("Probing target %q with module %q", target, moduleName)
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collector.NewMongodbCollector(opts))
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
@@ -26,34 +26,45 @@ type MongoSessionOpts struct {
 	TLSHostnameValidation bool
 	TLSAuth               bool
 	UserName              string
+	Password              string
 	AuthMechanism         string
 	SocketTimeout         time.Duration
 }
 
 // MongoSession creates a Mongo session
 func MongoSession(opts MongoSessionOpts) *mgo.Session {
-	dialInfo, err := mgo.ParseURL(opts.URI)
+	session, err := DialMongoSession(opts)
 	if err != nil {
-		glog.Errorf("Cannot connect to server using url %s: %s", opts.URI, err)
+		glog.Errorf("%s", err)
 		return nil
 	}
+	return session
+}
+
+// DialMongoSession creates a Mongo session, returning the reason when it can't.
+func DialMongoSession(opts MongoSessionOpts) (*mgo.Session, error) {
+	dialInfo, err := mgo.ParseURL(opts.URI)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to server using url %s: %s", opts.URI, err)
+	}
 
 	dialInfo.Direct = true // Force direct connection
 	dialInfo.Timeout = dialMongodbTimeout
 	if opts.UserName != "" {
 		dialInfo.Username = opts.UserName
 	}
+	if opts.Password != "" {
+		dialInfo.Password = opts.Password
+	}
 
 	cred, err := opts.configureDialInfoIfRequired(dialInfo)
 	if err != nil {
-		glog.Errorf("%s", err)
-		return nil
+		return nil, err
 	}
 
 	session, err := mgo.DialWithInfo(dialInfo)
 	if err != nil {
-		glog.Errorf("Cannot connect to server using url %s: %s", opts.URI, err)
-		return nil
+		return nil, fmt.Errorf("Cannot connect to server using url %s: %s", opts.URI, err)
 	}
 
 	// For direct connection, we need to set mode to be Monotonic (similar to SecondaryPreferred) or
@@ -62,14 +73,14 @@ func MongoSession(opts MongoSessionOpts) *mgo.Session {
 
 	if cred != nil {
 		if err := session.Login(cred); err != nil {
-			glog.Errorf("Cannot login to server using TLS credential: %s", err)
-			return nil
+			session.Close()
+			return nil, fmt.Errorf("Cannot login to server using TLS credential: %s", err)
 		}
 	}
 
 	session.SetSyncTimeout(syncMongodbTimeout)
 	session.SetSocketTimeout(opts.SocketTimeout)
-	return session
+	return session, nil
 }
 
 func (opts MongoSessionOpts) configureDialInfoIfRequired(dialInfo *mgo.DialInfo) (*mgo.Credential, error) {
@@ -0,0 +1,242 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sessionIdleTimeout  = 10 * time.Minute
+	minReconnectBackoff = 1 * time.Second
+	maxReconnectBackoff = 1 * time.Minute
+	// sessionPingTimeout bounds the ping checking that a session is alive, whatever the socket
+	// timeout of the target, so that a hung socket doesn't hold up every scrape of the target.
+	sessionPingTimeout = 5 * time.Second
+)
+
+var (
+	sessionsOpenDesc = prometheus.NewDesc(
+		"mongodb_exporter_sessions_open",
+		"The number of long-lived sessions the exporter keeps open to MongoDB targets.",
+		nil, nil,
+	)
+)
+
+// SessionManager keeps one authenticated session per target alive and hands out copies of it,
+// so that scrapes don't have to dial, handshake and login every time.
+type SessionManager struct {
+	lock     sync.Mutex
+	sessions map[MongoSessionOpts]*managedSession
+	open     int64
+
+	dialsTotal       *prometheus.CounterVec
+	disconnectsTotal *prometheus.CounterVec
+}
+
+// managedSession is the long-lived session of a single target.
+type managedSession struct {
+	// lastUsed is guarded by the lock of the manager, everything else by the session lock.
+	lastUsed time.Time
+
+	lock    sync.Mutex
+	target  string
+	session *mgo.Session
+
+	// consecutive failed dials and when the next dial may be attempted.
+	failures int
+	nextDial time.Time
+}
+
+// NewSessionManager returns a new instance of a SessionManager.
+func NewSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions: make(map[MongoSessionOpts]*managedSession),
+		dialsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "mongodb_exporter",
+			Subsystem: "session",
+			Name:      "dials_total",
+			Help:      "The total number of times a session was dialed to a target, by result.",
+		}, []string{"target", "result"}),
+		disconnectsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "mongodb_exporter",
+			Subsystem: "session",
+			Name:      "disconnects_total",
+			Help:      "The total number of times a long-lived session to a target was found dead and dropped.",
+		}, []string{"target"}),
+	}
+}
+
+// Session returns a copy of the long-lived session for the given options, dialing it first when
+// there is none or the existing one stopped answering. The caller must close the returned session.
+func (m *SessionManager) Session(opts MongoSessionOpts) (*mgo.Session, error) {
+	m.lock.Lock()
+	managed, ok := m.sessions[opts]
+	if !ok {
+		managed = &managedSession{target: sessionTarget(opts.URI)}
+		m.sessions[opts] = managed
+	}
+	managed.lastUsed = time.Now()
+	idle := m.removeIdle()
+	m.lock.Unlock()
+
+	for _, idleSession := range idle {
+		m.closeSession(idleSession)
+	}
+
+	// The session is pinged without holding its lock.
+	managed.lock.Lock()
+	current := managed.session
+	managed.lock.Unlock()
+	if current != nil {
+		err := pingSession(current)
+		if err == nil {
+			return current.Copy(), nil
+		}
+
+		managed.lock.Lock()
+		// Another caller may have replaced the session meanwhile.
+		if managed.session == current {
+			glog.Warningf("Dropping dead session to %s: %s", managed.target, err)
+			managed.session.Close()
+			managed.session = nil
+			atomic.AddInt64(&m.open, -1)
+			m.disconnectsTotal.WithLabelValues(managed.target).Inc()
+		}
+		managed.lock.Unlock()
+	}
+
+	managed.lock.Lock()
+	defer managed.lock.Unlock()
+
+	if managed.session != nil {
+		return managed.session.Copy(), nil
+	}
+
+	if now := time.Now(); now.Before(managed.nextDial) {
+		return nil, fmt.Errorf("Not dialing %s again before %s after %d failed attempts", managed.target, managed.nextDial.Format(time.RFC3339), managed.failures)
+	}
+
+	session, err := DialMongoSession(opts)
+	if err != nil {
+		managed.failures++
+		managed.nextDial = time.Now().Add(reconnectBackoff(managed.failures))
+		m.dialsTotal.WithLabelValues(managed.target, "failure").Inc()
+		return nil, err
+	}
+
+	managed.failures = 0
+	managed.nextDial = time.Time{}
+	managed.session = session
+	atomic.AddInt64(&m.open, 1)
+	m.dialsTotal.WithLabelValues(managed.target, "success").Inc()
+	return session.Copy(), nil
+}
+
+// pingSession pings a copy of the session with a short socket timeout.
+func pingSession(session *mgo.Session) error {
+	ping := session.Copy()
+	defer ping.Close()
+	ping.SetSocketTimeout(sessionPingTimeout)
+	return ping.Ping()
+}
+
+// removeIdle forgets about the targets whose sessions weren't used for a while, e.g. because they
+// are no longer probed, and returns them so that they can be closed. The metrics of the targets
+// which have no session left are deleted. The caller must hold m.lock.
+func (m *SessionManager) removeIdle() []*managedSession {
+	var idle []*managedSession
+	deadline := time.Now().Add(-sessionIdleTimeout)
+	for opts, managed := range m.sessions {
+		if managed.lastUsed.Before(deadline) {
+			idle = append(idle, managed)
+			delete(m.sessions, opts)
+		}
+	}
+
+	for _, managed := range idle {
+		if m.hasTarget(managed.target) {
+			continue
+		}
+		m.dialsTotal.DeleteLabelValues(managed.target, "success")
+		m.dialsTotal.DeleteLabelValues(managed.target, "failure")
+		m.disconnectsTotal.DeleteLabelValues(managed.target)
+	}
+	return idle
+}
+
+// hasTarget tells whether a session to the target is kept, e.g. with other credentials. The
+// caller must hold m.lock.
+func (m *SessionManager) hasTarget(target string) bool {
+	for _, managed := range m.sessions {
+		if managed.target == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SessionManager) closeSession(managed *managedSession) {
+	managed.lock.Lock()
+	defer managed.lock.Unlock()
+
+	if managed.session != nil {
+		managed.session.Close()
+		managed.session = nil
+		atomic.AddInt64(&m.open, -1)
+	}
+}
+
+// Close closes all the sessions kept by the manager.
+func (m *SessionManager) Close() {
+	m.lock.Lock()
+	sessions := m.sessions
+	m.sessions = make(map[MongoSessionOpts]*managedSession)
+	m.lock.Unlock()
+
+	for _, managed := range sessions {
+		m.closeSession(managed)
+	}
+}
+
+// Describe describes the session manager metrics for prometheus.
+func (m *SessionManager) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsOpenDesc
+	m.dialsTotal.Describe(ch)
+	m.disconnectsTotal.Describe(ch)
+}
+
+// Collect collects the session manager metrics.
+func (m *SessionManager) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(sessionsOpenDesc, prometheus.GaugeValue, float64(atomic.LoadInt64(&m.open)))
+	m.dialsTotal.Collect(ch)
+	m.disconnectsTotal.Collect(ch)
+}
+
+// reconnectBackoff returns how long to wait before dialing again after the given number of
+// consecutive failures.
+func reconnectBackoff(failures int) time.Duration {
+	backoff := minReconnectBackoff
+	for i := 1; i < failures && backoff < maxReconnectBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxReconnectBackoff {
+		backoff = maxReconnectBackoff
+	}
+	return backoff
+}
+
+// sessionTarget returns the hosts of the given URI, without any credentials, to be used as a label.
+func sessionTarget(uri string) string {
+	dialInfo, err := mgo.ParseURL(uri)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(dialInfo.Addrs, ",")
+}
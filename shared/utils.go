@@ -28,6 +28,18 @@ func ParameterizeString(text string) string {
 	return strings.ToLower(result)
 }
 
+// LowerCamelCase converts the given snakecase/underscore text to lowerCamelCase, e.g.
+// "in_write_lock" to "inWriteLock".
+func LowerCamelCase(text string) string {
+	parts := strings.Split(text, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
 // LoadCertificatesFrom returns certificates for a given pem file
 func LoadCertificatesFrom(pemFile string) (*x509.CertPool, error) {
 	caCert, err := ioutil.ReadFile(pemFile)
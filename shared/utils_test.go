@@ -39,3 +39,21 @@ func Test_ParameterizeString(t *testing.T) {
 		}
 	}
 }
+
+func Test_LowerCamelCase(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+	}{
+		{in: "regular", out: "regular"},
+		{in: "in_write_lock", out: "inWriteLock"},
+		{in: "page_faults", out: "pageFaults"},
+		{in: "", out: ""},
+	}
+
+	for _, test := range cases {
+		if out := LowerCamelCase(test.in); out != test.out {
+			t.Errorf("expected %s but got %s", test.out, out)
+		}
+	}
+}